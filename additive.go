@@ -0,0 +1,55 @@
+package saferith
+
+import (
+	"errors"
+	"io"
+)
+
+// SplitAdditive splits x into n additive shares modulo m, reading randomness from r.
+//
+// The shares sum to x modulo m, with any n - 1 of them being uniformly random, and
+// thus revealing nothing about x. This is the sharing used by many MPC protocols.
+// The value of x isn't leaked, only n, and the size of m.
+//
+// Each share has the same capacity as the modulus. An error is returned if n < 1,
+// or reading from r fails.
+func SplitAdditive(x *Nat, n int, m *Modulus, r io.Reader) ([]*Nat, error) {
+	if n < 1 {
+		return nil, errors.New("need at least one share")
+	}
+	shares := make([]*Nat, n)
+	last := new(Nat).Mod(x, m)
+	for i := 0; i < n-1; i++ {
+		share, err := new(Nat).Rand(m, r)
+		if err != nil {
+			// The shares so far, along with last, would reveal x
+			if zeroizing() {
+				for _, s := range shares[:i] {
+					s.Clear()
+				}
+				last.Clear()
+			}
+			return nil, err
+		}
+		last.ModSub(last, share, m)
+		shares[i] = share
+	}
+	shares[n-1] = last
+	wipeTemporaries(last)
+	return shares, nil
+}
+
+// CombineAdditive recombines additive shares modulo m, returning their sum.
+//
+// This is the inverse of SplitAdditive. The values of the shares aren't leaked.
+//
+// The capacity of the resulting number matches the capacity of the modulus.
+func CombineAdditive(shares []*Nat, m *Modulus) *Nat {
+	out := new(Nat).SetUint64(0)
+	out.Mod(out, m)
+	for _, share := range shares {
+		out.ModAdd(out, share, m)
+	}
+	wipeTemporaries(out)
+	return out
+}
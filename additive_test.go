@@ -0,0 +1,86 @@
+package saferith
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"testing/quick"
+)
+
+func testAdditiveRoundTrip(x Nat, m Modulus, n uint8, seed int64) bool {
+	count := 1 + int(n%16)
+	shares, err := SplitAdditive(&x, count, &m, rand.New(rand.NewSource(seed)))
+	if err != nil || len(shares) != count {
+		return false
+	}
+	for _, share := range shares {
+		if !share.checkInvariants() || share.AnnouncedLen() != m.BitLen() {
+			return false
+		}
+	}
+	return CombineAdditive(shares, &m).Eq(new(Nat).Mod(&x, &m)) == 1
+}
+
+func TestAdditiveRoundTrip(t *testing.T) {
+	err := quick.Check(testAdditiveRoundTrip, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSplitAdditiveSharesDiffer(t *testing.T) {
+	m := ModulusFromBytes(modulus2048())
+	x := new(Nat).SetUint64(42)
+	shares, err := SplitAdditive(x, 3, m, rand.New(rand.NewSource(0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// With a large modulus, no share should just be x
+	for i, share := range shares {
+		if share.Eq(new(Nat).Mod(x, m)) == 1 {
+			t.Errorf("share %d is the secret", i)
+		}
+	}
+}
+
+func TestSplitAdditiveErrors(t *testing.T) {
+	m := ModulusFromUint64(13)
+	x := new(Nat).SetUint64(5)
+	if _, err := SplitAdditive(x, 0, m, rand.New(rand.NewSource(0))); err == nil {
+		t.Errorf("0 shares should be rejected")
+	}
+	if _, err := SplitAdditive(x, 2, m, bytes.NewReader(nil)); err == nil {
+		t.Errorf("reader errors should be returned")
+	}
+	// A single share needs no randomness
+	shares, err := SplitAdditive(x, 1, m, bytes.NewReader(nil))
+	if err != nil || shares[0].Eq(x.Mod(x, m)) != 1 {
+		t.Errorf("%+v != %+v", x, shares)
+	}
+}
+
+func TestAdditiveZeroization(t *testing.T) {
+	SetZeroization(true)
+	defer SetZeroization(false)
+	m := ModulusFromBytes(modulus2048())
+	x := new(Nat).SetBytes(ones())
+	shares, err := SplitAdditive(x, 4, m, rand.New(rand.NewSource(0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	combined := CombineAdditive(shares, m)
+	if combined.Eq(new(Nat).Mod(x, m)) != 1 {
+		t.Errorf("%+v != %+v", x, combined)
+	}
+	// The running sums are computed in the unused capacity of these results
+	for _, z := range []*Nat{shares[len(shares)-1], combined} {
+		for _, limb := range z.limbs[len(z.limbs):cap(z.limbs)] {
+			if limb != 0 {
+				t.Errorf("unused limb %+v was not cleared", limb)
+			}
+		}
+	}
+	if _, err := SplitAdditive(x, 4, m, bytes.NewReader(make([]byte, 300))); err == nil {
+		t.Errorf("reader errors should be returned")
+	}
+}
@@ -2,9 +2,28 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE_go file.
 
-// +build !math_big_pure_go
+// +build !math_big_pure_go,!safenum_purego
 
 package saferith
 
-// This should be feature detected, but we can't use the internal/cpu package
-var support_adx = false
+// support_adx is true when the CPU supports the ADX and BMI2 extensions,
+// allowing addMulVVW to use MULX, ADCX, and ADOX.
+var support_adx = detectADX()
+
+// implemented in cpu_amd64.s
+func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+
+// detectADX checks for ADX and BMI2 support using CPUID.
+//
+// We can't use the internal/cpu package, like math/big does, so we query
+// the extended feature flags ourselves.
+func detectADX() bool {
+	maxLeaf, _, _, _ := cpuid(0, 0)
+	if maxLeaf < 7 {
+		return false
+	}
+	_, ebx, _, _ := cpuid(7, 0)
+	const bmi2 = 1 << 8
+	const adx = 1 << 19
+	return ebx&bmi2 != 0 && ebx&adx != 0
+}
@@ -0,0 +1,100 @@
+//go:build amd64 && !math_big_pure_go && !safenum_purego
+// +build amd64,!math_big_pure_go,!safenum_purego
+
+package saferith
+
+import (
+	"math/big"
+	"testing"
+	"testing/quick"
+)
+
+// withADX runs f with the ADX path of addMulVVW enabled or disabled
+func withADX(enabled bool, f func()) {
+	old := support_adx
+	support_adx = enabled
+	defer func() { support_adx = old }()
+	f()
+}
+
+// Tests both paths of addMulVVW, when the machine supports ADX
+func TestAddMulVVWADX(t *testing.T) {
+	paths := []bool{false}
+	if support_adx {
+		paths = append(paths, true)
+	} else {
+		t.Log("ADX is not supported, only testing the fallback")
+	}
+	for _, enabled := range paths {
+		withADX(enabled, func() {
+			// Lengths around 8 exercise both the unrolled loop, and the remainder
+			config := &quick.Config{MaxCount: 1000}
+			if err := quick.Check(testAddMulVVWMatchesGeneric, config); err != nil {
+				t.Errorf("adx = %v: %v", enabled, err)
+			}
+			for n := 0; n < 20; n++ {
+				z := make([]Word, n)
+				x := make([]Word, n)
+				for i := range x {
+					z[i] = ^Word(0)
+					x[i] = ^Word(0)
+				}
+				if !testAddMulVVWMatchesGeneric(z, x, ^Word(0)) {
+					t.Errorf("adx = %v: mismatch with %d limbs", enabled, n)
+				}
+			}
+		})
+	}
+}
+
+// benchmarkExp4096ADX benchmarks an RSA-4096 sized exponentiation, with a full size exponent
+func benchmarkExp4096ADX(b *testing.B, enabled bool, fill byte) {
+	if enabled && !support_adx {
+		b.Skip("ADX is not supported")
+	}
+	bytes := make([]byte, 512)
+	for i := range bytes {
+		bytes[i] = fill
+	}
+	m := ModulusFromBytes(bytes)
+	x := new(Nat).SetBytes(bytes[1:])
+	e := new(Nat).SetBytes(bytes[1:])
+	m.Precompute()
+	withADX(enabled, func() {
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			resultNat = *new(Nat).Exp(x, e, m)
+		}
+	})
+}
+
+func BenchmarkExp4096NatADX(b *testing.B) {
+	benchmarkExp4096ADX(b, true, 0xFD)
+}
+
+func BenchmarkExp4096NatNoADX(b *testing.B) {
+	benchmarkExp4096ADX(b, false, 0xFD)
+}
+
+func BenchmarkExp4096NatEvenADX(b *testing.B) {
+	benchmarkExp4096ADX(b, true, 0xFE)
+}
+
+func BenchmarkExp4096NatEvenNoADX(b *testing.B) {
+	benchmarkExp4096ADX(b, false, 0xFE)
+}
+
+// For comparison, the same exponentiation with math/big
+func BenchmarkExp4096Big(b *testing.B) {
+	bytes := make([]byte, 512)
+	for i := range bytes {
+		bytes[i] = 0xFD
+	}
+	m := new(big.Int).SetBytes(bytes)
+	x := new(big.Int).SetBytes(bytes[1:])
+	e := new(big.Int).SetBytes(bytes[1:])
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		new(big.Int).Exp(x, e, m)
+	}
+}
@@ -2,8 +2,8 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE_go file.
 
-//go:build !math_big_pure_go
-// +build !math_big_pure_go
+//go:build !math_big_pure_go && !safenum_purego
+// +build !math_big_pure_go,!safenum_purego
 
 package saferith
 
@@ -2,33 +2,278 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-//go:build !math_big_pure_go && loong64
+//go:build !math_big_pure_go && !safenum_purego && loong64
 
 #include "textflag.h"
 
 // This file provides fast assembly versions for the elementary
 // arithmetic operations on vectors implemented in arith.go.
+//
+// LoongArch has no carry flag, so carries are materialized in a register,
+// using SGTU after each addition.
 
+// func mulWW(x, y Word) (z1, z0 Word)
+TEXT ·mulWW(SB),NOSPLIT,$0
+	MOVV	x+0(FP), R4
+	MOVV	y+8(FP), R5
+	MULHVU	R4, R5, R6
+	MULV	R4, R5, R7
+	MOVV	R6, z1+16(FP)
+	MOVV	R7, z0+24(FP)
+	RET
+
+// func addVV(z, x, y []Word) (c Word)
 TEXT ·addVV(SB),NOSPLIT,$0
-	JMP ·addVV_g(SB)
+	MOVV	z_len+8(FP), R4
+	MOVV	x_base+24(FP), R5
+	MOVV	y_base+48(FP), R6
+	MOVV	z_base+0(FP), R7
+	// compute unrolled loop lengths
+	AND	$3, R4, R8
+	SRLV	$2, R4
+	XOR	R28, R28	// clear carry
+loop1:
+	BEQ	R8, loop1done
+loop1cont:
+	// unroll 1X
+	MOVV	0(R5), R9
+	MOVV	0(R6), R10
+	ADDVU	R10, R9	// ADCS R10, R9, R9 (cr=R28)
+	SGTU	R10, R9, R30	// ...
+	ADDVU	R28, R9	// ...
+	SGTU	R28, R9, R28	// ...
+	ADDVU	R30, R28	// ...
+	MOVV	R9, 0(R7)
+	ADDVU	$8, R5
+	ADDVU	$8, R6
+	ADDVU	$8, R7
+	SUBVU	$1, R8
+	BNE	R8, loop1cont
+loop1done:
+loop4:
+	BEQ	R4, loop4done
+loop4cont:
+	// unroll 4X
+	MOVV	0(R5), R8
+	MOVV	8(R5), R9
+	MOVV	16(R5), R10
+	MOVV	24(R5), R11
+	MOVV	0(R6), R12
+	MOVV	8(R6), R13
+	MOVV	16(R6), R14
+	MOVV	24(R6), R15
+	ADDVU	R12, R8	// ADCS R12, R8, R8 (cr=R28)
+	SGTU	R12, R8, R30	// ...
+	ADDVU	R28, R8	// ...
+	SGTU	R28, R8, R28	// ...
+	ADDVU	R30, R28	// ...
+	ADDVU	R13, R9	// ADCS R13, R9, R9 (cr=R28)
+	SGTU	R13, R9, R30	// ...
+	ADDVU	R28, R9	// ...
+	SGTU	R28, R9, R28	// ...
+	ADDVU	R30, R28	// ...
+	ADDVU	R14, R10	// ADCS R14, R10, R10 (cr=R28)
+	SGTU	R14, R10, R30	// ...
+	ADDVU	R28, R10	// ...
+	SGTU	R28, R10, R28	// ...
+	ADDVU	R30, R28	// ...
+	ADDVU	R15, R11	// ADCS R15, R11, R11 (cr=R28)
+	SGTU	R15, R11, R30	// ...
+	ADDVU	R28, R11	// ...
+	SGTU	R28, R11, R28	// ...
+	ADDVU	R30, R28	// ...
+	MOVV	R8, 0(R7)
+	MOVV	R9, 8(R7)
+	MOVV	R10, 16(R7)
+	MOVV	R11, 24(R7)
+	ADDVU	$32, R5
+	ADDVU	$32, R6
+	ADDVU	$32, R7
+	SUBVU	$1, R4
+	BNE	R4, loop4cont
+loop4done:
+	MOVV	R28, c+72(FP)
+	RET
 
+// func subVV(z, x, y []Word) (c Word)
 TEXT ·subVV(SB),NOSPLIT,$0
-	JMP ·subVV_g(SB)
+	MOVV	z_len+8(FP), R4
+	MOVV	x_base+24(FP), R5
+	MOVV	y_base+48(FP), R6
+	MOVV	z_base+0(FP), R7
+	// compute unrolled loop lengths
+	AND	$3, R4, R8
+	SRLV	$2, R4
+	XOR	R28, R28	// clear carry
+loop1:
+	BEQ	R8, loop1done
+loop1cont:
+	// unroll 1X
+	MOVV	0(R5), R9
+	MOVV	0(R6), R10
+	SGTU	R28, R9, R30	// SBCS R10, R9, R9
+	SUBVU	R28, R9	// ...
+	SGTU	R10, R9, R28	// ...
+	SUBVU	R10, R9	// ...
+	ADDVU	R30, R28	// ...
+	MOVV	R9, 0(R7)
+	ADDVU	$8, R5
+	ADDVU	$8, R6
+	ADDVU	$8, R7
+	SUBVU	$1, R8
+	BNE	R8, loop1cont
+loop1done:
+loop4:
+	BEQ	R4, loop4done
+loop4cont:
+	// unroll 4X
+	MOVV	0(R5), R8
+	MOVV	8(R5), R9
+	MOVV	16(R5), R10
+	MOVV	24(R5), R11
+	MOVV	0(R6), R12
+	MOVV	8(R6), R13
+	MOVV	16(R6), R14
+	MOVV	24(R6), R15
+	SGTU	R28, R8, R30	// SBCS R12, R8, R8
+	SUBVU	R28, R8	// ...
+	SGTU	R12, R8, R28	// ...
+	SUBVU	R12, R8	// ...
+	ADDVU	R30, R28	// ...
+	SGTU	R28, R9, R30	// SBCS R13, R9, R9
+	SUBVU	R28, R9	// ...
+	SGTU	R13, R9, R28	// ...
+	SUBVU	R13, R9	// ...
+	ADDVU	R30, R28	// ...
+	SGTU	R28, R10, R30	// SBCS R14, R10, R10
+	SUBVU	R28, R10	// ...
+	SGTU	R14, R10, R28	// ...
+	SUBVU	R14, R10	// ...
+	ADDVU	R30, R28	// ...
+	SGTU	R28, R11, R30	// SBCS R15, R11, R11
+	SUBVU	R28, R11	// ...
+	SGTU	R15, R11, R28	// ...
+	SUBVU	R15, R11	// ...
+	ADDVU	R30, R28	// ...
+	MOVV	R8, 0(R7)
+	MOVV	R9, 8(R7)
+	MOVV	R10, 16(R7)
+	MOVV	R11, 24(R7)
+	ADDVU	$32, R5
+	ADDVU	$32, R6
+	ADDVU	$32, R7
+	SUBVU	$1, R4
+	BNE	R4, loop4cont
+loop4done:
+	MOVV	R28, c+72(FP)
+	RET
 
 TEXT ·addVW(SB),NOSPLIT,$0
-	JMP ·addVW_g(SB)
+	JMP	·addVW_g(SB)
 
 TEXT ·subVW(SB),NOSPLIT,$0
-	JMP ·subVW_g(SB)
+	JMP	·subVW_g(SB)
 
 TEXT ·shlVU(SB),NOSPLIT,$0
-	JMP ·shlVU_g(SB)
+	JMP	·shlVU_g(SB)
 
 TEXT ·shrVU(SB),NOSPLIT,$0
-	JMP ·shrVU_g(SB)
+	JMP	·shrVU_g(SB)
 
+// func mulAddVWW(z, x []Word, y, r Word) (c Word)
 TEXT ·mulAddVWW(SB),NOSPLIT,$0
-	JMP ·mulAddVWW_g(SB)
+	MOVV	y+48(FP), R4
+	MOVV	r+56(FP), R5
+	MOVV	z_len+8(FP), R6
+	MOVV	x_base+24(FP), R7
+	MOVV	z_base+0(FP), R8
+	// compute unrolled loop lengths
+	AND	$3, R6, R9
+	SRLV	$2, R6
+loop1:
+	BEQ	R9, loop1done
+loop1cont:
+	// unroll 1X
+	MOVV	0(R7), R10
+	// synthetic carry, one column at a time
+	MULV	R4, R10, R11
+	MULHVU	R4, R10, R12
+	ADDVU	R5, R11, R10	// ADDS R5, R11, R10 (cr=R28)
+	SGTU	R5, R10, R28	// ...
+	ADDVU	R28, R12, R5	// ADC $0, R12, R5
+	MOVV	R10, 0(R8)
+	ADDVU	$8, R7
+	ADDVU	$8, R8
+	SUBVU	$1, R9
+	BNE	R9, loop1cont
+loop1done:
+loop4:
+	BEQ	R6, loop4done
+loop4cont:
+	// unroll 4X
+	MOVV	0(R7), R9
+	MOVV	8(R7), R10
+	MOVV	16(R7), R11
+	MOVV	24(R7), R12
+	// synthetic carry, one column at a time
+	MULV	R4, R9, R13
+	MULHVU	R4, R9, R14
+	ADDVU	R5, R13, R9	// ADDS R5, R13, R9 (cr=R28)
+	SGTU	R5, R9, R28	// ...
+	ADDVU	R28, R14, R5	// ADC $0, R14, R5
+	MULV	R4, R10, R13
+	MULHVU	R4, R10, R14
+	ADDVU	R5, R13, R10	// ADDS R5, R13, R10 (cr=R28)
+	SGTU	R5, R10, R28	// ...
+	ADDVU	R28, R14, R5	// ADC $0, R14, R5
+	MULV	R4, R11, R13
+	MULHVU	R4, R11, R14
+	ADDVU	R5, R13, R11	// ADDS R5, R13, R11 (cr=R28)
+	SGTU	R5, R11, R28	// ...
+	ADDVU	R28, R14, R5	// ADC $0, R14, R5
+	MULV	R4, R12, R13
+	MULHVU	R4, R12, R14
+	ADDVU	R5, R13, R12	// ADDS R5, R13, R12 (cr=R28)
+	SGTU	R5, R12, R28	// ...
+	ADDVU	R28, R14, R5	// ADC $0, R14, R5
+	MOVV	R9, 0(R8)
+	MOVV	R10, 8(R8)
+	MOVV	R11, 16(R8)
+	MOVV	R12, 24(R8)
+	ADDVU	$32, R7
+	ADDVU	$32, R8
+	SUBVU	$1, R6
+	BNE	R6, loop4cont
+loop4done:
+	MOVV	R5, c+64(FP)
+	RET
 
+// func addMulVVW(z, x []Word, y Word) (c Word)
 TEXT ·addMulVVW(SB),NOSPLIT,$0
-	JMP ·addMulVVW_g(SB)
+	MOVV	y+48(FP), R4
+	MOVV	z_len+8(FP), R6
+	MOVV	x_base+24(FP), R7
+	MOVV	z_base+0(FP), R8
+	MOVV	R0, R5	// c = 0
+	BEQ	R6, done
+loop:
+	MOVV	0(R7), R9
+	MOVV	0(R8), R10
+	MULV	R4, R9, R11
+	MULHVU	R4, R9, R12
+	// (R12, R11) += z[i]
+	ADDVU	R10, R11
+	SGTU	R10, R11, R13
+	ADDVU	R13, R12
+	// (R12, R11) += c, which can't overflow the high word
+	ADDVU	R5, R11
+	SGTU	R5, R11, R13
+	ADDVU	R13, R12, R5
+	MOVV	R11, 0(R8)
+	ADDVU	$8, R7
+	ADDVU	$8, R8
+	SUBVU	$1, R6
+	BNE	R6, loop
+done:
+	MOVV	R5, c+56(FP)
+	RET
@@ -2,8 +2,8 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE_go file.
 
-//go:build !math_big_pure_go && (mips64 || mips64le)
-// +build !math_big_pure_go
+//go:build !math_big_pure_go && !safenum_purego && (mips64 || mips64le)
+// +build !math_big_pure_go,!safenum_purego
 // +build mips64 mips64le
 
 #include "textflag.h"
@@ -2,8 +2,8 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE_go file.
 
-//go:build !math_big_pure_go && (ppc64 || ppc64le)
-// +build !math_big_pure_go
+//go:build !math_big_pure_go && !safenum_purego && (ppc64 || ppc64le)
+// +build !math_big_pure_go,!safenum_purego
 // +build ppc64 ppc64le
 
 #include "textflag.h"
@@ -2,13 +2,17 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE_go file.
 
-//go:build !math_big_pure_go && riscv64
-// +build !math_big_pure_go,riscv64
+//go:build !math_big_pure_go && !safenum_purego && riscv64
+// +build !math_big_pure_go,!safenum_purego,riscv64
 
 #include "textflag.h"
 
 // This file provides fast assembly versions for the elementary
 // arithmetic operations on vectors implemented in arith.go.
+//
+// RISC-V has no carry flag, so carries are materialized with SLTU after each
+// addition. These routines only need the base ISA, and MUL / MULHU, which are
+// part of both the M and Zmmul extensions.
 
 // func mulWW(x, y Word) (z1, z0 Word)
 TEXT ·mulWW(SB),NOSPLIT,$0
@@ -20,28 +24,258 @@ TEXT ·mulWW(SB),NOSPLIT,$0
 	MOV	X8, z0+24(FP)
 	RET
 
-
+// func addVV(z, x, y []Word) (c Word)
 TEXT ·addVV(SB),NOSPLIT,$0
-	JMP ·addVV_g(SB)
+	MOV	z_len+8(FP), X5
+	MOV	x_base+24(FP), X6
+	MOV	y_base+48(FP), X7
+	MOV	z_base+0(FP), X8
+	// compute unrolled loop lengths
+	AND	$3, X5, X9
+	SRL	$2, X5
+	XOR	X28, X28	// clear carry
+loop1:
+	BEQZ	X9, loop1done
+loop1cont:
+	// unroll 1X
+	MOV	0(X6), X10
+	MOV	0(X7), X11
+	ADD	X11, X10	// ADCS X11, X10, X10 (cr=X28)
+	SLTU	X11, X10, X31	// ...
+	ADD	X28, X10	// ...
+	SLTU	X28, X10, X28	// ...
+	ADD	X31, X28	// ...
+	MOV	X10, 0(X8)
+	ADD	$8, X6
+	ADD	$8, X7
+	ADD	$8, X8
+	SUB	$1, X9
+	BNEZ	X9, loop1cont
+loop1done:
+loop4:
+	BEQZ	X5, loop4done
+loop4cont:
+	// unroll 4X
+	MOV	0(X6), X9
+	MOV	8(X6), X10
+	MOV	16(X6), X11
+	MOV	24(X6), X12
+	MOV	0(X7), X13
+	MOV	8(X7), X14
+	MOV	16(X7), X15
+	MOV	24(X7), X16
+	ADD	X13, X9	// ADCS X13, X9, X9 (cr=X28)
+	SLTU	X13, X9, X31	// ...
+	ADD	X28, X9	// ...
+	SLTU	X28, X9, X28	// ...
+	ADD	X31, X28	// ...
+	ADD	X14, X10	// ADCS X14, X10, X10 (cr=X28)
+	SLTU	X14, X10, X31	// ...
+	ADD	X28, X10	// ...
+	SLTU	X28, X10, X28	// ...
+	ADD	X31, X28	// ...
+	ADD	X15, X11	// ADCS X15, X11, X11 (cr=X28)
+	SLTU	X15, X11, X31	// ...
+	ADD	X28, X11	// ...
+	SLTU	X28, X11, X28	// ...
+	ADD	X31, X28	// ...
+	ADD	X16, X12	// ADCS X16, X12, X12 (cr=X28)
+	SLTU	X16, X12, X31	// ...
+	ADD	X28, X12	// ...
+	SLTU	X28, X12, X28	// ...
+	ADD	X31, X28	// ...
+	MOV	X9, 0(X8)
+	MOV	X10, 8(X8)
+	MOV	X11, 16(X8)
+	MOV	X12, 24(X8)
+	ADD	$32, X6
+	ADD	$32, X7
+	ADD	$32, X8
+	SUB	$1, X5
+	BNEZ	X5, loop4cont
+loop4done:
+	MOV	X28, c+72(FP)
+	RET
 
+// func subVV(z, x, y []Word) (c Word)
 TEXT ·subVV(SB),NOSPLIT,$0
-	JMP ·subVV_g(SB)
+	MOV	z_len+8(FP), X5
+	MOV	x_base+24(FP), X6
+	MOV	y_base+48(FP), X7
+	MOV	z_base+0(FP), X8
+	// compute unrolled loop lengths
+	AND	$3, X5, X9
+	SRL	$2, X5
+	XOR	X28, X28	// clear carry
+loop1:
+	BEQZ	X9, loop1done
+loop1cont:
+	// unroll 1X
+	MOV	0(X6), X10
+	MOV	0(X7), X11
+	SLTU	X28, X10, X31	// SBCS X11, X10, X10
+	SUB	X28, X10	// ...
+	SLTU	X11, X10, X28	// ...
+	SUB	X11, X10	// ...
+	ADD	X31, X28	// ...
+	MOV	X10, 0(X8)
+	ADD	$8, X6
+	ADD	$8, X7
+	ADD	$8, X8
+	SUB	$1, X9
+	BNEZ	X9, loop1cont
+loop1done:
+loop4:
+	BEQZ	X5, loop4done
+loop4cont:
+	// unroll 4X
+	MOV	0(X6), X9
+	MOV	8(X6), X10
+	MOV	16(X6), X11
+	MOV	24(X6), X12
+	MOV	0(X7), X13
+	MOV	8(X7), X14
+	MOV	16(X7), X15
+	MOV	24(X7), X16
+	SLTU	X28, X9, X31	// SBCS X13, X9, X9
+	SUB	X28, X9	// ...
+	SLTU	X13, X9, X28	// ...
+	SUB	X13, X9	// ...
+	ADD	X31, X28	// ...
+	SLTU	X28, X10, X31	// SBCS X14, X10, X10
+	SUB	X28, X10	// ...
+	SLTU	X14, X10, X28	// ...
+	SUB	X14, X10	// ...
+	ADD	X31, X28	// ...
+	SLTU	X28, X11, X31	// SBCS X15, X11, X11
+	SUB	X28, X11	// ...
+	SLTU	X15, X11, X28	// ...
+	SUB	X15, X11	// ...
+	ADD	X31, X28	// ...
+	SLTU	X28, X12, X31	// SBCS X16, X12, X12
+	SUB	X28, X12	// ...
+	SLTU	X16, X12, X28	// ...
+	SUB	X16, X12	// ...
+	ADD	X31, X28	// ...
+	MOV	X9, 0(X8)
+	MOV	X10, 8(X8)
+	MOV	X11, 16(X8)
+	MOV	X12, 24(X8)
+	ADD	$32, X6
+	ADD	$32, X7
+	ADD	$32, X8
+	SUB	$1, X5
+	BNEZ	X5, loop4cont
+loop4done:
+	MOV	X28, c+72(FP)
+	RET
 
 TEXT ·addVW(SB),NOSPLIT,$0
-	JMP ·addVW_g(SB)
+	JMP	·addVW_g(SB)
 
 TEXT ·subVW(SB),NOSPLIT,$0
-	JMP ·subVW_g(SB)
+	JMP	·subVW_g(SB)
 
 TEXT ·shlVU(SB),NOSPLIT,$0
-	JMP ·shlVU_g(SB)
+	JMP	·shlVU_g(SB)
 
 TEXT ·shrVU(SB),NOSPLIT,$0
-	JMP ·shrVU_g(SB)
+	JMP	·shrVU_g(SB)
 
+// func mulAddVWW(z, x []Word, y, r Word) (c Word)
 TEXT ·mulAddVWW(SB),NOSPLIT,$0
-	JMP ·mulAddVWW_g(SB)
+	MOV	y+48(FP), X5
+	MOV	r+56(FP), X6
+	MOV	z_len+8(FP), X7
+	MOV	x_base+24(FP), X8
+	MOV	z_base+0(FP), X9
+	// compute unrolled loop lengths
+	AND	$3, X7, X10
+	SRL	$2, X7
+loop1:
+	BEQZ	X10, loop1done
+loop1cont:
+	// unroll 1X
+	MOV	0(X8), X11
+	// synthetic carry, one column at a time
+	MUL	X5, X11, X12
+	MULHU	X5, X11, X13
+	ADD	X6, X12, X11	// ADDS X6, X12, X11 (cr=X28)
+	SLTU	X6, X11, X28	// ...
+	ADD	X28, X13, X6	// ADC $0, X13, X6
+	MOV	X11, 0(X9)
+	ADD	$8, X8
+	ADD	$8, X9
+	SUB	$1, X10
+	BNEZ	X10, loop1cont
+loop1done:
+loop4:
+	BEQZ	X7, loop4done
+loop4cont:
+	// unroll 4X
+	MOV	0(X8), X10
+	MOV	8(X8), X11
+	MOV	16(X8), X12
+	MOV	24(X8), X13
+	// synthetic carry, one column at a time
+	MUL	X5, X10, X14
+	MULHU	X5, X10, X15
+	ADD	X6, X14, X10	// ADDS X6, X14, X10 (cr=X28)
+	SLTU	X6, X10, X28	// ...
+	ADD	X28, X15, X6	// ADC $0, X15, X6
+	MUL	X5, X11, X14
+	MULHU	X5, X11, X15
+	ADD	X6, X14, X11	// ADDS X6, X14, X11 (cr=X28)
+	SLTU	X6, X11, X28	// ...
+	ADD	X28, X15, X6	// ADC $0, X15, X6
+	MUL	X5, X12, X14
+	MULHU	X5, X12, X15
+	ADD	X6, X14, X12	// ADDS X6, X14, X12 (cr=X28)
+	SLTU	X6, X12, X28	// ...
+	ADD	X28, X15, X6	// ADC $0, X15, X6
+	MUL	X5, X13, X14
+	MULHU	X5, X13, X15
+	ADD	X6, X14, X13	// ADDS X6, X14, X13 (cr=X28)
+	SLTU	X6, X13, X28	// ...
+	ADD	X28, X15, X6	// ADC $0, X15, X6
+	MOV	X10, 0(X9)
+	MOV	X11, 8(X9)
+	MOV	X12, 16(X9)
+	MOV	X13, 24(X9)
+	ADD	$32, X8
+	ADD	$32, X9
+	SUB	$1, X7
+	BNEZ	X7, loop4cont
+loop4done:
+	MOV	X6, c+64(FP)
+	RET
 
+// func addMulVVW(z, x []Word, y Word) (c Word)
 TEXT ·addMulVVW(SB),NOSPLIT,$0
-	JMP ·addMulVVW_g(SB)
-
+	MOV	y+48(FP), X5
+	MOV	z_len+8(FP), X7
+	MOV	x_base+24(FP), X8
+	MOV	z_base+0(FP), X9
+	MOV	ZERO, X6	// c = 0
+	BEQZ	X7, done
+loop:
+	MOV	0(X8), X10
+	MOV	0(X9), X11
+	MUL	X5, X10, X12
+	MULHU	X5, X10, X13
+	// (X13, X12) += z[i]
+	ADD	X11, X12
+	SLTU	X11, X12, X14
+	ADD	X14, X13
+	// (X13, X12) += c, which can't overflow the high word
+	ADD	X6, X12
+	SLTU	X6, X12, X14
+	ADD	X14, X13, X6
+	MOV	X12, 0(X9)
+	ADD	$8, X8
+	ADD	$8, X9
+	SUB	$1, X7
+	BNEZ	X7, loop
+done:
+	MOV	X6, c+56(FP)
+	RET
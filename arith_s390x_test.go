@@ -2,8 +2,8 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE_go file.
 
-//go:build s390x && !math_big_pure_go
-// +build s390x,!math_big_pure_go
+//go:build s390x && !math_big_pure_go && !safenum_purego
+// +build s390x,!math_big_pure_go,!safenum_purego
 
 package big
 
@@ -0,0 +1,90 @@
+package saferith
+
+// checkBatch makes sure that the slices in a batch operation have matching lengths,
+// allocating any missing outputs.
+func checkBatch(out []*Nat, x []*Nat, y []*Nat) {
+	if len(out) != len(x) || len(out) != len(y) {
+		panic("batch operation: mismatched lengths")
+	}
+	for i := range out {
+		if out[i] == nil {
+			out[i] = new(Nat)
+		}
+	}
+}
+
+// BatchModMul calculates out[i] <- x[i] * y[i] mod m, for each i
+//
+// This produces the same results as calling ModMul for each element, but reuses
+// the same Scratch across the entire batch, avoiding allocations for each
+// multiplication.
+//
+// Each out[i] can alias x[i] or y[i], and any nil entries in out will be allocated.
+// This panics if the slices don't have the same length.
+func BatchModMul(out []*Nat, x []*Nat, y []*Nat, m *Modulus) {
+	checkBatch(out, x, y)
+	var s Scratch
+	for i := range out {
+		out[i].ModMulWithScratch(x[i], y[i], m, &s)
+	}
+}
+
+// BatchModAdd calculates out[i] <- x[i] + y[i] mod m, for each i
+//
+// Like BatchModMul, this gives the same results as calling ModAdd for each element,
+// but reuses the same scratch space across the entire batch.
+//
+// Each out[i] can alias x[i] or y[i], and any nil entries in out will be allocated.
+// This panics if the slices don't have the same length.
+func BatchModAdd(out []*Nat, x []*Nat, y []*Nat, m *Modulus) {
+	checkBatch(out, x, y)
+	size := len(m.nat.limbs)
+	var xModM, yModM Nat
+	scratch := make([]Word, size)
+	for i := range out {
+		xModM.Mod(x[i], m)
+		yModM.Mod(y[i], m)
+		z := out[i]
+		z.limbs = z.resizedLimbs(m.nat.announced)
+		// See ModAdd for why comparing the carries selects the right result
+		addCarry := addVV(z.limbs, xModM.limbs, yModM.limbs)
+		subCarry := subVV(scratch, z.limbs, m.nat.limbs)
+		ctCondCopy(ctEq(addCarry, subCarry), z.limbs, scratch)
+		z.reduced = m
+		z.announced = m.nat.announced
+	}
+}
+
+// BatchModInverse calculates out[i] <- x[i]^-1 mod m, for each i
+//
+// This uses Montgomery's trick, needing only a single inversion, along with
+// 3(n - 1) multiplications, which is much faster than inverting each element.
+// The returned Choice is 1 if every element was invertible. Otherwise, the
+// result is 0, and the contents of out are unspecified, since a single element
+// without an inverse poisons the entire batch.
+//
+// This only leaks the size of the batch, and the announced sizes of its elements.
+// Each out[i] can alias x[i], and any nil entries in out will be allocated.
+// This panics if the slices don't have the same length.
+func BatchModInverse(out []*Nat, x []*Nat, m *Modulus) Choice {
+	checkBatch(out, x, x)
+	if len(x) == 0 {
+		return 1
+	}
+	// prefix[i] = x[0] * ... * x[i]
+	prefix := make([]*Nat, len(x))
+	prefix[0] = new(Nat).Mod(x[0], m)
+	for i := 1; i < len(x); i++ {
+		prefix[i] = new(Nat).ModMul(prefix[i-1], x[i], m)
+	}
+	inv, ok := new(Nat).ModInverseChecked(prefix[len(x)-1], m)
+	// At each step, inv = (x[0] * ... * x[i])^-1
+	next := new(Nat)
+	for i := len(x) - 1; i > 0; i-- {
+		next.ModMul(inv, x[i], m)
+		out[i].ModMul(inv, prefix[i-1], m)
+		inv, next = next, inv
+	}
+	out[0].SetNat(inv)
+	return ok
+}
@@ -0,0 +1,119 @@
+package saferith
+
+import (
+	"testing"
+	"testing/quick"
+)
+
+func testBatchModMulMatchesModMul(a Nat, b Nat, c Nat, m Modulus) bool {
+	x := []*Nat{&a, &b, &c}
+	y := []*Nat{&c, &a, &b}
+	out := make([]*Nat, len(x))
+	BatchModMul(out, x, y, &m)
+	for i := range out {
+		if !out[i].checkInvariants() {
+			return false
+		}
+		if out[i].Eq(new(Nat).ModMul(x[i], y[i], &m)) != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBatchModMulMatchesModMul(t *testing.T) {
+	err := quick.Check(testBatchModMulMatchesModMul, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testBatchModAddMatchesModAdd(a Nat, b Nat, c Nat, m Modulus) bool {
+	x := []*Nat{&a, &b, &c}
+	y := []*Nat{&c, &a, &b}
+	out := make([]*Nat, len(x))
+	BatchModAdd(out, x, y, &m)
+	for i := range out {
+		if !out[i].checkInvariants() {
+			return false
+		}
+		if out[i].Eq(new(Nat).ModAdd(x[i], y[i], &m)) != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBatchModAddMatchesModAdd(t *testing.T) {
+	err := quick.Check(testBatchModAddMatchesModAdd, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBatchAliasing(t *testing.T) {
+	m := ModulusFromUint64(13)
+	x := []*Nat{new(Nat).SetUint64(5), new(Nat).SetUint64(12)}
+	y := []*Nat{new(Nat).SetUint64(7), new(Nat).SetUint64(12)}
+	BatchModMul(x, x, y, m)
+	for i, expected := range []uint64{35 % 13, 144 % 13} {
+		if x[i].Eq(new(Nat).SetUint64(expected)) != 1 {
+			t.Errorf("%+v != %+v", expected, x[i])
+		}
+	}
+	BatchModAdd(y, x, y, m)
+	for i, expected := range []uint64{(9 + 7) % 13, (1 + 12) % 13} {
+		if y[i].Eq(new(Nat).SetUint64(expected)) != 1 {
+			t.Errorf("%+v != %+v", expected, y[i])
+		}
+	}
+}
+
+func testBatchModInverseMatchesModInverse(a Nat, b Nat, c Nat, m Modulus) bool {
+	x := []*Nat{&a, &b, &c}
+	out := make([]*Nat, len(x))
+	ok := BatchModInverse(out, x, &m)
+	expectedOk := Choice(1)
+	for i := range x {
+		_, iOk := new(Nat).ModInverseChecked(x[i], &m)
+		expectedOk &= iOk
+	}
+	if ok != expectedOk {
+		return false
+	}
+	if ok == 1 {
+		for i := range x {
+			if !out[i].checkInvariants() || out[i].Eq(new(Nat).ModInverse(x[i], &m)) != 1 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestBatchModInverseMatchesModInverse(t *testing.T) {
+	err := quick.Check(testBatchModInverseMatchesModInverse, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBatchModInverseExamples(t *testing.T) {
+	m := ModulusFromUint64(13)
+	x := []*Nat{new(Nat).SetUint64(2), new(Nat).SetUint64(3), new(Nat).SetUint64(12)}
+	if BatchModInverse(x, x, m) != 1 {
+		t.Errorf("expected batch to be invertible")
+	}
+	for i, expected := range []uint64{7, 9, 12} {
+		if x[i].Eq(new(Nat).SetUint64(expected)) != 1 {
+			t.Errorf("%+v != %+v", expected, x[i])
+		}
+	}
+	x = []*Nat{new(Nat).SetUint64(2), new(Nat).SetUint64(26)}
+	if BatchModInverse(make([]*Nat, 2), x, m) != 0 {
+		t.Errorf("expected batch to not be invertible")
+	}
+	if BatchModInverse(nil, nil, m) != 1 {
+		t.Errorf("expected empty batch to be invertible")
+	}
+}
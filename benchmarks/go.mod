@@ -0,0 +1,12 @@
+module github.com/cronokirby/saferith/benchmarks
+
+go 1.23
+
+require (
+	filippo.io/bigmod v0.1.0
+	github.com/cronokirby/saferith v0.0.0
+)
+
+require golang.org/x/sys v0.11.0 // indirect
+
+replace github.com/cronokirby/saferith => ../
@@ -0,0 +1,241 @@
+// Package benchmarks sweeps the main operations of saferith over a range of sizes.
+//
+// This lives in its own module, so that the comparison with filippo.io/bigmod
+// doesn't add a dependency to saferith itself. Run from this directory with:
+//
+//	go test -bench . -count 10 > new.txt
+//	go test -bench . -count 10 -compare > compare.txt
+//
+// The output can then be fed to benchstat, with results split by size and implementation:
+//
+//	benchstat -col /impl compare.txt
+package benchmarks
+
+import (
+	"flag"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"filippo.io/bigmod"
+	"github.com/cronokirby/saferith"
+)
+
+var compare = flag.Bool("compare", false, "also benchmark math/big, and filippo.io/bigmod")
+
+var sizes = []int{256, 512, 1024, 2048, 4096, 8192}
+
+// operands holds big endian inputs of a given size, with x, y < m, and m odd
+type operands struct {
+	bits    int
+	x, y, m []byte
+}
+
+func newOperands(bits int) operands {
+	r := rand.New(rand.NewSource(int64(bits)))
+	random := func() []byte {
+		buf := make([]byte, bits/8)
+		r.Read(buf)
+		return buf
+	}
+	o := operands{bits: bits, x: random(), y: random(), m: random()}
+	// m has its top bit set, and is odd, and both x and y are below it
+	o.m[0] |= 0x80
+	o.m[len(o.m)-1] |= 1
+	o.x[0] &= 0x7F
+	o.y[0] &= 0x7F
+	return o
+}
+
+type impl struct {
+	name string
+	run  func(*testing.B, operands)
+}
+
+// sweep runs a benchmark for each size, and for each implementation, when comparing.
+//
+// The math/big and bigmod implementations can be nil, to skip them.
+func sweep(b *testing.B, safe, stdlib, bigmod func(*testing.B, operands)) {
+	impls := []impl{{"saferith", safe}}
+	if *compare {
+		impls = append(impls, impl{"big", stdlib}, impl{"bigmod", bigmod})
+	}
+	for _, bits := range sizes {
+		o := newOperands(bits)
+		for _, impl := range impls {
+			if impl.run == nil {
+				continue
+			}
+			run := impl.run
+			b.Run(fmt.Sprintf("bits=%d/impl=%s", bits, impl.name), func(b *testing.B) {
+				run(b, o)
+			})
+		}
+	}
+}
+
+// safeNats returns the operands as Nats, already reduced modulo m, like the other implementations
+func safeNats(o operands) (*saferith.Nat, *saferith.Nat, *saferith.Modulus) {
+	m := saferith.ModulusFromBytes(o.m)
+	x := new(saferith.Nat).SetBytes(o.x)
+	y := new(saferith.Nat).SetBytes(o.y)
+	return x.Mod(x, m), y.Mod(y, m), m
+}
+
+func bigInts(o operands) (*big.Int, *big.Int, *big.Int) {
+	return new(big.Int).SetBytes(o.x), new(big.Int).SetBytes(o.y), new(big.Int).SetBytes(o.m)
+}
+
+func bigmodNats(b *testing.B, o operands) (*bigmod.Nat, *bigmod.Nat, *bigmod.Modulus) {
+	m, err := bigmod.NewModulus(o.m)
+	if err != nil {
+		b.Fatal(err)
+	}
+	x, err := bigmod.NewNat().SetBytes(o.x, m)
+	if err != nil {
+		b.Fatal(err)
+	}
+	y, err := bigmod.NewNat().SetBytes(o.y, m)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return x, y, m
+}
+
+func BenchmarkAdd(b *testing.B) {
+	sweep(b, func(b *testing.B, o operands) {
+		x, y, _ := safeNats(o)
+		z := new(saferith.Nat)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			z.Add(x, y, o.bits+1)
+		}
+	}, func(b *testing.B, o operands) {
+		x, y, _ := bigInts(o)
+		z := new(big.Int)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			z.Add(x, y)
+		}
+	}, nil)
+}
+
+func BenchmarkModAdd(b *testing.B) {
+	sweep(b, func(b *testing.B, o operands) {
+		x, y, m := safeNats(o)
+		z := new(saferith.Nat)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			z.ModAdd(x, y, m)
+		}
+	}, func(b *testing.B, o operands) {
+		x, y, m := bigInts(o)
+		z := new(big.Int)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			z.Add(x, y)
+			z.Mod(z, m)
+		}
+	}, func(b *testing.B, o operands) {
+		x, y, m := bigmodNats(b, o)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			x.Add(y, m)
+		}
+	})
+}
+
+func BenchmarkMul(b *testing.B) {
+	sweep(b, func(b *testing.B, o operands) {
+		x, y, _ := safeNats(o)
+		z := new(saferith.Nat)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			z.Mul(x, y, -1)
+		}
+	}, func(b *testing.B, o operands) {
+		x, y, _ := bigInts(o)
+		z := new(big.Int)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			z.Mul(x, y)
+		}
+	}, nil)
+}
+
+func BenchmarkModMul(b *testing.B) {
+	sweep(b, func(b *testing.B, o operands) {
+		x, y, m := safeNats(o)
+		z := new(saferith.Nat)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			z.ModMul(x, y, m)
+		}
+	}, func(b *testing.B, o operands) {
+		x, y, m := bigInts(o)
+		z := new(big.Int)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			z.Mul(x, y)
+			z.Mod(z, m)
+		}
+	}, func(b *testing.B, o operands) {
+		x, y, m := bigmodNats(b, o)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			x.Mul(y, m)
+		}
+	})
+}
+
+func BenchmarkExp(b *testing.B) {
+	sweep(b, func(b *testing.B, o operands) {
+		x, y, m := safeNats(o)
+		z := new(saferith.Nat)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			z.Exp(x, y, m)
+		}
+	}, func(b *testing.B, o operands) {
+		x, y, m := bigInts(o)
+		z := new(big.Int)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			z.Exp(x, y, m)
+		}
+	}, func(b *testing.B, o operands) {
+		x, _, m := bigmodNats(b, o)
+		z := bigmod.NewNat()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			z.Exp(x, o.y, m)
+		}
+	})
+}
+
+func BenchmarkModInverse(b *testing.B) {
+	sweep(b, func(b *testing.B, o operands) {
+		x, _, m := safeNats(o)
+		z := new(saferith.Nat)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			z.ModInverse(x, m)
+		}
+	}, func(b *testing.B, o operands) {
+		x, _, m := bigInts(o)
+		z := new(big.Int)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			z.ModInverse(x, m)
+		}
+	}, func(b *testing.B, o operands) {
+		x, _, m := bigmodNats(b, o)
+		z := bigmod.NewNat()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			// Note that unlike the other two, this is variable time
+			z.InverseVarTime(x, m)
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package saferith
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// CapPolicy is a rule for choosing the capacity of the results of arithmetic.
+//
+// Operations like Add or Mul take the capacity of their result explicitly, and
+// silently discard any bits which don't fit. Rather than working out capacities
+// by hand at each step, a chain of operations can pick a policy, and use it
+// for every step:
+//
+//	p := saferith.CapSumForMul
+//	z.Mul(x, y, p.Mul(x, y))
+//	z.Add(z, w, p.Add(z, w))
+//
+// Capacities are derived from announced lengths only, so no values are leaked.
+type CapPolicy int
+
+const (
+	// CapExact keeps results as large as the largest input, like fixed size integers.
+	//
+	// Sums and products which don't fit wrap around.
+	CapExact CapPolicy = iota
+	// CapMax makes results as large as the largest input, plus room for carries.
+	//
+	// Sums and shifts never lose any bits, but products still wrap around
+	// past the size of the largest input. This suits chains where products
+	// get reduced afterwards, e.g. with a Modulus.
+	CapMax
+	// CapSumForMul never loses bits, giving products the sum of the sizes of their inputs.
+	CapSumForMul
+)
+
+// String returns the name of this policy.
+func (p CapPolicy) String() string {
+	switch p {
+	case CapExact:
+		return "CapExact"
+	case CapMax:
+		return "CapMax"
+	case CapSumForMul:
+		return "CapSumForMul"
+	}
+	return fmt.Sprintf("CapPolicy(%d)", int(p))
+}
+
+// Add returns the capacity to use for x + y.
+func (p CapPolicy) Add(x *Nat, y *Nat) int {
+	if p == CapExact {
+		return x.maxAnnounced(y)
+	}
+	return x.maxAnnounced(y) + 1
+}
+
+// Sub returns the capacity to use for x - y.
+//
+// Differences are never larger than their inputs, so this is the same for every policy.
+func (p CapPolicy) Sub(x *Nat, y *Nat) int {
+	return x.maxAnnounced(y)
+}
+
+// Mul returns the capacity to use for x * y.
+func (p CapPolicy) Mul(x *Nat, y *Nat) int {
+	if p == CapSumForMul {
+		return x.announced + y.announced
+	}
+	return x.maxAnnounced(y)
+}
+
+// Lsh returns the capacity to use for x << shift.
+func (p CapPolicy) Lsh(x *Nat, shift uint) int {
+	if p == CapExact {
+		return x.announced
+	}
+	return x.announced + int(shift)
+}
+
+// truncationChecksEnabled is 1 if operations should panic when discarding bits
+var truncationChecksEnabled uint32
+
+// SetTruncationChecks controls whether or not operations panic when truncating their results.
+//
+// When enabled, Add, Mul, Lsh, Div, and DivRem panic if their result has bits which
+// don't fit in the requested capacity, and Sub panics if its result would be negative.
+// This is meant for tests, to catch capacities which are too small, and
+// NOT for production: whether or not an operation panics depends on the values
+// involved, which leaks them. Explicitly wrapping operations, like WrappingAdd,
+// or Resize, are never checked. Checks are disabled by default, unless building
+// with the safenum_checkcap tag, in which case they can't be disabled.
+//
+// This can be called concurrently with other operations.
+func SetTruncationChecks(enabled bool) {
+	var v uint32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreUint32(&truncationChecksEnabled, v)
+}
+
+// truncationChecks returns true if truncation checks are enabled
+//
+// With the safenum_checkcap build tag, they always are.
+func truncationChecks() bool {
+	return checkCapBuild || atomic.LoadUint32(&truncationChecksEnabled) == 1
+}
+
+// checkTruncation panics if full doesn't fit in cap bits.
+//
+// LEAK: whether or not full fits
+// OK: this is only used when truncation checks are enabled
+func checkTruncation(op string, full *Nat, cap int) {
+	if overflowsCap(full.limbs, cap) == 1 {
+		panic(fmt.Sprintf("saferith: %s truncated its result to %d bits", op, cap))
+	}
+}
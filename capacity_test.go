@@ -0,0 +1,94 @@
+package saferith
+
+import (
+	"testing"
+	"testing/quick"
+)
+
+func TestCapPolicyExamples(t *testing.T) {
+	x := new(Nat).SetUint64(0xFF).Resize(8)
+	y := new(Nat).SetUint64(0xFFFF).Resize(16)
+	tests := []struct {
+		policy        CapPolicy
+		add, sub, mul int
+		lsh           int
+	}{
+		{CapExact, 16, 16, 16, 8},
+		{CapMax, 17, 16, 16, 11},
+		{CapSumForMul, 17, 16, 24, 11},
+	}
+	for _, test := range tests {
+		p := test.policy
+		actual := []int{p.Add(x, y), p.Sub(y, x), p.Mul(x, y), p.Lsh(x, 3)}
+		expected := []int{test.add, test.sub, test.mul, test.lsh}
+		for i := range expected {
+			if actual[i] != expected[i] {
+				t.Errorf("%v: %+v != %+v", p, expected, actual)
+				break
+			}
+		}
+	}
+}
+
+// Under CapSumForMul, nothing is ever lost
+func testCapSumForMulLossless(x Nat, y Nat, shift uint8) bool {
+	p := CapSumForMul
+	sum := new(Nat).Add(&x, &y, p.Add(&x, &y))
+	if new(Nat).Sub(sum, &y, p.Sub(sum, &y)).Eq(&x) != 1 {
+		return false
+	}
+	product := new(Nat).Mul(&x, &y, p.Mul(&x, &y))
+	if product.Big().Cmp(new(Nat).Mul(&x, &y, -1).Big()) != 0 {
+		return false
+	}
+	shifted := new(Nat).Lsh(&x, uint(shift), p.Lsh(&x, uint(shift)))
+	return new(Nat).Rsh(shifted, uint(shift), -1).Eq(&x) == 1
+}
+
+func TestCapSumForMulLossless(t *testing.T) {
+	SetTruncationChecks(true)
+	defer SetTruncationChecks(false)
+	err := quick.Check(testCapSumForMulLossless, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTruncationChecks(t *testing.T) {
+	x := new(Nat).SetUint64(0xFF)
+	y := new(Nat).SetUint64(0x1FF)
+	if !checkCapBuild {
+		// Without checks, results wrap around silently
+		new(Nat).Add(x, x, 8)
+		new(Nat).Sub(x, y, -1)
+	} else if !truncationChecks() {
+		t.Error("safenum_checkcap should enable truncation checks")
+	}
+
+	SetTruncationChecks(true)
+	defer SetTruncationChecks(false)
+	expectPanic(t, "Add", func() { new(Nat).Add(x, x, 8) })
+	expectPanic(t, "Sub", func() { new(Nat).Sub(x, y, -1) })
+	expectPanic(t, "Sub", func() { new(Nat).Sub(y, x, 7) })
+	expectPanic(t, "Mul", func() { new(Nat).Mul(x, x, 8) })
+	expectPanic(t, "Lsh", func() { new(Nat).Lsh(x, 1, 8) })
+	expectPanic(t, "Div", func() { new(Nat).Div(y, ModulusFromUint64(2), 7) })
+	expectPanic(t, "DivRem", func() { new(Nat).DivRem(y, new(Nat).SetUint64(2), 7) })
+
+	// Smaller capacities are fine, as long as the result fits
+	small := new(Nat).SetUint64(1).Resize(16)
+	if actual := new(Nat).Add(small, small, 8); actual.EqUint64(2) != 1 {
+		t.Errorf("%+v != 2", actual)
+	}
+	if actual := new(Nat).Mul(small, y, 9); actual.EqUint64(0x1FF) != 1 {
+		t.Errorf("%+v != 0x1FF", actual)
+	}
+	if actual := new(Nat).Div(y, ModulusFromUint64(2), 8); actual.EqUint64(0xFF) != 1 {
+		t.Errorf("%+v != 0xFF", actual)
+	}
+	// Explicitly wrapping operations aren't checked
+	new(Nat).WrappingAdd(x, x, 8)
+	new(Nat).WrappingSub(x, y, -1)
+	new(Nat).WrappingMul(x, x, 8)
+	new(Nat).SubChecked(x, y, -1)
+}
@@ -0,0 +1,199 @@
+package saferith
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// This file implements encoding to and from CBOR bignums, as described in
+// RFC 8949, Section 3.4.3.
+//
+// A bignum consists of a tag, either 2 for non-negative numbers, or 3 for
+// negative numbers, followed by a byte string containing a big endian number.
+// For tag 3, the byte string contains n, representing the number -1 - n.
+
+// CBOR major types
+const (
+	cborUint     = 0
+	cborNegInt   = 1
+	cborBytes    = 2
+	cborTag      = 6
+	cborPosTag   = 2
+	cborNegTag   = 3
+	cborMaxShort = 23
+)
+
+// appendCBORHeader appends the initial bytes for a CBOR data item to out.
+//
+// This will leak the value of arg, but we only use this for public lengths and tags.
+func appendCBORHeader(out []byte, major byte, arg uint64) []byte {
+	major <<= 5
+	switch {
+	case arg <= cborMaxShort:
+		return append(out, major|byte(arg))
+	case arg <= 0xFF:
+		return append(out, major|24, byte(arg))
+	case arg <= 0xFFFF:
+		out = append(out, major|25, 0, 0)
+		binary.BigEndian.PutUint16(out[len(out)-2:], uint16(arg))
+		return out
+	case arg <= 0xFFFF_FFFF:
+		out = append(out, major|26, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(out[len(out)-4:], uint32(arg))
+		return out
+	default:
+		out = append(out, major|27, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(out[len(out)-8:], arg)
+		return out
+	}
+}
+
+// readCBORHeader parses the initial bytes of a CBOR data item, returning its major type,
+// argument, and the remaining data.
+//
+// Indefinite lengths aren't supported, since they're never used for bignums.
+func readCBORHeader(data []byte) (byte, uint64, []byte, error) {
+	if len(data) < 1 {
+		return 0, 0, nil, errors.New("cbor: unexpected end of data")
+	}
+	major := data[0] >> 5
+	info := data[0] & 0x1F
+	data = data[1:]
+	if info <= cborMaxShort {
+		return major, uint64(info), data, nil
+	}
+	var size int
+	switch info {
+	case 24:
+		size = 1
+	case 25:
+		size = 2
+	case 26:
+		size = 4
+	case 27:
+		size = 8
+	default:
+		return 0, 0, nil, errors.New("cbor: unsupported additional information")
+	}
+	if len(data) < size {
+		return 0, 0, nil, errors.New("cbor: unexpected end of data")
+	}
+	var arg uint64
+	for _, b := range data[:size] {
+		arg = (arg << 8) | uint64(b)
+	}
+	return major, arg, data[size:], nil
+}
+
+// readCBORBytes parses a CBOR byte string, returning its contents, and the remaining data.
+func readCBORBytes(data []byte) ([]byte, []byte, error) {
+	major, length, data, err := readCBORHeader(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if major != cborBytes {
+		return nil, nil, errors.New("cbor: expected byte string")
+	}
+	if uint64(len(data)) < length {
+		return nil, nil, errors.New("cbor: unexpected end of data")
+	}
+	return data[:length], data[length:], nil
+}
+
+// MarshalCBOR encodes this number as a CBOR bignum, with tag 2.
+//
+// The byte string will contain the output of Bytes(), so its length
+// depends only on the announced length of this Nat.
+func (z *Nat) MarshalCBOR() ([]byte, error) {
+	bytes := z.Bytes()
+	out := make([]byte, 0, 11+len(bytes))
+	out = appendCBORHeader(out, cborTag, cborPosTag)
+	out = appendCBORHeader(out, cborBytes, uint64(len(bytes)))
+	return append(out, bytes...), nil
+}
+
+// UnmarshalCBOR decodes a CBOR bignum with tag 2, or an unsigned integer, into z.
+//
+// For a bignum, the announced length will be 8 times the length of the byte string,
+// as with SetBytes. For an unsigned integer, the announced length will be 64.
+//
+// This will leak whether or not the data is valid, but nothing else about its value.
+func (z *Nat) UnmarshalCBOR(data []byte) error {
+	major, arg, rest, err := readCBORHeader(data)
+	if err != nil {
+		return err
+	}
+	switch {
+	case major == cborUint:
+		z.SetUint64(arg)
+	case major == cborTag && arg == cborPosTag:
+		var bytes []byte
+		bytes, rest, err = readCBORBytes(rest)
+		if err != nil {
+			return err
+		}
+		z.SetBytes(bytes)
+	default:
+		return errors.New("cbor: expected unsigned integer or bignum")
+	}
+	if len(rest) != 0 {
+		return errors.New("cbor: trailing data")
+	}
+	return nil
+}
+
+// MarshalCBOR encodes this number as a CBOR bignum, with tag 2 or 3 depending on the sign.
+//
+// The byte string will have the same length as z.Abs().Bytes(), and depends only on
+// the announced length of this Int. The tag will leak the sign, naturally.
+func (z *Int) MarshalCBOR() ([]byte, error) {
+	negative := z.IsNegative()
+	// For negative numbers, we encode |z| - 1, which can't underflow
+	n := new(Nat).SetNat(&z.abs)
+	subVW(n.limbs, n.limbs, Word(negative))
+	bytes := n.Bytes()
+	out := make([]byte, 0, 11+len(bytes))
+	out = appendCBORHeader(out, cborTag, uint64(ctIfElse(negative, cborNegTag, cborPosTag)))
+	out = appendCBORHeader(out, cborBytes, uint64(len(bytes)))
+	return append(out, bytes...), nil
+}
+
+// UnmarshalCBOR decodes a CBOR bignum, or integer, into z.
+//
+// For a bignum, the announced length of the absolute value will be 8 times
+// the length of the byte string, plus one, since -1 - n may need an extra bit.
+// For integers, the announced length will be 65 bits, for the same reason.
+//
+// This will leak whether or not the data is valid, and its sign, but nothing else.
+func (z *Int) UnmarshalCBOR(data []byte) error {
+	major, arg, rest, err := readCBORHeader(data)
+	if err != nil {
+		return err
+	}
+	var negative Choice
+	switch {
+	case major == cborUint || major == cborNegInt:
+		negative = Choice(major)
+		z.abs.SetUint64(arg)
+	case major == cborTag && (arg == cborPosTag || arg == cborNegTag):
+		negative = Choice(arg & 1)
+		var bytes []byte
+		bytes, rest, err = readCBORBytes(rest)
+		if err != nil {
+			return err
+		}
+		z.abs.SetBytes(bytes)
+	default:
+		return errors.New("cbor: expected integer or bignum")
+	}
+	if len(rest) != 0 {
+		return errors.New("cbor: trailing data")
+	}
+	// The absolute value of -1 - n is n + 1
+	cap := z.abs.announced + 1
+	z.abs.limbs = z.abs.resizedLimbs(cap)
+	addVW(z.abs.limbs, z.abs.limbs, Word(negative))
+	z.abs.announced = cap
+	z.sign = negative
+	return nil
+}
@@ -0,0 +1,86 @@
+package saferith
+
+import (
+	"bytes"
+	"testing"
+	"testing/quick"
+)
+
+func testNatCBORRoundTrip(x Nat) bool {
+	out, err := x.MarshalCBOR()
+	if err != nil {
+		return false
+	}
+	y := new(Nat)
+	err = y.UnmarshalCBOR(out)
+	if err != nil {
+		return false
+	}
+	return x.Eq(y) == 1 && y.checkInvariants()
+}
+
+func TestNatCBORRoundTrip(t *testing.T) {
+	err := quick.Check(testNatCBORRoundTrip, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testIntCBORRoundTrip(x *Int) bool {
+	out, err := x.MarshalCBOR()
+	if err != nil {
+		return false
+	}
+	y := new(Int)
+	err = y.UnmarshalCBOR(out)
+	if err != nil {
+		return false
+	}
+	return x.Eq(y) == 1 && y.abs.checkInvariants()
+}
+
+func TestIntCBORRoundTrip(t *testing.T) {
+	err := quick.Check(testIntCBORRoundTrip, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCBORExamples(t *testing.T) {
+	// RFC 8949, Appendix A: 18446744073709551616 = 0xc249010000000000000000
+	x := new(Nat).SetBytes([]byte{1, 0, 0, 0, 0, 0, 0, 0, 0})
+	expected := []byte{0xC2, 0x49, 1, 0, 0, 0, 0, 0, 0, 0, 0}
+	actual, _ := x.MarshalCBOR()
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	// -18446744073709551617 = 0xc349010000000000000000
+	i := new(Int)
+	if err := i.UnmarshalCBOR([]byte{}); err == nil {
+		t.Errorf("expected error for empty data")
+	}
+	expected = []byte{0xC3, 0x49, 1, 0, 0, 0, 0, 0, 0, 0, 0}
+	if err := i.UnmarshalCBOR(expected); err != nil {
+		t.Error(err)
+	}
+	expectedInt := new(Int).SetBytes([]byte{1, 0, 0, 0, 0, 0, 0, 0, 1}).Neg(1)
+	if expectedInt.Eq(i) != 1 {
+		t.Errorf("%+v != %+v", expectedInt, i)
+	}
+	// -1000 = 0x3903e7
+	if err := i.UnmarshalCBOR([]byte{0x39, 0x03, 0xE7}); err != nil {
+		t.Error(err)
+	}
+	expectedInt.SetInt64(-1000)
+	if expectedInt.Eq(i) != 1 {
+		t.Errorf("%+v != %+v", expectedInt, i)
+	}
+	// A negative integer can't be decoded into a Nat
+	if err := x.UnmarshalCBOR([]byte{0x39, 0x03, 0xE7}); err == nil {
+		t.Errorf("expected error decoding negative integer")
+	}
+	// Trailing data
+	if err := x.UnmarshalCBOR([]byte{0x01, 0x01}); err == nil {
+		t.Errorf("expected error with trailing data")
+	}
+}
@@ -0,0 +1,16 @@
+//go:build safenum_checkcap
+// +build safenum_checkcap
+
+package saferith
+
+// The safenum_checkcap build tag turns on truncation checks permanently, as if
+// SetTruncationChecks(true) had been called before anything else.
+//
+// This makes it easy to run the test suite of a package using saferith with
+// checks enabled, without modifying it:
+//
+//	go test -tags safenum_checkcap ./...
+//
+// These checks take variable time, and panic depending on secret values,
+// so this tag must never be used in production builds.
+const checkCapBuild = true
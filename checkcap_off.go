@@ -0,0 +1,7 @@
+//go:build !safenum_checkcap
+// +build !safenum_checkcap
+
+package saferith
+
+// checkCapBuild is true when built with the safenum_checkcap tag, see checkcap.go
+const checkCapBuild = false
@@ -0,0 +1,51 @@
+// Command dudect runs statistical timing tests over saferith's operations.
+//
+// For each operation, this prints the t statistic comparing timings on fixed
+// and random inputs, and exits with a non zero status if any of them leak.
+// Values of |t| above 10 indicate a leak. Usage:
+//
+//	go run ./cmd/dudect -samples 100000 -run Exp
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/cronokirby/saferith/internal/cttest"
+)
+
+func main() {
+	samples := flag.Int("samples", 100000, "number of samples per test")
+	run := flag.String("run", "", "only run tests matching this regular expression")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "seed used to generate inputs")
+	flag.Parse()
+
+	filter, err := regexp.Compile(*run)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	leaky := false
+	for _, test := range cttest.Suite() {
+		if !filter.MatchString(test.Name) {
+			continue
+		}
+		result, err := cttest.Run(test, *samples, *seed)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		status := "ok"
+		if result.Leaky() {
+			status = "LEAK"
+			leaky = true
+		}
+		fmt.Printf("%-16s %8d samples  t = %8.2f  %s\n", result.Name, result.Samples, result.T, status)
+	}
+	if leaky {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,85 @@
+package saferith
+
+import (
+	"errors"
+)
+
+// CRT holds precomputed values for working modulo n = p * q, with p and q coprime
+//
+// Using the Chinese Remainder Theorem, operations modulo n can be split into
+// operations modulo p and q, which are considerably faster, since they work
+// with numbers half the size. This is what makes RSA and Paillier private key
+// operations fast.
+//
+// Like with Modulus, the sizes of p and q are considered to be public.
+type CRT struct {
+	p *Modulus
+	q *Modulus
+	n *Modulus
+	// q^-1 mod p
+	qInv *Nat
+}
+
+// NewCRT creates a new CRT structure from two coprime moduli.
+//
+// This returns an error if p and q are not coprime. The result of this check,
+// as well as the true size of p * q, are leaked.
+func NewCRT(p *Modulus, q *Modulus) (*CRT, error) {
+	qInv, ok := new(Nat).ModInverseChecked(&q.nat, p)
+	if ok != 1 {
+		return nil, errors.New("CRT moduli are not coprime")
+	}
+	n := ModulusFromNat(new(Nat).Mul(&p.nat, &q.nat, p.nat.announced+q.nat.announced))
+	return &CRT{p: p, q: q, n: n, qInv: qInv}, nil
+}
+
+// P returns the first modulus.
+func (c *CRT) P() *Modulus {
+	return c.p
+}
+
+// Q returns the second modulus.
+func (c *CRT) Q() *Modulus {
+	return c.q
+}
+
+// N returns the product of both moduli.
+func (c *CRT) N() *Modulus {
+	return c.n
+}
+
+// Split calculates x mod p, and x mod q.
+func (c *CRT) Split(x *Nat) (*Nat, *Nat) {
+	return new(Nat).Mod(x, c.p), new(Nat).Mod(x, c.q)
+}
+
+// Combine calculates the unique x mod n, such that x = xp mod p, and x = xq mod q.
+//
+// This uses Garner's formula, x = xq + ((xp - xq) q^-1 mod p) q.
+//
+// The capacity of the result matches the capacity of n.
+func (c *CRT) Combine(xp *Nat, xq *Nat) *Nat {
+	xq = new(Nat).Mod(xq, c.q)
+	h := new(Nat).Mod(xq, c.p)
+	h.ModSub(xp, h, c.p)
+	h.ModMul(h, c.qInv, c.p)
+	out := new(Nat).Mul(h, &c.q.nat, c.n.nat.announced)
+	out.Add(out, xq, c.n.nat.announced)
+	// Since h < p, and xq < q, the result is at most (p - 1) q + q - 1 < n
+	out.reduced = c.n
+	return out
+}
+
+// ExpCRT calculates x^e mod n, given the exponents ep and eq to use modulo p and q.
+//
+// When p and q are prime, by Fermat's little theorem, we can use ep = e mod (p - 1),
+// and eq = e mod (q - 1), which makes the exponents half as large. For general moduli,
+// passing e as both exponents gives the correct result.
+//
+// Like Exp, this only leaks the announced lengths of the exponents.
+func (c *CRT) ExpCRT(x *Nat, ep *Nat, eq *Nat) *Nat {
+	xp, xq := c.Split(x)
+	xp.Exp(xp, ep, c.p)
+	xq.Exp(xq, eq, c.q)
+	return c.Combine(xp, xq)
+}
@@ -0,0 +1,78 @@
+package saferith
+
+import (
+	"math/big"
+	"testing"
+	"testing/quick"
+)
+
+// Two 128 bit primes
+const crtPHex = "D5E4E1F0A85B4F0EC2E3B1C2A5C3F5E7"
+const crtQHex = "F7D3A1B8C9E2F4A6B5C7D9E1F3A5B7CF"
+
+func testCRT(t *testing.T) *CRT {
+	p, _ := new(big.Int).SetString(crtPHex, 16)
+	q, _ := new(big.Int).SetString(crtQHex, 16)
+	// Move to the next primes, so that our constants don't need to be exact
+	for !p.ProbablyPrime(20) {
+		p.Add(p, big.NewInt(2))
+	}
+	for !q.ProbablyPrime(20) {
+		q.Add(q, big.NewInt(2))
+	}
+	crt, err := NewCRT(ModulusFromBig(p), ModulusFromBig(q))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return crt
+}
+
+func TestCRTCombineRoundTrip(t *testing.T) {
+	crt := testCRT(t)
+	err := quick.Check(func(x Nat) bool {
+		xp, xq := crt.Split(&x)
+		actual := crt.Combine(xp, xq)
+		if !actual.checkInvariants() {
+			return false
+		}
+		return actual.Eq(new(Nat).Mod(&x, crt.N())) == 1
+	}, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCRTExpMatchesExp(t *testing.T) {
+	crt := testCRT(t)
+	pMinus1 := ModulusFromNat(new(Nat).Sub(crt.P().Nat(), new(Nat).SetUint64(1), -1))
+	qMinus1 := ModulusFromNat(new(Nat).Sub(crt.Q().Nat(), new(Nat).SetUint64(1), -1))
+	err := quick.Check(func(x Nat, e Nat) bool {
+		expected := new(Nat).Exp(&x, &e, crt.N())
+		// With the full exponent
+		actual := crt.ExpCRT(&x, &e, &e)
+		if !actual.checkInvariants() || actual.Eq(expected) != 1 {
+			return false
+		}
+		// With the exponents reduced using Fermat's little theorem
+		actual = crt.ExpCRT(&x, new(Nat).Mod(&e, pMinus1), new(Nat).Mod(&e, qMinus1))
+		return actual.Eq(expected) == 1
+	}, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNewCRTRejectsNonCoprime(t *testing.T) {
+	_, err := NewCRT(ModulusFromUint64(6), ModulusFromUint64(9))
+	if err == nil {
+		t.Errorf("expected error for non coprime moduli")
+	}
+	crt, err := NewCRT(ModulusFromUint64(4), ModulusFromUint64(9))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := crt.Combine(new(Nat).SetUint64(3), new(Nat).SetUint64(5))
+	if x.Eq(new(Nat).SetUint64(23)) != 1 {
+		t.Errorf("%+v != 23", x)
+	}
+}
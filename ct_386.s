@@ -0,0 +1,30 @@
+//go:build !math_big_pure_go && !safenum_purego
+// +build !math_big_pure_go,!safenum_purego
+
+#include "textflag.h"
+
+// func condCopyVV(v Word, x, y []Word)
+//
+// This copies y into x if v = 1, using a mask, rather than a branch, or CMOV,
+// to select each limb: x[i] ^= -v & (x[i] ^ y[i]).
+TEXT ·condCopyVV(SB),NOSPLIT,$0
+	MOVL v+0(FP), AX
+	MOVL x+4(FP), DI
+	MOVL x_len+8(FP), BP
+	MOVL y+16(FP), SI
+	NEGL AX			// mask = -v
+	MOVL $0, BX		// i = 0
+	JMP E1
+
+L1:	MOVL (DI)(BX*4), CX
+	MOVL (SI)(BX*4), DX
+	XORL CX, DX
+	ANDL AX, DX
+	XORL DX, CX
+	MOVL CX, (DI)(BX*4)
+	ADDL $1, BX		// i++
+
+E1:	CMPL BX, BP		// i < n
+	JL L1
+
+	RET
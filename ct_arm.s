@@ -0,0 +1,29 @@
+//go:build !math_big_pure_go && !safenum_purego
+// +build !math_big_pure_go,!safenum_purego
+
+#include "textflag.h"
+
+// func condCopyVV(v Word, x, y []Word)
+//
+// This copies y into x if v = 1, using a mask, rather than conditional
+// execution, to select each limb: x[i] ^= -v & (x[i] ^ y[i]).
+TEXT ·condCopyVV(SB),NOSPLIT,$0
+	MOVW	v+0(FP), R0
+	MOVW	x+4(FP), R1
+	MOVW	x_len+8(FP), R2
+	MOVW	y+16(FP), R3
+	RSB	$0, R0		// mask = -v
+	ADD	R2<<2, R1, R2
+	B E1
+L1:
+	MOVW	(R1), R4
+	MOVW.P	4(R3), R5
+	EOR	R4, R5
+	AND	R0, R5
+	EOR	R5, R4
+	MOVW.P	R4, 4(R1)
+E1:
+	TEQ	R1, R2
+	BNE L1
+
+	RET
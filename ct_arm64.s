@@ -0,0 +1,42 @@
+//go:build !math_big_pure_go && !safenum_purego
+// +build !math_big_pure_go,!safenum_purego
+
+#include "textflag.h"
+
+// func condCopyVV(v Word, x, y []Word)
+//
+// This copies y into x if v = 1, using a mask, rather than a branch, or CSEL,
+// to select each limb: x[i] ^= -v & (x[i] ^ y[i]).
+TEXT ·condCopyVV(SB),NOSPLIT,$0
+	MOVD	v+0(FP), R0
+	MOVD	x+8(FP), R1
+	MOVD	x_len+16(FP), R2
+	MOVD	y+32(FP), R3
+	NEG	R0, R0
+	TBZ	$0, R2, loop
+
+	MOVD	(R1), R4
+	MOVD.P	8(R3), R5
+	EOR	R4, R5, R5
+	AND	R0, R5, R5
+	EOR	R5, R4, R4
+	MOVD.P	R4, 8(R1)
+	SUB	$1, R2
+
+// The main loop handles two limbs at a time
+loop:
+	CBZ	R2, done
+	LDP	(R1), (R4, R5)
+	LDP.P	16(R3), (R6, R7)
+	EOR	R4, R6, R6
+	EOR	R5, R7, R7
+	AND	R0, R6, R6
+	AND	R0, R7, R7
+	EOR	R6, R4, R4
+	EOR	R7, R5, R5
+	STP.P	(R4, R5), 16(R1)
+	SUB	$2, R2
+	B	loop
+
+done:
+	RET
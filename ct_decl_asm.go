@@ -0,0 +1,8 @@
+//go:build (386 || arm || arm64 || loong64) && !math_big_pure_go && !safenum_purego
+// +build 386 arm arm64 loong64
+// +build !math_big_pure_go,!safenum_purego
+
+package saferith
+
+// implemented in ct_$GOARCH.s
+func condCopyVV(v Word, x, y []Word)
@@ -0,0 +1,29 @@
+//go:build !math_big_pure_go && !safenum_purego
+// +build !math_big_pure_go,!safenum_purego
+
+#include "textflag.h"
+
+// func condCopyVV(v Word, x, y []Word)
+//
+// Like on arm64, this uses a mask to select each limb, rather than a branch,
+// or MASKEQZ: x[i] ^= -v & (x[i] ^ y[i]).
+TEXT ·condCopyVV(SB),NOSPLIT,$0
+	MOVV	v+0(FP), R4
+	MOVV	x_base+8(FP), R5
+	MOVV	x_len+16(FP), R6
+	MOVV	y_base+32(FP), R7
+	SUBVU	R4, R0, R4
+	BEQ	R6, done
+loop:
+	MOVV	0(R5), R8
+	MOVV	0(R7), R9
+	XOR	R8, R9
+	AND	R4, R9
+	XOR	R9, R8
+	MOVV	R8, 0(R5)
+	ADDVU	$8, R5
+	ADDVU	$8, R7
+	SUBVU	$1, R6
+	BNE	R6, loop
+done:
+	RET
@@ -0,0 +1,34 @@
+//go:build ctgrind && linux && amd64 && !math_big_pure_go && !safenum_purego
+// +build ctgrind,linux,amd64,!math_big_pure_go,!safenum_purego
+
+package saferith
+
+// The ctgrind build tag enables hooks to mark limbs as secret, for use with
+// Valgrind's memcheck tool, following the approach of ctgrind.
+//
+// Secret limbs are marked as undefined memory, which memcheck then tracks as
+// it flows through the program. Any branch, or memory access, whose outcome
+// depends on undefined memory is reported as an error, which is exactly what
+// we want to avoid for secret values. Outside of Valgrind, the hooks do nothing.
+
+// Memcheck client request numbers, from valgrind/memcheck.h
+const (
+	vgMakeMemUndefined = ('M'<<24 | 'C'<<16) + 1
+	vgMakeMemDefined   = ('M'<<24 | 'C'<<16) + 2
+)
+
+// implemented in ctgrind_amd64.s
+func valgrindMakeMem(request uintptr, x []Word)
+
+// classify marks the limbs of x as secret.
+func classify(x []Word) {
+	valgrindMakeMem(vgMakeMemUndefined, x)
+}
+
+// declassify marks the limbs of x as public again.
+//
+// This should only be done for results which are meant to be public,
+// or right before checking results in tests.
+func declassify(x []Word) {
+	valgrindMakeMem(vgMakeMemDefined, x)
+}
@@ -0,0 +1,28 @@
+//go:build ctgrind && linux && !math_big_pure_go && !safenum_purego
+// +build ctgrind,linux,!math_big_pure_go,!safenum_purego
+
+#include "textflag.h"
+
+// func valgrindMakeMem(request uintptr, x []Word)
+//
+// This issues a Valgrind client request, acting on the memory backing x.
+// See valgrind/valgrind.h for the magic sequence used here: Valgrind picks up
+// on it, and executes the request, and outside of Valgrind it does nothing.
+TEXT ·valgrindMakeMem(SB),NOSPLIT,$48-32
+	// The request, and its arguments, need to be contiguous in memory
+	MOVQ	request+0(FP), AX
+	MOVQ	AX, 0(SP)
+	MOVQ	x_base+8(FP), AX
+	MOVQ	AX, 8(SP)
+	MOVQ	x_len+16(FP), AX
+	SHLQ	$3, AX
+	MOVQ	AX, 16(SP)
+	MOVQ	$0, 24(SP)
+	MOVQ	$0, 32(SP)
+	MOVQ	$0, 40(SP)
+	LEAQ	0(SP), AX
+	XORL	DX, DX
+	ROLQ	$3, DI; ROLQ $13, DI
+	ROLQ	$61, DI; ROLQ $51, DI
+	XCHGQ	BX, BX
+	RET
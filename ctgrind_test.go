@@ -0,0 +1,66 @@
+//go:build ctgrind && linux && amd64 && !math_big_pure_go && !safenum_purego
+// +build ctgrind,linux,amd64,!math_big_pure_go,!safenum_purego
+
+package saferith
+
+import (
+	"testing"
+)
+
+// ctgrindNat returns a Nat with n bytes, derived from seed, with secret limbs.
+func ctgrindNat(n int, seed byte) *Nat {
+	bytes := make([]byte, n)
+	for i := range bytes {
+		bytes[i] = seed*byte(i) + 0x5B
+	}
+	x := new(Nat).SetBytes(bytes)
+	classify(x.limbs)
+	return x
+}
+
+// TestCTGrind runs critical operations on secret inputs.
+//
+// This only checks something when run under Valgrind, which reports any branch,
+// or memory index, depending on secret limbs:
+//
+//	go test -c -tags valgrind,ctgrind -o saferith.test
+//	valgrind --error-exitcode=1 ./saferith.test -test.run CTGrind
+//
+// The valgrind tag makes the Go runtime describe its allocator to Valgrind,
+// which avoids spurious errors, and needs Go 1.25 or later.
+func TestCTGrind(t *testing.T) {
+	m := ModulusFromBytes([]byte{
+		0xC5, 0x11, 0x3A, 0x7E, 0x90, 0x2D, 0x44, 0x6B,
+		0x01, 0xF2, 0x8C, 0x33, 0x57, 0xE9, 0x1A, 0x0B,
+		0x66, 0x4E, 0xD3, 0x29, 0xB8, 0x70, 0x05, 0x9F,
+		0x12, 0x3C, 0x81, 0xAA, 0x47, 0x6E, 0xF0, 0x97,
+	})
+	x := ctgrindNat(32, 3)
+	y := ctgrindNat(32, 7)
+
+	mul := new(Nat).Mul(x, y, -1)
+	exp := new(Nat).Exp(x, y, m)
+	gt, eq, lt := x.Cmp(y)
+	// Comparisons between numbers of different sizes should only depend on those sizes
+	short := ctgrindNat(16, 5)
+	mixedGt, mixedEq, mixedLt := x.Cmp(short)
+	cmp := []Word{Word(gt), Word(eq), Word(lt), Word(mixedGt), Word(mixedEq), Word(mixedLt), Word(short.EqTrimmed(x))}
+	inv := new(Nat).ModInverse(x, m)
+
+	for _, limbs := range [][]Word{x.limbs, y.limbs, short.limbs, mul.limbs, exp.limbs, cmp, inv.limbs} {
+		declassify(limbs)
+	}
+	expected := new(Nat).Mul(x, y, -1)
+	if mul.Eq(expected) != 1 {
+		t.Errorf("%+v != %+v", expected, mul)
+	}
+	if cmp[0]+cmp[1]+cmp[2] != 1 || cmp[3]+cmp[4]+cmp[5] != 1 {
+		t.Errorf("invalid comparison: %v", cmp)
+	}
+	if new(Nat).ModMul(x, inv, m).EqUint64(1) != 1 {
+		t.Errorf("%+v is not the inverse of %+v", inv, x)
+	}
+	if !exp.checkInvariants() {
+		t.Errorf("invalid exponentiation result: %+v", exp)
+	}
+}
@@ -0,0 +1,131 @@
+package saferith
+
+import "errors"
+
+// This file implements encoding to and from ASN.1 DER INTEGERs, as described in
+// ITU-T X.690, Section 8.3.
+//
+// DER requires integers to be encoded in their minimal two's complement form,
+// which means that these encodings leak the true size of the numbers involved,
+// unlike most of the other operations in this package.
+
+// The tag for an ASN.1 INTEGER.
+const derIntegerTag = 0x02
+
+// minimalTwos strips the redundant leading bytes from a two's complement encoding.
+//
+// LEAK: the true size of the number
+func minimalTwos(twos []byte) []byte {
+	for len(twos) > 1 {
+		if twos[0] == 0 && twos[1]&0x80 == 0 {
+			twos = twos[1:]
+		} else if twos[0] == 0xFF && twos[1]&0x80 != 0 {
+			twos = twos[1:]
+		} else {
+			break
+		}
+	}
+	return twos
+}
+
+// marshalDERInteger wraps the contents of an INTEGER with its tag and length.
+func marshalDERInteger(contents []byte) []byte {
+	out := []byte{derIntegerTag}
+	length := len(contents)
+	if length < 0x80 {
+		out = append(out, byte(length))
+	} else {
+		var lengthBytes []byte
+		for l := length; l > 0; l >>= 8 {
+			lengthBytes = append([]byte{byte(l)}, lengthBytes...)
+		}
+		out = append(out, 0x80|byte(len(lengthBytes)))
+		out = append(out, lengthBytes...)
+	}
+	return append(out, contents...)
+}
+
+// parseDERInteger extracts the contents of an INTEGER, checking that the encoding is valid DER.
+func parseDERInteger(data []byte) ([]byte, error) {
+	if len(data) < 2 || data[0] != derIntegerTag {
+		return nil, errors.New("asn1: expected INTEGER")
+	}
+	length := int(data[1])
+	data = data[2:]
+	if length&0x80 != 0 {
+		lengthSize := length & 0x7F
+		// Lengths of 0x80 or more bytes are far beyond anything we'd accept anyways.
+		if lengthSize == 0 || lengthSize > 4 || len(data) < lengthSize || data[0] == 0 {
+			return nil, errors.New("asn1: invalid length")
+		}
+		length = 0
+		for _, b := range data[:lengthSize] {
+			length = (length << 8) | int(b)
+		}
+		data = data[lengthSize:]
+		// DER requires the short form whenever possible
+		if length < 0x80 {
+			return nil, errors.New("asn1: non-minimal length")
+		}
+	}
+	if len(data) != length {
+		return nil, errors.New("asn1: length doesn't match data")
+	}
+	if length == 0 {
+		return nil, errors.New("asn1: empty INTEGER")
+	}
+	if len(minimalTwos(data)) != len(data) {
+		return nil, errors.New("asn1: non-minimal INTEGER")
+	}
+	return data, nil
+}
+
+// MarshalASN1 encodes this number as an ASN.1 DER INTEGER.
+//
+// DER requires a minimal encoding, so this leaks the true size of z.
+func (z *Nat) MarshalASN1() ([]byte, error) {
+	// An extra zero byte ensures that the number is interpreted as positive
+	contents := append([]byte{0}, z.Bytes()...)
+	return marshalDERInteger(minimalTwos(contents)), nil
+}
+
+// UnmarshalASN1 decodes an ASN.1 DER INTEGER into z.
+//
+// An error is returned if the encoding isn't valid DER, or if the number is negative.
+//
+// The announced length of z will be 8 times the number of content bytes,
+// which leaks the true size of z, as with MarshalASN1.
+func (z *Nat) UnmarshalASN1(data []byte) error {
+	contents, err := parseDERInteger(data)
+	if err != nil {
+		return err
+	}
+	if contents[0]&0x80 != 0 {
+		return errors.New("asn1: negative INTEGER")
+	}
+	z.SetBytes(contents)
+	return nil
+}
+
+// MarshalASN1 encodes this number as an ASN.1 DER INTEGER.
+//
+// DER requires a minimal two's complement encoding, so this leaks the true
+// size of z, as well as its sign.
+func (z *Int) MarshalASN1() ([]byte, error) {
+	return marshalDERInteger(minimalTwos(z.Bytes())), nil
+}
+
+// UnmarshalASN1 decodes an ASN.1 DER INTEGER into z.
+//
+// An error is returned if the encoding isn't valid DER.
+//
+// The announced length of z will be 8 times the number of content bytes,
+// as with SetBytesSigned.
+func (z *Int) UnmarshalASN1(data []byte) error {
+	contents, err := parseDERInteger(data)
+	if err != nil {
+		return err
+	}
+	z.SetBytesSigned(contents)
+	return nil
+}
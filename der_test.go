@@ -0,0 +1,88 @@
+package saferith
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"testing"
+	"testing/quick"
+)
+
+func testIntASN1MatchesStdlib(x *Int) bool {
+	actual, err := x.MarshalASN1()
+	if err != nil {
+		return false
+	}
+	expected, err := asn1.Marshal(x.Big())
+	if err != nil {
+		return false
+	}
+	if !bytes.Equal(expected, actual) {
+		return false
+	}
+	y := new(Int)
+	err = y.UnmarshalASN1(actual)
+	if err != nil {
+		return false
+	}
+	return x.Eq(y) == 1
+}
+
+func TestIntASN1MatchesStdlib(t *testing.T) {
+	err := quick.Check(testIntASN1MatchesStdlib, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testNatASN1MatchesStdlib(x Nat) bool {
+	actual, err := x.MarshalASN1()
+	if err != nil {
+		return false
+	}
+	expected, err := asn1.Marshal(x.Big())
+	if err != nil {
+		return false
+	}
+	if !bytes.Equal(expected, actual) {
+		return false
+	}
+	y := new(Nat)
+	err = y.UnmarshalASN1(actual)
+	if err != nil {
+		return false
+	}
+	return x.Eq(y) == 1
+}
+
+func TestNatASN1MatchesStdlib(t *testing.T) {
+	err := quick.Check(testNatASN1MatchesStdlib, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestASN1Invalid(t *testing.T) {
+	invalid := [][]byte{
+		// wrong tag
+		{0x04, 0x01, 0x01},
+		// empty
+		{0x02, 0x00},
+		// non-minimal contents
+		{0x02, 0x02, 0x00, 0x01},
+		{0x02, 0x02, 0xFF, 0x80},
+		// non-minimal length
+		{0x02, 0x81, 0x01, 0x01},
+		// wrong length
+		{0x02, 0x02, 0x01},
+	}
+	x := new(Int)
+	for _, data := range invalid {
+		if x.UnmarshalASN1(data) == nil {
+			t.Errorf("expected error unmarshalling %+v", data)
+		}
+	}
+	n := new(Nat)
+	if n.UnmarshalASN1([]byte{0x02, 0x01, 0x80}) == nil {
+		t.Errorf("expected error unmarshalling negative number")
+	}
+}
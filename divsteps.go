@@ -0,0 +1,149 @@
+package saferith
+
+// This file implements modular inversion through the "divsteps" GCD of
+// Bernstein and Yang:
+//
+//   https://eprint.iacr.org/2019/266
+//
+// Each divstep only looks at the parity of g, and the sign of delta, which
+// makes it easy to perform in constant-time, with a fixed number of iterations
+// depending only on the announced size of the modulus.
+
+// divstepsIterations returns the number of divsteps needed for inputs of some number of bits
+//
+// These are the bounds established in the paper, in Theorem 11.2.
+func divstepsIterations(bits int) int {
+	if bits < 46 {
+		return (49*bits + 80) / 17
+	}
+	return (49*bits + 57) / 17
+}
+
+// ctNeg sets z <- -x, in two's complement
+func ctNeg(z []Word, x []Word) {
+	// -x = ~x + 1
+	for i := 0; i < len(z); i++ {
+		z[i] = ^x[i]
+	}
+	addVW(z, z, 1)
+}
+
+// ctNegMod sets z <- -x mod m, assuming that x is reduced
+func ctNegMod(z []Word, x []Word, m []Word) {
+	isZero := cmpZero(x)
+	subVV(z, m, x)
+	// m - 0 = m, which isn't reduced
+	for i := 0; i < len(z); i++ {
+		z[i] &= -Word(1 ^ isZero)
+	}
+}
+
+// ctAddMod sets z <- x + y mod m, assuming that x and y are reduced
+//
+// scratch must have the same size as m.
+func ctAddMod(z []Word, x []Word, y []Word, m []Word, scratch []Word) {
+	carry := addVV(z, x, y)
+	borrow := subVV(scratch, z, m)
+	// We subtract m if the sum overflowed, or if it's at least m
+	ctCondCopy(Choice(carry|(1^borrow)), z, scratch)
+}
+
+// ctHalfMod sets x <- x / 2 mod m, assuming that m is odd, and x reduced
+func ctHalfMod(x []Word, m []Word) {
+	// Adding m when x is odd gives us an even number, with the same residue
+	odd := Choice(x[0] & 1)
+	mask := -Word(odd)
+	var carry Word
+	for i := 0; i < len(x); i++ {
+		x[i], carry = add(x[i], m[i]&mask, carry)
+	}
+	shrVU(x, x, 1)
+	x[len(x)-1] |= carry << (_W - 1)
+}
+
+// divstepsInverse sets z <- x^-1 mod m, returning 1 if x was invertible
+//
+// This assumes that m is odd, and that x is already reduced modulo m.
+// Neither of them need to be truncated to their true size. This will only
+// leak the announced sizes of x and m.
+//
+// When x isn't invertible, z will contain an unspecified value.
+func (z *Nat) divstepsInverse(x *Nat, m *Nat) Choice {
+	size := limbCount(m.announced)
+	mLimbs := m.unaliasedLimbs(z)[:size]
+	// f and g are signed, and stay bounded by m in absolute value. We still
+	// need room for the sign, along with the intermediate value g + f,
+	// so we use an extra limb.
+	buf := make([]Word, 3*(size+1)+4*size)
+	f := buf[:size+1]
+	g := buf[size+1 : 2*(size+1)]
+	negF := buf[2*(size+1) : 3*(size+1)]
+	copy(f, mLimbs)
+	copy(g, x.extendedLimbs(m.announced))
+	// The coefficients d and e are reduced modulo m, and satisfy the invariants
+	//
+	//   f = d * x mod m
+	//   g = e * x mod m
+	d := buf[3*(size+1) : 3*(size+1)+size]
+	e := buf[3*(size+1)+size : 3*(size+1)+2*size]
+	negD := buf[3*(size+1)+2*size : 3*(size+1)+3*size]
+	t := buf[3*(size+1)+3*size:]
+	// e = 1, unless m = 1, in which case 1 isn't reduced
+	copy(t, mLimbs)
+	t[0] ^= 1
+	e[0] = Word(1 ^ cmpZero(t))
+
+	// delta is signed, but small enough to never overflow
+	delta := Word(1)
+	iterations := divstepsIterations(m.announced)
+	for i := 0; i < iterations; i++ {
+		gOdd := Choice(g[0] & 1)
+		// delta > 0 if and only if -delta is negative
+		swap := Choice((-delta)>>(_W-1)) & gOdd
+		// When swapping, we move to (-delta, g, -f, e, -d)
+		ctNeg(negF, f)
+		ctNegMod(negD, d, mLimbs)
+		ctCondCopy(swap, f, g)
+		ctCondCopy(swap, g, negF)
+		ctCondCopy(swap, d, e)
+		ctCondCopy(swap, e, negD)
+		delta = ctIfElse(swap, -delta, delta)
+		// Now, g is odd exactly when it was before, and we can add in f
+		// to make it even, which is what the remaining step needs.
+		var carry Word
+		mask := -Word(gOdd)
+		for j := 0; j < len(g); j++ {
+			g[j], carry = add(g[j], f[j]&mask, carry)
+		}
+		// e also needs d added in, under the same condition
+		for j := 0; j < size; j++ {
+			t[j] = d[j] & mask
+		}
+		ctAddMod(e, e, t, mLimbs, negD)
+		// g is even, so we can shift, preserving the sign
+		sign := g[len(g)-1] >> (_W - 1)
+		shrVU(g, g, 1)
+		g[len(g)-1] |= sign << (_W - 1)
+		ctHalfMod(e, mLimbs)
+		delta++
+	}
+
+	// At this point g = 0, and f = ±gcd(x, m), so x is invertible exactly when f = ±1
+	fNegative := Choice(f[len(f)-1] >> (_W - 1))
+	ctNeg(negF, f)
+	ctCondCopy(fNegative, f, negF)
+	f[0] ^= 1
+	ok := cmpZero(f)
+	// Since f = d * x mod m, we need to negate d if f = -1
+	ctNegMod(negD, d, mLimbs)
+	ctCondCopy(fNegative, d, negD)
+
+	z.limbs = z.resizedLimbs(m.announced)
+	copy(z.limbs, d)
+	z.announced = m.announced
+	z.reduced = nil
+	if zeroizing() {
+		clearLimbs(buf)
+	}
+	return ok
+}
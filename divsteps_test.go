@@ -0,0 +1,68 @@
+package saferith
+
+import (
+	"math/big"
+	"testing"
+	"testing/quick"
+)
+
+func testDivstepsInverseMatchesBig(x Nat, m Modulus, padding uint8) bool {
+	if m.even {
+		return true
+	}
+	xModM := new(Nat).Mod(&x, &m)
+	// The modulus doesn't need to be truncated to its true size
+	mNat := m.Nat().Resize(m.BitLen() + int(padding))
+	actual := new(Nat)
+	ok := actual.divstepsInverse(xModM, mNat)
+	if !actual.checkInvariants() {
+		return false
+	}
+	if m.Big().Cmp(big.NewInt(1)) == 0 {
+		return ok == 1 && actual.EqZero() == 1
+	}
+	expected := new(big.Int).ModInverse(x.Big(), m.Big())
+	if expected == nil {
+		return ok == 0
+	}
+	return ok == 1 && actual.Big().Cmp(expected) == 0
+}
+
+func TestDivstepsInverseMatchesBig(t *testing.T) {
+	err := quick.Check(testDivstepsInverseMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDivstepsInverseExamples(t *testing.T) {
+	large := ModulusFromBytes(modulus2048())
+	largeMinus := func(c uint64) *Nat {
+		return new(Nat).Sub(large.Nat(), new(Nat).SetUint64(c), large.BitLen())
+	}
+	cases := []struct {
+		x *Nat
+		m *Modulus
+	}{
+		{new(Nat).SetUint64(6), ModulusFromUint64(15)},
+		{new(Nat).SetUint64(7), ModulusFromUint64(15)},
+		{new(Nat).SetUint64(0), ModulusFromUint64(15)},
+		{new(Nat).SetUint64(1), large},
+		{new(Nat).SetUint64(2), large},
+		{largeMinus(1), large},
+		{largeMinus(2), large},
+		{new(Nat).SetUint64(0), large},
+	}
+	for _, c := range cases {
+		actual := new(Nat)
+		ok := actual.divstepsInverse(c.x, c.m.Nat())
+		expected := new(big.Int).ModInverse(c.x.Big(), c.m.Big())
+		if (expected != nil) != (ok == 1) {
+			t.Errorf("%+v^-1 mod %+v: %+v != %+v", c.x, c.m, expected != nil, ok)
+			continue
+		}
+		if expected != nil && actual.Big().Cmp(expected) != 0 {
+			t.Errorf("%+v != %+v", expected, actual)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package saferith
+
+import (
+	"errors"
+)
+
+// Field represents the integers modulo an odd prime p.
+//
+// Unlike operations on Nat, which take a Modulus each time, elements of a Field
+// are bound to it, and stay in Montgomery form between operations. This avoids
+// converting in and out of Montgomery form on every multiplication, and makes
+// for a more convenient API when implementing things like elliptic curves.
+//
+// Like with Modulus, the size of p is considered to be public.
+type Field struct {
+	p *Modulus
+}
+
+// NewField creates a new Field, using p as its modulus.
+//
+// p should be prime, for Inverse and Sqrt to work correctly, but this isn't checked.
+// An error is returned if p is even, since Montgomery form requires an odd modulus.
+func NewField(p *Modulus) (*Field, error) {
+	if p.even {
+		return nil, errors.New("field modulus must be odd")
+	}
+	return &Field{p: p}, nil
+}
+
+// Modulus returns the modulus of this field.
+func (f *Field) Modulus() *Modulus {
+	return f.p
+}
+
+// Element returns a new element of this field, with the value 0.
+func (f *Field) Element() *FieldElement {
+	size := len(f.p.nat.limbs)
+	return &FieldElement{field: f, limbs: make([]Word, size)}
+}
+
+// FieldElement represents an element of a Field.
+//
+// Internally, an element x is stored as xR mod p, in Montgomery form.
+// Elements from different fields can't be mixed, and attempting to do so panics.
+type FieldElement struct {
+	field *Field
+	limbs []Word
+	// Scratch space for multiplication and squaring, allocated on first use
+	scratch []Word
+}
+
+// checkField panics if the elements don't all belong to the same field as z.
+func (z *FieldElement) checkField(xs ...*FieldElement) {
+	for _, x := range xs {
+		if x.field != z.field {
+			panic("field element: mismatched fields")
+		}
+	}
+}
+
+// getScratch returns twice the number of limbs in p, as scratch space.
+func (z *FieldElement) getScratch() []Word {
+	if z.scratch == nil {
+		z.scratch = make([]Word, 2*len(z.limbs))
+	}
+	return z.scratch
+}
+
+// wipe clears the scratch space of z, along with some temporary values, if zeroization is enabled.
+//
+// Operations leave intermediate values derived from their arguments in the scratch space.
+func (z *FieldElement) wipe(temporaries ...*Nat) {
+	if !zeroizing() {
+		return
+	}
+	clearLimbs(z.scratch)
+	for _, t := range temporaries {
+		t.Clear()
+	}
+}
+
+// Field returns the field this element belongs to.
+func (z *FieldElement) Field() *Field {
+	return z.field
+}
+
+// Set sets z <- x, returning z.
+func (z *FieldElement) Set(x *FieldElement) *FieldElement {
+	z.checkField(x)
+	copy(z.limbs, x.limbs)
+	return z
+}
+
+// SetNat sets z <- x mod p, returning z.
+func (z *FieldElement) SetNat(x *Nat) *FieldElement {
+	p := z.field.p
+	xModP := new(Nat).Mod(x, p)
+	// Multiplying by R^2 gives us xR^2 / R = xR
+	montgomeryMul(xModP.limbs, p.reduction().rr, z.limbs, z.getScratch(), p)
+	z.wipe(xModP)
+	return z
+}
+
+// SetUint64 sets z <- x mod p, returning z.
+func (z *FieldElement) SetUint64(x uint64) *FieldElement {
+	return z.SetNat(new(Nat).SetUint64(x))
+}
+
+// Nat returns the value of this element, as a Nat reduced modulo p.
+func (z *FieldElement) Nat() *Nat {
+	p := z.field.p
+	out := new(Nat)
+	out.limbs = make([]Word, len(z.limbs))
+	one := make([]Word, len(z.limbs))
+	one[0] = 1
+	// xR / R = x
+	montgomeryMul(z.limbs, one, out.limbs, z.getScratch(), p)
+	out.announced = p.nat.announced
+	out.reduced = p
+	z.wipe()
+	return out
+}
+
+// Eq checks if z = x.
+func (z *FieldElement) Eq(x *FieldElement) Choice {
+	z.checkField(x)
+	return cmpEq(z.limbs, x.limbs)
+}
+
+// IsZero checks if z = 0.
+func (z *FieldElement) IsZero() Choice {
+	return cmpZero(z.limbs)
+}
+
+// Select sets z <- yes ? x : y, returning z.
+//
+// This doesn't leak which value was selected.
+func (z *FieldElement) Select(yes Choice, x *FieldElement, y *FieldElement) *FieldElement {
+	z.checkField(x, y)
+	for i := 0; i < len(z.limbs); i++ {
+		z.limbs[i] = ctIfElse(yes, x.limbs[i], y.limbs[i])
+	}
+	return z
+}
+
+// Add sets z <- x + y, returning z.
+func (z *FieldElement) Add(x *FieldElement, y *FieldElement) *FieldElement {
+	z.checkField(x, y)
+	scratch := z.getScratch()[:len(z.limbs)]
+	// See ModAdd for why comparing the carries selects the right result
+	addCarry := addVV(z.limbs, x.limbs, y.limbs)
+	subCarry := subVV(scratch, z.limbs, z.field.p.nat.limbs)
+	ctCondCopy(ctEq(addCarry, subCarry), z.limbs, scratch)
+	z.wipe()
+	return z
+}
+
+// Sub sets z <- x - y, returning z.
+func (z *FieldElement) Sub(x *FieldElement, y *FieldElement) *FieldElement {
+	z.checkField(x, y)
+	scratch := z.getScratch()[:len(z.limbs)]
+	underflow := Choice(subVV(z.limbs, x.limbs, y.limbs))
+	addVV(scratch, z.limbs, z.field.p.nat.limbs)
+	ctCondCopy(underflow, z.limbs, scratch)
+	z.wipe()
+	return z
+}
+
+// Neg sets z <- -x, returning z.
+func (z *FieldElement) Neg(x *FieldElement) *FieldElement {
+	return z.Sub(z.field.Element(), x)
+}
+
+// Mul sets z <- x * y, returning z.
+func (z *FieldElement) Mul(x *FieldElement, y *FieldElement) *FieldElement {
+	z.checkField(x, y)
+	// xR * yR / R = xyR
+	montgomeryMul(x.limbs, y.limbs, z.limbs, z.getScratch(), z.field.p)
+	z.wipe()
+	return z
+}
+
+// Square sets z <- x^2, returning z.
+//
+// This is faster than calling Mul with the same argument twice.
+func (z *FieldElement) Square(x *FieldElement) *FieldElement {
+	z.checkField(x)
+	montgomerySqr(x.limbs, z.limbs, z.getScratch(), z.field.p)
+	z.wipe()
+	return z
+}
+
+// Inverse sets z <- x^-1, returning z.
+//
+// If x is zero, then z is set to zero as well.
+func (z *FieldElement) Inverse(x *FieldElement) *FieldElement {
+	z.checkField(x)
+	xNat := x.Nat()
+	inv := new(Nat).ModInverse(xNat, z.field.p)
+	ctCondCopy(xNat.EqZero(), inv.limbs, xNat.limbs)
+	z.SetNat(inv)
+	z.wipe(xNat, inv)
+	return z
+}
+
+// Sqrt sets z <- sqrt(x), returning 1 if x was a square.
+//
+// If x is not a square, then 0 is returned, and the value of z is unspecified.
+// Like Nat.ModSqrt, this leaks whether or not p = 3 mod 4.
+func (z *FieldElement) Sqrt(x *FieldElement) Choice {
+	z.checkField(x)
+	xNat := x.Nat()
+	root, ok := new(Nat).ModSqrtChecked(xNat, z.field.p)
+	z.SetNat(root)
+	z.wipe(xNat, root)
+	return ok
+}
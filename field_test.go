@@ -0,0 +1,203 @@
+package saferith
+
+import (
+	"math/big"
+	"testing"
+	"testing/quick"
+)
+
+// The P-256 and Curve25519 primes, being 3 mod 4 and 1 mod 4 respectively
+var fieldTestPrimes = []string{
+	"FFFFFFFF00000001000000000000000000000000FFFFFFFFFFFFFFFFFFFFFFFF",
+	"7FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFED",
+}
+
+func testFields(t *testing.T) []*Field {
+	var fields []*Field
+	for _, hex := range fieldTestPrimes {
+		p, err := ModulusFromHex(hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := NewField(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func TestNewFieldRejectsEvenModulus(t *testing.T) {
+	_, err := NewField(ModulusFromUint64(10))
+	if err == nil {
+		t.Error("expected an error for an even modulus")
+	}
+}
+
+func TestFieldNatRoundTrip(t *testing.T) {
+	for _, f := range testFields(t) {
+		err := quick.Check(func(x Nat) bool {
+			actual := f.Element().SetNat(&x).Nat()
+			if !actual.checkInvariants() {
+				return false
+			}
+			return actual.Eq(new(Nat).Mod(&x, f.Modulus())) == 1
+		}, &quick.Config{})
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestFieldArithmeticMatchesBig(t *testing.T) {
+	for _, f := range testFields(t) {
+		p := f.Modulus().Big()
+		err := quick.Check(func(x Nat, y Nat) bool {
+			xBig := new(big.Int).Mod(x.Big(), p)
+			yBig := new(big.Int).Mod(y.Big(), p)
+			a := f.Element().SetNat(&x)
+			b := f.Element().SetNat(&y)
+			check := func(z *FieldElement, expected *big.Int) bool {
+				return z.Nat().Big().Cmp(new(big.Int).Mod(expected, p)) == 0
+			}
+			if !check(f.Element().Add(a, b), new(big.Int).Add(xBig, yBig)) {
+				return false
+			}
+			if !check(f.Element().Sub(a, b), new(big.Int).Sub(xBig, yBig)) {
+				return false
+			}
+			if !check(f.Element().Neg(a), new(big.Int).Neg(xBig)) {
+				return false
+			}
+			if !check(f.Element().Mul(a, b), new(big.Int).Mul(xBig, yBig)) {
+				return false
+			}
+			if !check(f.Element().Square(a), new(big.Int).Mul(xBig, xBig)) {
+				return false
+			}
+			expectedInv := new(big.Int).ModInverse(xBig, p)
+			if expectedInv == nil {
+				expectedInv = new(big.Int)
+			}
+			return check(f.Element().Inverse(a), expectedInv)
+		}, &quick.Config{})
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestFieldAliasing(t *testing.T) {
+	for _, f := range testFields(t) {
+		err := quick.Check(func(x Nat, y Nat) bool {
+			a := f.Element().SetNat(&x)
+			b := f.Element().SetNat(&y)
+			expected := f.Element().Mul(a, b)
+			expected.Add(expected, a)
+			actual := f.Element().Set(a)
+			actual.Mul(actual, b)
+			actual.Add(actual, a)
+			return actual.Eq(expected) == 1
+		}, &quick.Config{})
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestFieldSqrt(t *testing.T) {
+	for _, f := range testFields(t) {
+		err := quick.Check(func(x Nat) bool {
+			a := f.Element().SetNat(&x)
+			square := f.Element().Square(a)
+			root := f.Element()
+			if root.Sqrt(square) != 1 {
+				return false
+			}
+			return f.Element().Square(root).Eq(square) == 1
+		}, &quick.Config{})
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestFieldSqrtNonSquare(t *testing.T) {
+	for _, f := range testFields(t) {
+		// Both primes are large enough for 2 or 3 to be a non residue
+		p := f.Modulus().Big()
+		var nonSquare uint64 = 2
+		if big.Jacobi(big.NewInt(2), p) == 1 {
+			nonSquare = 3
+		}
+		if big.Jacobi(new(big.Int).SetUint64(nonSquare), p) != -1 {
+			t.Fatal("test constant is a square")
+		}
+		if f.Element().Sqrt(f.Element().SetUint64(nonSquare)) != 0 {
+			t.Errorf("%d should not have a square root", nonSquare)
+		}
+	}
+}
+
+func TestFieldInverseOfZero(t *testing.T) {
+	for _, f := range testFields(t) {
+		inv := f.Element().Inverse(f.Element())
+		if inv.IsZero() != 1 {
+			t.Errorf("%+v != 0", inv.Nat())
+		}
+	}
+}
+
+func TestFieldSelect(t *testing.T) {
+	f := testFields(t)[0]
+	a := f.Element().SetUint64(3)
+	b := f.Element().SetUint64(5)
+	if f.Element().Select(1, a, b).Eq(a) != 1 {
+		t.Error("Select(1, a, b) != a")
+	}
+	if f.Element().Select(0, a, b).Eq(b) != 1 {
+		t.Error("Select(0, a, b) != b")
+	}
+}
+
+func TestFieldMismatchPanics(t *testing.T) {
+	fields := testFields(t)
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+	fields[0].Element().Add(fields[0].Element(), fields[1].Element())
+}
+
+func TestFieldZeroizationClearsScratch(t *testing.T) {
+	SetZeroization(true)
+	defer SetZeroization(false)
+	for _, f := range testFields(t) {
+		a := f.Element().SetUint64(3)
+		b := f.Element().SetUint64(5)
+		z := f.Element()
+		ops := []func(){
+			func() { z.SetUint64(7) },
+			func() { z.Add(a, b) },
+			func() { z.Sub(a, b) },
+			func() { z.Mul(a, b) },
+			func() { z.Square(a) },
+			func() { z.Inverse(a) },
+			func() { z.Sqrt(a) },
+			func() { z.Nat() },
+		}
+		for _, op := range ops {
+			op()
+			for _, limb := range append(z.scratch, a.scratch...) {
+				if limb != 0 {
+					t.Errorf("scratch limb %+v was not cleared", limb)
+				}
+			}
+		}
+		if z.Mul(a, b).Nat().EqUint64(15) != 1 {
+			t.Errorf("%+v != 15", z.Nat())
+		}
+	}
+}
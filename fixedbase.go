@@ -0,0 +1,150 @@
+package saferith
+
+// fixedBaseWindow is the number of exponent bits handled by each table in a FixedBase.
+const fixedBaseWindow = 4
+
+// FixedBase holds precomputed powers of a fixed base, modulo some modulus.
+//
+// This makes exponentiating that base considerably faster than with Exp, which is
+// useful when the same public base, like a group generator g, gets raised to many
+// different secret exponents, as in Diffie-Hellman, Schnorr, or Pedersen commitments.
+//
+// For each window of 4 bits in the exponent, we store a table of the 16 possible
+// powers of the base. An exponentiation then needs no squarings, only one
+// multiplication per window, with each entry being selected in constant time.
+// This costs 16 * bits / 4 numbers of memory, the size of the modulus each.
+//
+// The base, the modulus, and the number of bits are all considered public.
+// A FixedBase is never modified after creation, so it can be shared between goroutines.
+type FixedBase struct {
+	g    *Nat
+	m    *Modulus
+	bits int
+	// one is 1, in the representation used by the tables
+	one *Nat
+	// tables[i][k] = g^(k * 2^(4i)) mod m, in Montgomery form for odd moduli
+	tables [][1 << fixedBaseWindow]*Nat
+}
+
+// NewFixedBase precomputes the powers of g modulo m, for exponents of up to bits bits.
+//
+// Exponents with a larger announced length can still be used, but don't benefit
+// from the precomputation.
+func NewFixedBase(g *Nat, m *Modulus, bits int) *FixedBase {
+	size := len(m.nat.limbs)
+	scratch := make([]Word, 2*size)
+	newReduced := func() *Nat {
+		out := new(Nat)
+		out.limbs = make([]Word, size)
+		out.announced = m.nat.announced
+		out.reduced = m
+		return out
+	}
+
+	// one will be 1 in the representation we're working with, reduced in case m = 1
+	one := new(Nat).SetUint64(1)
+	one.Mod(one, m)
+	base := new(Nat).Mod(g, m)
+	if !m.even {
+		montgomeryMul(one.limbs, m.reduction().rr, one.limbs, scratch, m)
+		montgomeryMul(base.limbs, m.reduction().rr, base.limbs, scratch, m)
+	}
+
+	tables := make([][1 << fixedBaseWindow]*Nat, (bits+fixedBaseWindow-1)/fixedBaseWindow)
+	for i := range tables {
+		tables[i][0] = one
+		tables[i][1] = base
+		for k := 2; k < len(tables[i]); k++ {
+			tables[i][k] = newReduced()
+			expMul(tables[i][k], tables[i][k-1], base, scratch, m)
+		}
+		// The base for the next window is base^16
+		next := newReduced()
+		expMul(next, tables[i][len(tables[i])-1], base, scratch, m)
+		base = next
+	}
+	return &FixedBase{g: new(Nat).Mod(g, m), m: m, bits: bits, one: one, tables: tables}
+}
+
+// Modulus returns the modulus the powers of this base are reduced by.
+func (b *FixedBase) Modulus() *Modulus {
+	return b.m
+}
+
+// Bits returns the largest exponent length this base has precomputed powers for.
+func (b *FixedBase) Bits() int {
+	return b.bits
+}
+
+// ExpFixedBase calculates z <- g^y mod m, using the precomputed powers of g in b.
+//
+// Like Exp, only the announced length of y is leaked. If y is announced to
+// be larger than b.Bits(), then this falls back to calling Exp.
+//
+// The capacity of the resulting number matches the capacity of the modulus.
+func (z *Nat) ExpFixedBase(b *FixedBase, y *Nat) *Nat {
+	m := b.m
+	// LEAK: y's length
+	// OK: this should be public
+	if y.announced > b.bits {
+		return z.Exp(b.g, y, m)
+	}
+	size := len(m.nat.limbs)
+	scratch := make([]Word, 2*size)
+	acc := new(Nat).SetNat(b.one)
+	selected := new(Nat).SetNat(b.one)
+	b.mulPow(acc, y, selected, scratch)
+	b.finish(z, acc, scratch)
+	b.wipe(z, acc, selected, scratch)
+	return z
+}
+
+// wipe clears the temporary values used to exponentiate, if zeroization is enabled.
+//
+// These are all derived from the secret exponent.
+func (b *FixedBase) wipe(z *Nat, acc *Nat, selected *Nat, scratch []Word) {
+	if zeroizing() {
+		clearLimbs(scratch)
+	}
+	wipeTemporaries(z, acc, selected)
+}
+
+// finish sets z <- acc, converting it out of the representation used by the tables.
+//
+// scratch should have twice the length of the modulus.
+func (b *FixedBase) finish(z *Nat, acc *Nat, scratch []Word) *Nat {
+	if !b.m.even {
+		// Multiplying by 1 takes us back out of Montgomery form
+		size := len(b.m.nat.limbs)
+		one := scratch[size:]
+		for i := range one {
+			one[i] = 0
+		}
+		one[0] = 1
+		montgomeryMul(acc.limbs, one, acc.limbs, scratch[:size], b.m)
+	}
+	return z.SetNat(acc)
+}
+
+// mulPow sets acc <- acc * g^y mod m, in the representation used by the tables.
+//
+// y must not be larger than b.bits, and selected is used to hold table entries.
+func (b *FixedBase) mulPow(acc *Nat, y *Nat, selected *Nat, scratch []Word) {
+	yLimbs := y.extendedLimbs(y.announced)
+	// LEAK: y's length
+	// OK: this should be public
+	windows := (y.announced + fixedBaseWindow - 1) / fixedBaseWindow
+	for i := 0; i < windows; i++ {
+		b.mulWindow(acc, yLimbs, i, selected, scratch)
+	}
+}
+
+// mulWindow sets acc <- acc * g^(w * 2^(4i)) mod m, where w is the ith window of y.
+func (b *FixedBase) mulWindow(acc *Nat, yLimbs []Word, i int, selected *Nat, scratch []Word) {
+	shift := uint(i*fixedBaseWindow) % _W
+	window := (yLimbs[i*fixedBaseWindow/_W] >> shift) & (1<<fixedBaseWindow - 1)
+	for k := 0; k < len(b.tables[i]); k++ {
+		ctCondCopy(ctEq(window, Word(k)), selected.limbs, b.tables[i][k].limbs)
+	}
+	expMul(acc, acc, selected, scratch, b.m)
+}
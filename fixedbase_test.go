@@ -0,0 +1,58 @@
+package saferith
+
+import (
+	"testing"
+	"testing/quick"
+)
+
+func testExpFixedBaseMatchesExp(g Nat, y Nat, m Modulus, extra uint8) bool {
+	base := NewFixedBase(&g, &m, y.AnnouncedLen()+int(extra))
+	expected := new(Nat).Exp(&g, &y, &m)
+	actual := new(Nat).ExpFixedBase(base, &y)
+	return actual.checkInvariants() && actual.AnnouncedLen() == expected.AnnouncedLen() && actual.Eq(expected) == 1
+}
+
+func TestExpFixedBaseMatchesExp(t *testing.T) {
+	err := quick.Check(testExpFixedBaseMatchesExp, &quick.Config{MaxCount: 50})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testExpFixedBaseLargeExponent(g Nat, y Nat, m Modulus) bool {
+	// Exponents larger than the precomputed tables fall back to Exp
+	base := NewFixedBase(&g, &m, y.AnnouncedLen()/2)
+	expected := new(Nat).Exp(&g, &y, &m)
+	return new(Nat).ExpFixedBase(base, &y).Eq(expected) == 1
+}
+
+func TestExpFixedBaseLargeExponent(t *testing.T) {
+	err := quick.Check(testExpFixedBaseLargeExponent, &quick.Config{MaxCount: 20})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExpFixedBaseAliasing(t *testing.T) {
+	m := ModulusFromUint64(1000003)
+	base := NewFixedBase(new(Nat).SetUint64(2), m, 64)
+	y := new(Nat).SetUint64(0xDEADBEEF)
+	expected := new(Nat).Exp(new(Nat).SetUint64(2), y, m)
+	y.ExpFixedBase(base, y)
+	if y.Eq(expected) != 1 {
+		t.Errorf("%+v != %+v", expected, y)
+	}
+}
+
+func TestExpFixedBaseExamples(t *testing.T) {
+	for _, m := range []*Modulus{ModulusFromUint64(1), ModulusFromUint64(13), ModulusFromUint64(16)} {
+		base := NewFixedBase(new(Nat).SetUint64(3), m, 16)
+		for e := uint64(0); e < 20; e++ {
+			expected := new(Nat).Exp(new(Nat).SetUint64(3), new(Nat).SetUint64(e), m)
+			actual := new(Nat).ExpFixedBase(base, new(Nat).SetUint64(e).Resize(16))
+			if actual.Eq(expected) != 1 {
+				t.Errorf("%+v != %+v", expected, actual)
+			}
+		}
+	}
+}
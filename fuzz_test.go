@@ -0,0 +1,172 @@
+//go:build go1.18
+// +build go1.18
+
+package saferith
+
+import (
+	"math/big"
+	"testing"
+)
+
+// These fuzz targets compare the results of operations against math/big.
+//
+// Each input is given as big endian bytes, along with some extra bits of
+// capacity, so that different announced sizes get explored as well. Run with:
+//
+//	go test -fuzz FuzzMul
+
+// The largest input the fuzz targets consider, in bytes, to keep them fast
+const fuzzMaxBytes = 128
+
+// fuzzNat creates a Nat from bytes, announcing extra bits beyond what's needed
+func fuzzNat(bytes []byte, extra uint8) *Nat {
+	if len(bytes) > fuzzMaxBytes {
+		bytes = bytes[:fuzzMaxBytes]
+	}
+	x := new(Nat).SetBytes(bytes)
+	return x.Resize(x.AnnouncedLen() + int(extra))
+}
+
+// fuzzModulus creates a Modulus from bytes, returning nil if the bytes are 0
+func fuzzModulus(t *testing.T, bytes []byte) *Modulus {
+	if len(bytes) > fuzzMaxBytes {
+		bytes = bytes[:fuzzMaxBytes]
+	}
+	if new(big.Int).SetBytes(bytes).Sign() == 0 {
+		// A zero modulus must be rejected
+		defer func() {
+			if recover() == nil {
+				t.Errorf("ModulusFromBytes(%x) didn't panic", bytes)
+			}
+		}()
+		ModulusFromBytes(bytes)
+		return nil
+	}
+	return ModulusFromBytes(bytes)
+}
+
+// fuzzSeeds adds seeds around limb boundaries, where carries matter
+func fuzzSeeds(f *testing.F, add func(x []byte, y []byte)) {
+	values := [][]byte{
+		{},
+		{0},
+		{1},
+		{0xFF, 0xFF, 0xFF, 0xFF},
+		{0x01, 0x00, 0x00, 0x00, 0x00},
+		{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF},
+		{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		{0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01},
+	}
+	for _, x := range values {
+		for _, y := range values {
+			add(x, y)
+		}
+	}
+}
+
+func fuzzCheck(t *testing.T, op string, expected *big.Int, actual *Nat) {
+	if !actual.checkInvariants() {
+		t.Errorf("%s: invalid result %+v", op, actual)
+	}
+	if actual.Big().Cmp(expected) != 0 {
+		t.Errorf("%s: %v != %v", op, expected, actual.Big())
+	}
+}
+
+func FuzzAdd(f *testing.F) {
+	skipIfCheckingCaps(f)
+	fuzzSeeds(f, func(x []byte, y []byte) {
+		f.Add(x, uint8(0), y, uint8(0), uint16(64))
+	})
+	f.Fuzz(func(t *testing.T, xBytes []byte, xExtra uint8, yBytes []byte, yExtra uint8, cap uint16) {
+		x := fuzzNat(xBytes, xExtra)
+		y := fuzzNat(yBytes, yExtra)
+		c := int(cap % (8*fuzzMaxBytes + 128))
+		bound := new(big.Int).Lsh(big.NewInt(1), uint(c))
+		expected := new(big.Int).Add(x.Big(), y.Big())
+		fuzzCheck(t, "Add", new(big.Int).Mod(expected, bound), new(Nat).Add(x, y, c))
+		expected = new(big.Int).Sub(x.Big(), y.Big())
+		fuzzCheck(t, "Sub", new(big.Int).Mod(expected, bound), new(Nat).Sub(x, y, c))
+	})
+}
+
+func FuzzMul(f *testing.F) {
+	skipIfCheckingCaps(f)
+	fuzzSeeds(f, func(x []byte, y []byte) {
+		f.Add(x, uint8(0), y, uint8(0), uint16(128))
+	})
+	f.Fuzz(func(t *testing.T, xBytes []byte, xExtra uint8, yBytes []byte, yExtra uint8, cap uint16) {
+		x := fuzzNat(xBytes, xExtra)
+		y := fuzzNat(yBytes, yExtra)
+		c := int(cap % (16*fuzzMaxBytes + 128))
+		bound := new(big.Int).Lsh(big.NewInt(1), uint(c))
+		expected := new(big.Int).Mul(x.Big(), y.Big())
+		fuzzCheck(t, "Mul", new(big.Int).Mod(expected, bound), new(Nat).Mul(x, y, c))
+	})
+}
+
+func FuzzExp(f *testing.F) {
+	fuzzSeeds(f, func(x []byte, m []byte) {
+		f.Add(x, uint8(0), []byte{0x03}, m)
+	})
+	f.Fuzz(func(t *testing.T, xBytes []byte, xExtra uint8, eBytes []byte, mBytes []byte) {
+		m := fuzzModulus(t, mBytes)
+		if m == nil {
+			return
+		}
+		x := fuzzNat(xBytes, xExtra)
+		// Large exponents make this slow, without exploring anything new
+		if len(eBytes) > 16 {
+			eBytes = eBytes[:16]
+		}
+		e := new(Nat).SetBytes(eBytes)
+		expected := new(big.Int).Exp(x.Big(), e.Big(), m.Big())
+		fuzzCheck(t, "Exp", expected, new(Nat).Exp(x, e, m))
+	})
+}
+
+func FuzzModInverse(f *testing.F) {
+	fuzzSeeds(f, func(x []byte, m []byte) {
+		f.Add(x, uint8(0), m)
+	})
+	f.Fuzz(func(t *testing.T, xBytes []byte, xExtra uint8, mBytes []byte) {
+		m := fuzzModulus(t, mBytes)
+		if m == nil {
+			return
+		}
+		x := fuzzNat(xBytes, xExtra)
+		actual, ok := new(Nat).ModInverseChecked(x, m)
+		expected := new(big.Int).ModInverse(x.Big(), m.Big())
+		if m.Big().Cmp(big.NewInt(1)) == 0 {
+			// Everything is invertible mod 1, with 0 as the inverse
+			expected = new(big.Int)
+		}
+		if (expected != nil) != (ok == 1) {
+			t.Fatalf("ModInverseChecked(%v, %v): ok = %v", x.Big(), m.Big(), ok)
+		}
+		if expected != nil {
+			fuzzCheck(t, "ModInverse", expected, actual)
+		}
+	})
+}
+
+func FuzzDiv(f *testing.F) {
+	fuzzSeeds(f, func(x []byte, y []byte) {
+		f.Add(x, uint8(0), y, uint8(0))
+	})
+	f.Fuzz(func(t *testing.T, xBytes []byte, xExtra uint8, yBytes []byte, yExtra uint8) {
+		x := fuzzNat(xBytes, xExtra)
+		y := fuzzNat(yBytes, yExtra)
+		if y.EqZero() == 1 {
+			return
+		}
+		expectedQ, expectedR := new(big.Int).QuoRem(x.Big(), y.Big(), new(big.Int))
+		q, r := new(Nat).DivRem(x, y, -1)
+		fuzzCheck(t, "DivRem quotient", expectedQ, q)
+		fuzzCheck(t, "DivRem remainder", expectedR, r)
+
+		m := ModulusFromNat(y)
+		fuzzCheck(t, "Div", expectedQ, new(Nat).Div(x, m, -1))
+		fuzzCheck(t, "Mod", expectedR, new(Nat).Mod(x, m))
+	})
+}
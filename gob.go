@@ -0,0 +1,40 @@
+package saferith
+
+// This file implements encoding/gob support.
+//
+// gob would fall back to MarshalBinary on its own, but implementing GobEncoder
+// explicitly keeps the format stable, and documents that these types can be
+// sent over net/rpc, or persisted with gob. The encoding is exactly the one
+// produced by MarshalBinary, so announced lengths survive a round trip.
+
+// GobEncode implements gob.GobEncoder, using the same format as MarshalBinary.
+func (i *Nat) GobEncode() ([]byte, error) {
+	return i.MarshalBinary()
+}
+
+// GobDecode implements gob.GobDecoder, expecting the format produced by GobEncode.
+func (i *Nat) GobDecode(data []byte) error {
+	return i.UnmarshalBinary(data)
+}
+
+// GobEncode implements gob.GobEncoder, using the same format as MarshalBinary.
+func (i *Int) GobEncode() ([]byte, error) {
+	return i.MarshalBinary()
+}
+
+// GobDecode implements gob.GobDecoder, expecting the format produced by GobEncode.
+func (i *Int) GobDecode(data []byte) error {
+	return i.UnmarshalBinary(data)
+}
+
+// GobEncode implements gob.GobEncoder, using the same format as MarshalBinary.
+//
+// Like MarshalBinary, this includes the precomputed constants of the modulus.
+func (i *Modulus) GobEncode() ([]byte, error) {
+	return i.MarshalBinary()
+}
+
+// GobDecode implements gob.GobDecoder, expecting the format produced by GobEncode.
+func (i *Modulus) GobDecode(data []byte) error {
+	return i.UnmarshalBinary(data)
+}
@@ -0,0 +1,72 @@
+package saferith
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+	"testing/quick"
+)
+
+// gobValues is a struct holding every type we support, like an RPC argument would.
+type gobValues struct {
+	Nat     *Nat
+	Int     *Int
+	Modulus *Modulus
+}
+
+func gobRoundTrip(in interface{}, out interface{}) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+		return err
+	}
+	return gob.NewDecoder(&buf).Decode(out)
+}
+
+func testGobRoundTrip(x Nat, y *Int, m Modulus) bool {
+	in := gobValues{Nat: &x, Int: y, Modulus: &m}
+	var out gobValues
+	if err := gobRoundTrip(in, &out); err != nil {
+		return false
+	}
+	if out.Nat.Eq(&x) != 1 || out.Nat.AnnouncedLen() != x.AnnouncedLen() || !out.Nat.checkInvariants() {
+		return false
+	}
+	if out.Int.Eq(y) != 1 || out.Int.AnnouncedLen() != y.AnnouncedLen() {
+		return false
+	}
+	_, eq, _ := out.Modulus.Cmp(&m)
+	if eq != 1 {
+		return false
+	}
+	// The decoded modulus should be usable right away
+	expected := new(Nat).ModMul(&x, &x, &m)
+	return new(Nat).ModMul(&x, &x, out.Modulus).Eq(expected) == 1
+}
+
+func TestGobRoundTrip(t *testing.T) {
+	err := quick.Check(testGobRoundTrip, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGobMatchesBinary(t *testing.T) {
+	x := new(Nat).SetUint64(0x1234).Resize(100)
+	gobbed, _ := x.GobEncode()
+	binary, _ := x.MarshalBinary()
+	if !bytes.Equal(gobbed, binary) {
+		t.Errorf("%x != %x", binary, gobbed)
+	}
+}
+
+func TestGobDecodeRejectsGarbage(t *testing.T) {
+	if err := new(Nat).GobDecode([]byte{0xFF}); err == nil {
+		t.Errorf("expected an error for an unknown version")
+	}
+	if err := new(Int).GobDecode(nil); err == nil {
+		t.Errorf("expected an error for empty data")
+	}
+	if err := new(Modulus).GobDecode([]byte{0xFF}); err == nil {
+		t.Errorf("expected an error for an unknown version")
+	}
+}
@@ -0,0 +1,163 @@
+package saferith
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+)
+
+// hashSecurityBits is the security parameter k used when hashing to a modulus.
+//
+// Following RFC 9380, we produce k more bits than the modulus has before reducing,
+// which makes the bias of the result negligible, at most 2^-k.
+const hashSecurityBits = 128
+
+// hashOversizeDSTPrefix is used to hash domain separation tags which are too long.
+const hashOversizeDSTPrefix = "H2C-OVERSIZE-DST-"
+
+// expandMessageXMD implements expand_message_xmd from RFC 9380, with SHA-256.
+//
+// This returns length uniformly random bytes, derived from msg and dst. The pieces
+// of msg are concatenated. Since the lengths involved are public, this panics if
+// length is larger than 255 blocks of output.
+func expandMessageXMD(dst []byte, msg [][]byte, length int) []byte {
+	const bIn = sha256.BlockSize
+	const bOut = sha256.Size
+	ell := (length + bOut - 1) / bOut
+	if ell > 255 || length > 0xFFFF {
+		panic("expandMessageXMD: length is too large")
+	}
+	if len(dst) > 255 {
+		h := sha256.New()
+		h.Write([]byte(hashOversizeDSTPrefix))
+		h.Write(dst)
+		dst = h.Sum(nil)
+	}
+	dstPrime := append(append([]byte{}, dst...), byte(len(dst)))
+
+	h := sha256.New()
+	h.Write(make([]byte, bIn))
+	for _, piece := range msg {
+		h.Write(piece)
+	}
+	h.Write([]byte{byte(length >> 8), byte(length), 0})
+	h.Write(dstPrime)
+	b0 := h.Sum(nil)
+
+	out := make([]byte, 0, ell*bOut)
+	bi := make([]byte, bOut)
+	for i := 1; i <= ell; i++ {
+		// b_1 = H(b_0 || 1 || DST'), and b_i = H((b_0 ^ b_(i - 1)) || i || DST')
+		for j := range bi {
+			bi[j] ^= b0[j]
+		}
+		h.Reset()
+		h.Write(bi)
+		h.Write([]byte{byte(i)})
+		h.Write(dstPrime)
+		bi = h.Sum(bi[:0])
+		out = append(out, bi...)
+	}
+	return out[:length]
+}
+
+// hashLen returns the number of bytes to hash to, for an element modulo m.
+func hashLen(m *Modulus) int {
+	return (m.nat.announced + hashSecurityBits + 7) / 8
+}
+
+// HashToMod sets z to the hash of data, reduced modulo m, returning z.
+//
+// This is hash_to_field from RFC 9380, with a count of 1, using expand_message_xmd
+// with SHA-256: enough bytes are derived from data to make the bias of the result
+// negligible, and then reduced modulo m. dst is a domain separation tag, which
+// should be unique to each use of this function, e.g. "MYPROTOCOL-V1-CHALLENGE".
+//
+// The pieces of data are concatenated before hashing, which means that ("ab", "c"),
+// and ("a", "bc") hash to the same value. Pieces which don't have a fixed length
+// should thus be encoded with their length.
+//
+// This only leaks the lengths of dst and data. The capacity of the resulting
+// number matches the capacity of the modulus.
+func (z *Nat) HashToMod(m *Modulus, dst []byte, data ...[]byte) *Nat {
+	uniform := expandMessageXMD(dst, data, hashLen(m))
+	return z.Mod(new(Nat).SetBytes(uniform), m)
+}
+
+// HashToModN hashes data to count independent numbers modulo m.
+//
+// This is hash_to_field from RFC 9380, using expand_message_xmd with SHA-256,
+// as in HashToMod. With a count of 1, this returns the same result as HashToMod.
+// Hashing to a curve in RFC 9380 uses a count of 2, for example.
+func HashToModN(m *Modulus, count int, dst []byte, data ...[]byte) []*Nat {
+	l := hashLen(m)
+	uniform := expandMessageXMD(dst, data, count*l)
+	out := make([]*Nat, count)
+	for i := range out {
+		out[i] = new(Nat).Mod(new(Nat).SetBytes(uniform[i*l:(i+1)*l]), m)
+	}
+	return out
+}
+
+// SetHash sets z to the hash of data, as an element of its field, returning z.
+//
+// This uses HashToMod, with the modulus of the field. See HashToMod for the
+// meaning of dst, and how data is hashed.
+func (z *FieldElement) SetHash(dst []byte, data ...[]byte) *FieldElement {
+	return z.SetNat(new(Nat).HashToMod(z.field.p, dst, data...))
+}
+
+// DeriveNat deterministically derives a uniformly random number in [1, m) from secret and label.
+//
+// This uses HMAC-DRBG with SHA-256, exactly like the nonce generation of RFC 6979,
+// section 3.2, with secret || label in place of int2octets(x) || bits2octets(h1).
+// Passing those values thus produces the nonces of deterministic ECDSA. More generally,
+// this is useful for deterministic signatures, or reproducible test vectors.
+// Since secret and label are concatenated, secret should have a fixed length.
+//
+// Like Rand, this uses rejection sampling, and the number of attempts doesn't
+// leak anything about the value returned. As in RFC 6979, 0 is rejected as well,
+// so m must be larger than 1, and this will panic otherwise. The capacity of the
+// resulting number matches the capacity of the modulus.
+func DeriveNat(secret []byte, label []byte, m *Modulus) *Nat {
+	// LEAK: whether m is 1
+	// OK: moduli are allowed to leak their true length
+	if m.nat.TrueLen() <= 1 {
+		panic("DeriveNat: modulus must be larger than 1")
+	}
+	mac := func(key []byte, data ...[]byte) []byte {
+		h := hmac.New(sha256.New, key)
+		for _, d := range data {
+			h.Write(d)
+		}
+		return h.Sum(nil)
+	}
+	v := make([]byte, sha256.Size)
+	for i := range v {
+		v[i] = 0x01
+	}
+	k := make([]byte, sha256.Size)
+	k = mac(k, v, []byte{0x00}, secret, label)
+	v = mac(k, v)
+	k = mac(k, v, []byte{0x01}, secret, label)
+	v = mac(k, v)
+
+	bits := m.nat.announced
+	t := make([]byte, 0, (bits+8*sha256.Size-1)/(8*sha256.Size)*sha256.Size)
+	z := new(Nat)
+	for {
+		t = t[:0]
+		for 8*len(t) < bits {
+			v = mac(k, v)
+			t = append(t, v...)
+		}
+		// Keep the leftmost bits of t, like bits2int
+		z.SetBytes(t)
+		z.Rsh(z, uint(8*len(t)-bits), bits)
+		if _, _, lt := z.CmpMod(m); lt&(1^z.EqZero()) == 1 {
+			z.reduced = m
+			return z
+		}
+		k = mac(k, v, []byte{0x00})
+		v = mac(k, v)
+	}
+}
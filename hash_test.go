@@ -0,0 +1,146 @@
+package saferith
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"testing/quick"
+)
+
+// Test vectors from RFC 9380, Appendix K.1
+func TestExpandMessageXMDExamples(t *testing.T) {
+	dst := []byte("QUUX-V01-CS02-with-expander-SHA256-128")
+	examples := []struct {
+		msg      string
+		length   int
+		expected string
+	}{
+		{"", 0x20, "68a985b87eb6b46952128911f2a4412bbc302a9d759667f87f7a21d803f07235"},
+		{"abc", 0x20, "d8ccab23b5985ccea865c6c97b6e5b8350e794e603b4b97902f53a8a0d605615"},
+		{"abcdef0123456789", 0x20, "eff31487c770a893cfb36f912fbfcbff40d5661771ca4b2cb4eafe524333f5c1"},
+		{"", 0x80, "af84c27ccfd45d41914fdff5df25293e221afc53d8ad2ac06d5e3e29485dadbee0d121587713a3e0dd4d5e69e93eb7cd4f5df4cd103e188cf60cb02edc3edf18eda8576c412b18ffb658e3dd6ec849469b979d444cf7b26911a08e63cf31f9dcc541708d3491184472c2c29bb749d4286b004ceb5ee6b9a7fa5b646c993f0ced"},
+	}
+	for _, e := range examples {
+		expected, _ := hex.DecodeString(e.expected)
+		actual := expandMessageXMD(dst, [][]byte{[]byte(e.msg)}, e.length)
+		if !bytes.Equal(expected, actual) {
+			t.Errorf("%x != %x", expected, actual)
+		}
+	}
+}
+
+func TestExpandMessageXMDLongDST(t *testing.T) {
+	long := bytes.Repeat([]byte{'a'}, 256)
+	hashed := sha256.Sum256(append([]byte(hashOversizeDSTPrefix), long...))
+	expected := expandMessageXMD(hashed[:], [][]byte{[]byte("abc")}, 0x20)
+	actual := expandMessageXMD(long, [][]byte{[]byte("abc")}, 0x20)
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("%x != %x", expected, actual)
+	}
+}
+
+// Test vector from RFC 9380, Appendix J.1.1, for P256_XMD:SHA-256_SSWU_RO_
+func TestHashToModNExamples(t *testing.T) {
+	p, _ := ModulusFromHex("FFFFFFFF00000001000000000000000000000000FFFFFFFFFFFFFFFFFFFFFFFF")
+	dst := []byte("QUUX-V01-CS02-with-P256_XMD:SHA-256_SSWU_RO_")
+	expected := []string{
+		"AD5342C66A6DD0FF080DF1DA0EA1C04B96E0330DD89406465EEBA11582515009",
+		"8C0F1D43204BD6F6EA70AE8013070A1518B43873BCD850AAFA0A9E220E2EEA5A",
+	}
+	actual := HashToModN(p, 2, dst, []byte(""))
+	for i := range expected {
+		if actual[i].Hex() != expected[i] {
+			t.Errorf("%s != %s", expected[i], actual[i].Hex())
+		}
+	}
+}
+
+func testHashToModMatchesN(data []byte, m Modulus) bool {
+	dst := []byte("saferith-test")
+	single := new(Nat).HashToMod(&m, dst, data)
+	if !single.checkInvariants() || single.AnnouncedLen() != m.BitLen() {
+		return false
+	}
+	// The data is concatenated, so splitting it doesn't change the result
+	split := new(Nat).HashToMod(&m, dst, data[:len(data)/2], data[len(data)/2:])
+	return single.Eq(HashToModN(&m, 1, dst, data)[0]) == 1 && single.Eq(split) == 1
+}
+
+func TestHashToModMatchesN(t *testing.T) {
+	err := quick.Check(testHashToModMatchesN, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHashToModDomainSeparation(t *testing.T) {
+	m := ModulusFromBytes(modulus2048())
+	a := new(Nat).HashToMod(m, []byte("A"), []byte("data"))
+	b := new(Nat).HashToMod(m, []byte("B"), []byte("data"))
+	if a.Eq(b) == 1 {
+		t.Errorf("different tags produced the same hash")
+	}
+}
+
+func TestFieldElementSetHash(t *testing.T) {
+	p := ModulusFromUint64(1000003)
+	f, _ := NewField(p)
+	dst := []byte("saferith-test")
+	expected := new(Nat).HashToMod(p, dst, []byte("abc"))
+	actual := f.Element().SetHash(dst, []byte("abc")).Nat()
+	if expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+}
+
+// Test vectors from RFC 6979, Appendix A.2.5, for ECDSA with P-256 and SHA-256
+func TestDeriveNatExamples(t *testing.T) {
+	q, _ := ModulusFromHex("FFFFFFFF00000000FFFFFFFFFFFFFFFFBCE6FAADA7179E84F3B9CAC2FC632551")
+	x, _ := hex.DecodeString("C9AFA9D845BA75166B5C215767B1D6934E50C3DB36E89B127B8A622B120F6721")
+	examples := []struct {
+		message  string
+		expected string
+	}{
+		{"sample", "A6E3C57DD01ABE90086538398355DD4C3B17AA873382B0F24D6129493D8AAD60"},
+		{"test", "D16B6AE827F17175E040871A1C7EC3500192C4C92677336EC2537ACAEE0008E0"},
+	}
+	for _, e := range examples {
+		// The hashes are smaller than q, so bits2octets(h1) is just h1
+		h1 := sha256.Sum256([]byte(e.message))
+		actual := DeriveNat(x, h1[:], q)
+		if actual.Hex() != e.expected {
+			t.Errorf("%s != %s", e.expected, actual.Hex())
+		}
+	}
+}
+
+func testDeriveNatInRange(secret []byte, label []byte, m Modulus) bool {
+	x := DeriveNat(secret, label, &m)
+	if !x.checkInvariants() || x.AnnouncedLen() != m.BitLen() {
+		return false
+	}
+	if _, _, lt := x.CmpMod(&m); lt != 1 || x.EqZero() == 1 {
+		return false
+	}
+	// Deriving again gives the same result
+	return DeriveNat(secret, label, &m).Eq(x) == 1
+}
+
+func TestDeriveNatInRange(t *testing.T) {
+	err := quick.Check(testDeriveNatInRange, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDeriveNatRejectsZero(t *testing.T) {
+	// With m = 2, half of the candidates are 0, so these have to be rejected
+	m := ModulusFromUint64(2)
+	for i := 0; i < 64; i++ {
+		x := DeriveNat([]byte("secret"), []byte{byte(i)}, m)
+		if x.EqUint64(1) != 1 {
+			t.Errorf("%+v != 1", x)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package saferith
 
 import (
 	"errors"
+	"fmt"
 	"math/big"
 	"math/bits"
 )
@@ -17,7 +18,8 @@ type Int struct {
 	// When 1, this is a negative number, when 0 a positive number.
 	//
 	// There's a bit of redundancy to note, because -0 and +0 represent the same
-	// number. We need to be careful around this edge case.
+	// number. To avoid having to be careful around this edge case, every operation
+	// calls normalizeSign before returning, so that -0 never appears.
 	sign Choice
 	// The absolute value.
 	//
@@ -34,26 +36,174 @@ func (z *Int) SetBytes(data []byte) *Int {
 	return z
 }
 
+// SetBytesLE interprets a number in little-endian form, stores it in z, and returns z.
+//
+// This number will be positive.
+func (z *Int) SetBytesLE(data []byte) *Int {
+	z.sign = 0
+	z.abs.SetBytesLE(data)
+	return z
+}
+
+// SetBytesSigned interprets a number in big-endian two's complement form, stores it in z, and returns z.
+//
+// Unlike SetBytes, this can produce negative numbers. The most significant bit
+// of data determines the sign.
+//
+// The absolute value will have a capacity of 8 * len(data) bits, which is enough
+// to hold any value in the encoding, including the most negative one.
+//
+// This is the inverse of Bytes.
+func (z *Int) SetBytesSigned(data []byte) *Int {
+	z.abs.SetBytes(data)
+	if len(data) <= 0 {
+		z.sign = 0
+		return z
+	}
+	return z.setTwosSign(Choice(data[0] >> 7))
+}
+
+// setTwosSign interprets the absolute value of z as a two's complement number
+// with the given sign bit, converting it back to a sign and absolute value.
+func (z *Int) setTwosSign(sign Choice) *Int {
+	z.sign = sign
+	// The sign bit might not be at the top of the last limb, so we extend it before negating.
+	if len(z.abs.limbs) > 0 {
+		z.abs.limbs[len(z.abs.limbs)-1] |= ^limbMask(z.abs.announced) & -Word(z.sign)
+	}
+	negateTwos(z.sign, z.abs.limbs)
+	maskEnd(z.abs.limbs, z.abs.announced)
+	return z.normalizeSign()
+}
+
+// SetBytesSignedLE interprets a number in little-endian two's complement form, stores it in z, and returns z.
+//
+// This is like SetBytesSigned, except that the least significant byte comes first.
+//
+// This is the inverse of BytesLE.
+func (z *Int) SetBytesSignedLE(data []byte) *Int {
+	z.abs.SetBytesLE(data)
+	if len(data) <= 0 {
+		z.sign = 0
+		return z
+	}
+	return z.setTwosSign(Choice(data[len(data)-1] >> 7))
+}
+
+// FillBytes writes out the big-endian two's complement representation of z into buf.
+//
+// The entire buffer is written to, with the sign extended to fill out the most
+// significant bytes. If buf is too short, the most significant bytes will be truncated,
+// which means that the result may no longer have the same sign as z.
+//
+// This doesn't allocate, and only leaks the length of buf, and the announced length of z.
+func (z *Int) FillBytes(buf []byte) []byte {
+	sign := Word(z.sign)
+	// We negate limb by limb, as in negateTwos, while writing out the bytes
+	carry := sign
+	i := len(buf)
+	// LEAK: the number of limbs, and the length of buf
+	// OK: these are public
+	for limbI := 0; i > 0; limbI++ {
+		var x Word
+		if limbI < len(z.abs.limbs) {
+			x = z.abs.limbs[limbI]
+		}
+		x, carry = add(x^-sign, carry, 0)
+		for j := 0; j < _S && i > 0; j++ {
+			i--
+			buf[i] = byte(x)
+			x >>= 8
+		}
+	}
+	return buf
+}
+
+// FillBytesLE writes out the little-endian two's complement representation of z into buf.
+//
+// This is like FillBytes, except that the least significant byte comes first.
+func (z *Int) FillBytesLE(buf []byte) []byte {
+	sign := Word(z.sign)
+	carry := sign
+	i := 0
+	// LEAK: the number of limbs, and the length of buf
+	// OK: these are public
+	for limbI := 0; i < len(buf); limbI++ {
+		var x Word
+		if limbI < len(z.abs.limbs) {
+			x = z.abs.limbs[limbI]
+		}
+		x, carry = add(x^-sign, carry, 0)
+		for j := 0; j < _S && i < len(buf); j++ {
+			buf[i] = byte(x)
+			x >>= 8
+			i++
+		}
+	}
+	return buf
+}
+
+// twosByteLen returns the number of bytes used by Bytes and BytesLE.
+//
+// This leaves room for the absolute value, along with an extra sign bit.
+func (z *Int) twosByteLen() int {
+	return (z.abs.announced + 8) / 8
+}
+
+// Bytes returns the big-endian two's complement representation of z.
+//
+// The output has enough bytes to hold the absolute value of z, along with
+// an extra sign bit, i.e. (z.AnnouncedLen() + 8) / 8 bytes. Its length thus
+// depends only on the announced size of z.
+//
+// This can be parsed back with SetBytesSigned.
+func (z *Int) Bytes() []byte {
+	return z.FillBytes(make([]byte, z.twosByteLen()))
+}
+
+// BytesLE returns the little-endian two's complement representation of z.
+//
+// This is like Bytes, except that the least significant byte comes first.
+//
+// This can be parsed back with SetBytesSignedLE.
+func (z *Int) BytesLE() []byte {
+	return z.FillBytesLE(make([]byte, z.twosByteLen()))
+}
+
 // MarshalBinary implements encoding.BinaryMarshaler.
-// The retrned byte slice is always of length 1 + len(i.Abs().Bytes()),
-// where the first byte encodes the sign.
+//
+// The encoding consists of a version byte, followed by a byte holding the sign,
+// followed by the encoding of the absolute value, as in Nat.MarshalBinary, minus
+// the version byte.
 func (i *Int) MarshalBinary() ([]byte, error) {
-	length := 1 + (i.abs.announced+7)/8
-	out := make([]byte, length)
-	out[0] = byte(i.sign)
-	i.abs.FillBytes(out[1:])
-	return out, nil
+	out := make([]byte, 0, 6+(i.abs.announced+7)/8)
+	out = append(out, binaryVersion, byte(i.sign))
+	return i.abs.appendBinary(out), nil
 }
 
 // UnmarshalBinary implements encoding.BinaryUnmarshaler.
-// Returns an error when the length of data is 0,
-// since we always expect the first byte to encode the sign.
+//
+// This expects the format produced by MarshalBinary, returning an error if the
+// data is malformed, or has an unknown version.
 func (i *Int) UnmarshalBinary(data []byte) error {
-	if len(data) == 0 {
+	if len(data) < 1 || data[0] != binaryVersion {
+		return errors.New("unknown binary encoding version")
+	}
+	if len(data) < 2 {
 		return errors.New("data must contain a sign byte")
 	}
-	i.abs.SetBytes(data[1:])
-	i.sign = Choice(data[0] & 1)
+	if data[1] > 1 {
+		return errors.New("invalid sign byte")
+	}
+	rest, err := i.abs.parseBinary(data[2:])
+	if err != nil {
+		return err
+	}
+	if len(rest) != 0 {
+		return errors.New("trailing binary data")
+	}
+	i.sign = Choice(data[1])
+	i.normalizeSign()
 	return nil
 }
 
@@ -66,6 +216,50 @@ func (z *Int) SetUint64(x uint64) *Int {
 	return z
 }
 
+// SetInt64 sets the value of z to x, including its sign.
+//
+// The absolute value will have the capacity of a 64 bit number. This doesn't
+// leak the sign of x.
+func (z *Int) SetInt64(x int64) *Int {
+	sign := uint64(x) >> 63
+	z.sign = Choice(sign)
+	// Conditionally negate x in two's complement
+	z.abs.SetUint64((uint64(x) ^ -sign) + sign)
+	return z
+}
+
+// low64 returns the least significant 64 bits of the absolute value of z.
+func (z *Int) low64() uint64 {
+	var ret uint64
+	for i := 0; i < len(z.abs.limbs) && i*_W < 64; i++ {
+		ret |= uint64(z.abs.limbs[i]) << (i * _W)
+	}
+	return ret
+}
+
+// Int64 returns the value of z as an int64.
+//
+// If z doesn't fit inside of an int64, the result is truncated: only the least
+// significant 64 bits of the absolute value are kept, and then negated in two's
+// complement if z is negative. This matches the usual wrapping semantics of
+// integer conversion in Go.
+//
+// This doesn't leak any information about the value of z.
+func (z *Int) Int64() int64 {
+	sign := uint64(z.sign)
+	return int64((z.low64() ^ -sign) + sign)
+}
+
+// Uint64 returns the value of z as a uint64.
+//
+// This returns the same bits as uint64(z.Int64()), so negative numbers will
+// wrap around, as will numbers with an absolute value of more than 64 bits.
+//
+// This doesn't leak any information about the value of z.
+func (z *Int) Uint64() uint64 {
+	return uint64(z.Int64())
+}
+
 // SetNat will set the absolute value of z to x, and the sign to zero, returning z.
 func (z *Int) SetNat(x *Nat) *Int {
 	z.sign = 0
@@ -83,13 +277,23 @@ func (z *Int) Clone() *Int {
 	return out
 }
 
+// Clear overwrites z with zeros, returning z.
+//
+// Like Nat.Clear, this clears all of the limbs backing the absolute value.
+func (z *Int) Clear() *Int {
+	z.sign = 0
+	z.abs.Clear()
+	return z
+}
+
 // SetBig will set the value of this number to the value of a big.Int, including sign.
 //
 // The size dicates the number of bits to use for the absolute value. This is important,
 // in order to include additional padding that the big.Int might have stripped off.
 //
 // Since big.Int stores its sign as a boolean, it's likely that this conversion
-// will leak the value of the sign.
+// will leak the value of the sign. Like Nat.SetBig, the true size of the absolute
+// value will also be leaked.
 func (z *Int) SetBig(x *big.Int, size int) *Int {
 	// x.Sign() = {-1, 0, 1},
 	// 1 - x.Sign() = {2, 1, 0},
@@ -114,7 +318,7 @@ func (z *Int) Big() *big.Int {
 // Resize adjust the announced size of this number, possibly truncating the absolute value.
 func (z *Int) Resize(cap int) *Int {
 	z.abs.Resize(cap)
-	return z
+	return z.normalizeSign()
 }
 
 // String formats this number as a signed hex string.
@@ -126,6 +330,111 @@ func (z *Int) String() string {
 	return string(rune(sign)) + z.abs.String()
 }
 
+// Format implements fmt.Formatter.
+//
+// The verbs %v and %s produce the same output as String. The verbs %b, %o, %O,
+// %d, %x, and %X format the value of this number, including its sign, supporting
+// the same flags as big.Int, such as width, zero padding, and '+'.
+//
+// As with Nat.Format, using a numeric verb leaks the true size of this number,
+// as well as its sign, so this shouldn't be used with sensitive values.
+func (z *Int) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v', 's':
+		formatString(s, z.String())
+	default:
+		z.Big().Format(s, verb)
+	}
+}
+
+// MarshalJSON implements json.Marshaler.
+//
+// The value is encoded as a JSON string, holding either a '+' or '-' sign,
+// followed by the absolute value, in the same format as Nat.MarshalJSON.
+//
+// Because a sign is always present, the length of the string only depends
+// on the announced size of this number.
+func (z *Int) MarshalJSON() ([]byte, error) {
+	sign := ctIfElse(z.sign, Word('-'), Word('+'))
+	return []byte(`"` + string(rune(sign)) + z.abs.hexNibbles() + `"`), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// This accepts a JSON string containing an optional '+' or '-' sign, followed
+// by hex digits, as in Nat.UnmarshalJSON. The JSON value null leaves z unchanged.
+func (z *Int) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) < 2 || data[0] != '"' || data[len(data)-1] != '"' {
+		return errors.New("expected a JSON string")
+	}
+	_, err := z.SetHex(string(data[1 : len(data)-1]))
+	return err
+}
+
+// splitSign removes an optional leading '+' or '-' from a string, returning the sign.
+func splitSign(s string) (Choice, string) {
+	if len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+		return ctEq(Word(s[0]), Word('-')), s[1:]
+	}
+	return 0, s
+}
+
+// SetHex modifies the value of z to hold a signed hex string, returning z
+//
+// The string consists of an optional '+' or '-' sign, followed by hex digits,
+// following the same rules as Nat.SetHex. If the string is invalid, the value
+// of z will be undefined, and an error will be returned.
+func (z *Int) SetHex(hex string) (*Int, error) {
+	sign, hex := splitSign(hex)
+	if _, err := z.abs.SetHex(hex); err != nil {
+		return nil, err
+	}
+	z.sign = sign
+	return z.normalizeSign(), nil
+}
+
+// SetDecimal modifies the value of z to hold a signed decimal string, returning z
+//
+// The string consists of an optional '+' or '-' sign, followed by decimal digits,
+// following the same rules as Nat.SetDecimal. If the string is invalid, the value
+// of z will be undefined, and an error will be returned.
+func (z *Int) SetDecimal(dec string) (*Int, error) {
+	sign, dec := splitSign(dec)
+	if _, err := z.abs.SetDecimal(dec); err != nil {
+		return nil, err
+	}
+	z.sign = sign
+	return z.normalizeSign(), nil
+}
+
+// normalizeSign clears the sign of z if its absolute value is 0, returning z.
+//
+// This gets called at the end of every operation which might produce -0, so that
+// a sign of 1 always means a negative number.
+func (z *Int) normalizeSign() *Int {
+	z.sign &= 1 ^ z.abs.EqZero()
+	return z
+}
+
+// IsZero checks if this Int is 0.
+func (z *Int) IsZero() Choice {
+	return z.abs.EqZero()
+}
+
+// Sign returns (negative, zero, positive), according to the sign of z.
+//
+// Exactly one of these values will be 1. Unlike math/big's Sign, this doesn't
+// leak the sign of z, only the announced length of its absolute value, so there's
+// no need to look at String(), or compare Abs() with anything, to learn the sign.
+func (z *Int) Sign() (Choice, Choice, Choice) {
+	zero := z.abs.EqZero()
+	negative := z.sign & (1 ^ zero)
+	return negative, zero, 1 ^ negative ^ zero
+}
+
 // Eq checks if this Int has the same value as another Int.
 //
 // Note that negative zero and positive zero are the same number.
@@ -137,14 +446,55 @@ func (z *Int) Eq(x *Int) Choice {
 	return sameSign & z.abs.Eq(&x.abs)
 }
 
+// EqInt64 compares z with x, returning 1 if they're equal
+//
+// Like Nat.EqUint64, this won't allocate. Negative zero and positive zero are
+// treated as the same number.
+func (z *Int) EqInt64(x int64) Choice {
+	sign := uint64(x) >> 63
+	abs := (uint64(x) ^ -sign) + sign
+	sameSign := z.abs.EqZero() | (1 ^ z.sign ^ Choice(sign))
+	return sameSign & z.abs.EqUint64(abs)
+}
+
+// Cmp compares two Ints, returning results for (>, =, <) in that order.
+//
+// Because these relations are mutually exclusive, exactly one of these values
+// will be true.
+//
+// Negative zero and positive zero are treated as the same number.
+//
+// This function doesn't leak any information about the values involved, only
+// their announced lengths.
+func (z *Int) Cmp(x *Int) (Choice, Choice, Choice) {
+	absGt, absEq, absLt := z.abs.Cmp(&x.abs)
+	// Zero is never negative, regardless of what the sign says
+	zNeg := z.sign & (1 ^ z.abs.EqZero())
+	xNeg := x.sign & (1 ^ x.abs.EqZero())
+	sameSign := 1 ^ zNeg ^ xNeg
+	// With the same sign, negative numbers reverse the ordering of absolute values
+	gt := (sameSign & (((1 ^ zNeg) & absGt) | (zNeg & absLt))) | ((1 ^ zNeg) & xNeg)
+	lt := (sameSign & (((1 ^ zNeg) & absLt) | (zNeg & absGt))) | (zNeg & (1 ^ xNeg))
+	return gt, sameSign & absEq, lt
+}
+
 // Abs returns the absolute value of this Int.
 func (z *Int) Abs() *Nat {
 	return new(Nat).SetNat(&z.abs)
 }
 
 // IsNegative checks if this value is negative
+//
+// Zero is never negative. Like Sign, this doesn't leak the sign of z.
 func (z *Int) IsNegative() Choice {
-	return z.sign
+	return z.sign & (1 ^ z.abs.EqZero())
+}
+
+// IsPositive checks if this value is strictly positive
+//
+// Zero is never positive. Like Sign, this doesn't leak the sign of z.
+func (z *Int) IsPositive() Choice {
+	return (1 ^ z.sign) & (1 ^ z.abs.EqZero())
 }
 
 // AnnouncedLen returns the announced size of this int's absolute value.
@@ -163,11 +513,40 @@ func (z *Int) TrueLen() int {
 	return z.abs.TrueLen()
 }
 
+// Normalize shrinks the announced length of z's absolute value to its true length, returning z.
+//
+// See Nat.Normalize
+func (z *Int) Normalize() *Int {
+	z.abs.Normalize()
+	return z
+}
+
 // Neg calculates z <- -x.
 //
 // The result has the same announced size.
 func (z *Int) Neg(doit Choice) *Int {
 	z.sign ^= doit
+	return z.normalizeSign()
+}
+
+// CondSwap swaps the values of z and x if yes = 1, and does nothing otherwise.
+//
+// Like Nat.CondSwap, this doesn't leak whether the swap happened, and both
+// absolute values end up with the largest announced size between z and x.
+func (z *Int) CondSwap(yes Choice, x *Int) {
+	zSign := z.sign
+	z.sign = Choice(ctIfElse(yes, Word(x.sign), Word(zSign)))
+	x.sign = Choice(ctIfElse(yes, Word(zSign), Word(x.sign)))
+	z.abs.CondSwap(yes, &x.abs)
+}
+
+// Select sets z <- yes ? x : y, returning z.
+//
+// Like Nat.Select, this doesn't leak which value was selected, and z
+// is allowed to alias x or y.
+func (z *Int) Select(yes Choice, x *Int, y *Int) *Int {
+	z.sign = Choice(ctIfElse(yes, Word(x.sign), Word(y.sign)))
+	z.abs.Select(yes, &x.abs, &y.abs)
 	return z
 }
 
@@ -186,12 +565,51 @@ func (z *Int) Mul(x *Int, y *Int, cap int) *Int {
 	// (-1)^sx * ax * (-1)^sy * ay = (-1)^(sx + sy) * ax * ay
 	z.sign = x.sign ^ y.sign
 	z.abs.Mul(&x.abs, &y.abs, cap)
-	return z
+	return z.normalizeSign()
+}
+
+// Lsh calculates z <- x << shift, producing a certain number of bits
+//
+// This shifts the absolute value, keeping the sign, which is the same as multiplying
+// by 2^shift. This method will leak the value of shift.
+//
+// If cap < 0, the number of bits will be x.AnnouncedLen() + shift.
+func (z *Int) Lsh(x *Int, shift uint, cap int) *Int {
+	z.sign = x.sign
+	z.abs.Lsh(&x.abs, shift, cap)
+	return z.normalizeSign()
+}
+
+// Rsh calculates z <- x >> shift, producing a certain number of bits
+//
+// Like the >> operator on signed integers, this is an arithmetic shift, rounding
+// towards negative infinity. For example, -5 >> 1 = -3. This method will leak
+// the value of shift, but not whether any rounding happened.
+//
+// If cap < 0, the number of bits will be x.AnnouncedLen() - shift + 1, and at least 1,
+// since rounding negative numbers can increase the absolute value.
+func (z *Int) Rsh(x *Int, shift uint, cap int) *Int {
+	if cap < 0 {
+		cap = x.abs.announced - int(shift) + 1
+		if cap < 1 {
+			cap = 1
+		}
+	}
+	// Rounding down a negative number means adding 1 to its absolute value,
+	// whenever some of the bits we shift out are non-zero.
+	lost := 1 ^ new(Nat).TruncateBits(&x.abs, int(shift)).EqZero()
+	roundUp := new(Nat).SetUint64(uint64(x.sign & lost))
+	z.sign = x.sign
+	z.abs.Rsh(&x.abs, shift, cap)
+	z.abs.Add(&z.abs, roundUp, cap)
+	return z.normalizeSign()
 }
 
 // Mod calculates z mod M, handling negatives correctly.
 //
 // As indicated by the types, this function will return a number in the range 0..m-1.
+//
+// This is the remainder matching the quotient calculated by Div.
 func (z *Int) Mod(m *Modulus) *Nat {
 	out := new(Nat).Mod(&z.abs, m)
 	negated := new(Nat).ModNeg(out, m)
@@ -199,6 +617,129 @@ func (z *Int) Mod(m *Modulus) *Nat {
 	return out
 }
 
+// ModNeg calculates -z mod m, handling negatives correctly.
+//
+// Like Mod, this returns a number in the range 0..m-1, with the same capacity as
+// the modulus, and doesn't leak the sign of z.
+func (z *Int) ModNeg(m *Modulus) *Nat {
+	out := z.Mod(m)
+	return out.ModNeg(out, m)
+}
+
+// ModSub calculates z - y mod m, handling negatives correctly.
+//
+// This saves reducing both numbers with Mod before calling Nat.ModSub, which is
+// common when checking signature equations. Like Mod, this returns a number in
+// the range 0..m-1, with the same capacity as the modulus, and doesn't leak
+// the signs of z or y.
+func (z *Int) ModSub(y *Int, m *Modulus) *Nat {
+	out := z.Mod(m)
+	return out.ModSub(out, y.Mod(m), m)
+}
+
+// Div calculates z <- x / m, rounding towards negative infinity, with m a Modulus.
+//
+// Because m is always positive, this coincides with Euclidean division. In other words,
+// the quotient produced here pairs with the remainder produced by x.Mod(m), in that
+//    x = (x / m) * m + x.Mod(m)
+// always holds, with x.Mod(m) in the range 0..m-1.
+//
+// cap determines the number of bits to use for the absolute value of the result.
+// If cap < 0, then the number of bits will be x.AnnouncedLen() - m.BitLen() + 2
+//
+// This doesn't leak anything about the values involved, including the sign of x.
+func (z *Int) Div(x *Int, m *Modulus, cap int) *Int {
+	if cap < 0 {
+		cap = x.abs.announced - m.nat.announced + 2
+		// A negative x smaller than m still has a quotient of -1
+		if cap < 1 {
+			cap = 1
+		}
+	}
+	// We have |x| = q * m + r, so -|x| = -(q + 1) * m + (m - r), whenever r != 0.
+	remainder := new(Nat).Mod(&x.abs, m)
+	adjust := x.sign & (1 ^ remainder.EqZero())
+	sign := x.sign
+	z.abs.Div(&x.abs, m, cap)
+	z.abs.Add(&z.abs, new(Nat).SetUint64(uint64(adjust)), cap)
+	z.sign = sign
+	return z.normalizeSign()
+}
+
+// ExactDiv calculates z <- x / y, assuming that y divides x exactly.
+//
+// This is the case for Paillier's L function, for example. Instead of long division,
+// we multiply by the inverse of y modulo 2^cap, after removing the factors of 2
+// shared by x and y. This is much faster, but the result is unspecified if y doesn't
+// divide x, or if y is 0.
+//
+// cap determines the number of bits to use for the absolute value of the result.
+// If cap < 0, then the number of bits will be x.AnnouncedLen().
+//
+// This only leaks the announced lengths of x and y, along with cap.
+func (z *Int) ExactDiv(x *Int, y *Int, cap int) *Int {
+	if cap < 0 {
+		cap = x.abs.announced
+	}
+	sign := x.sign ^ y.sign
+	// Since y divides x, removing the trailing zeros of y leaves us with an odd divisor,
+	// which is invertible modulo 2^cap.
+	shift := uint(y.abs.TrailingZeros())
+	a := new(Nat).RshSecret(&x.abs, shift, -1)
+	d := new(Nat).RshSecret(&y.abs, shift, -1)
+	inv := new(Nat).SetUint64(uint64(invertModW(d.limbOrZero(0))))
+	two := new(Nat).SetUint64(2)
+	t := new(Nat)
+	// Each Newton iteration inv <- inv * (2 - d * inv) doubles the number of correct bits
+	// LEAK: cap
+	// OK: this is public
+	for precision := _W; precision < cap; precision *= 2 {
+		t.WrappingMul(d, inv, cap)
+		t.WrappingSub(two, t, cap)
+		inv.WrappingMul(inv, t, cap)
+	}
+	z.abs.WrappingMul(a, inv, cap)
+	z.sign = sign
+	wipeTemporaries(&z.abs, a, d, inv, t)
+	return z.normalizeSign()
+}
+
+// ExpMod calculates z^e mod m, handling a negative z correctly.
+//
+// Like Mod, the result will be a number in the range 0..m-1, with the
+// same capacity as the modulus.
+//
+// This doesn't leak the sign of z.
+func (z *Int) ExpMod(e *Nat, m *Modulus) *Nat {
+	out := z.Mod(m)
+	return out.Exp(out, e, m)
+}
+
+// ModInverse calculates z^-1 mod m, handling a negative z correctly.
+//
+// Like Mod, the result will be a number in the range 0..m-1, with the
+// same capacity as the modulus. As with Nat.ModInverse, z must be invertible
+// modulo m, otherwise the result is unspecified. Use ModInverseChecked to
+// detect this situation.
+//
+// This doesn't leak the sign of z.
+func (z *Int) ModInverse(m *Modulus) *Nat {
+	out, _ := z.ModInverseChecked(m)
+	return out
+}
+
+// ModInverseChecked calculates z^-1 mod m, returning 1 if z was invertible
+//
+// This is like ModInverse, but reports whether or not gcd(z, m) = 1, as part
+// of the same inversion, like Nat.ModInverseChecked. When this returns 0,
+// the resulting number is unspecified.
+//
+// This doesn't leak the sign of z, or whether it was invertible.
+func (z *Int) ModInverseChecked(m *Modulus) (*Nat, Choice) {
+	out := z.Mod(m)
+	return out.ModInverseChecked(out, m)
+}
+
 // SetModSymmetric takes a number x mod M, and returns a signed number centered around 0.
 //
 // This effectively takes numbers in the range:
@@ -215,7 +756,7 @@ func (z *Int) SetModSymmetric(x *Nat, m *Modulus) *Int {
 	z.abs.CondAssign(negatedLeq, negated)
 	// A negative modular number, by definition, will have it's negation <= itself
 	z.sign = negatedLeq
-	return z
+	return z.normalizeSign()
 }
 
 // CheckInRange checks whether or not this Int is in the range for SetModSymmetric.
@@ -256,8 +797,14 @@ func negateTwos(doit Choice, z []Word) {
 }
 
 // convert a slice to two's complement, using a sign, and writing the result to out
+//
+// out may be longer than abs, in which case the remaining limbs are treated as zero,
+// before negating.
 func toTwos(sign Choice, abs []Word, out []Word) {
 	copy(out, abs)
+	for i := len(abs); i < len(out); i++ {
+		out[i] = 0
+	}
 	negateTwos(sign, out)
 }
 
@@ -276,7 +823,83 @@ func fromTwos(bits int, mut []Word) Choice {
 // The cap determines the number of bits to use for the absolute value of the result.
 //
 // If cap < 0, cap gets set to max(x.AnnouncedLen(), y.AnnouncedLen()) + 1
+//
+// By working in two's complement, we avoid having to compare |x| and |y| when
+// the signs differ, so this doesn't leak anything beyond the announced sizes.
 func (z *Int) Add(x *Int, y *Int, cap int) *Int {
+	return z.addSigned(x, y, y.sign, cap)
+}
+
+// Sub calculates z <- x - y.
+//
+// The cap determines the number of bits to use for the absolute value of the result.
+//
+// If cap < 0, cap gets set to max(x.AnnouncedLen(), y.AnnouncedLen()) + 1
+//
+// Like Add, this doesn't leak anything beyond the announced sizes. In particular,
+// subtracting a number from itself produces zero, with no special handling of -0.
+func (z *Int) Sub(x *Int, y *Int, cap int) *Int {
+	return z.addSigned(x, y, 1^y.sign, cap)
+}
+
+// addSignedFull calculates z <- x + (-1)^ySign * |y|, returning 1 if |z| doesn't fit in cap bits.
+//
+// The sum is first calculated exactly, with z keeping the correct sign, and its absolute
+// value then being truncated to cap bits.
+func (z *Int) addSignedFull(x *Int, y *Int, ySign Choice, cap int) Choice {
+	full := x.abs.maxAnnounced(&y.abs) + 1
+	if cap < 0 {
+		cap = full
+	}
+	if full < cap {
+		full = cap
+	}
+	z.addSigned(x, y, ySign, full)
+	return z.abs.truncateChecked(cap)
+}
+
+// WrappingAdd calculates z <- x + y, reducing the absolute value modulo 2^cap.
+//
+// Unlike Add, which can produce the wrong sign when the result doesn't fit, this
+// calculates the sum exactly before truncating it, so the sign is always correct.
+//
+// If cap < 0, cap gets set to max(x.AnnouncedLen(), y.AnnouncedLen()) + 1
+func (z *Int) WrappingAdd(x *Int, y *Int, cap int) *Int {
+	z.addSignedFull(x, y, y.sign, cap)
+	return z.normalizeSign()
+}
+
+// WrappingSub calculates z <- x - y, reducing the absolute value modulo 2^cap.
+//
+// See WrappingAdd.
+func (z *Int) WrappingSub(x *Int, y *Int, cap int) *Int {
+	z.addSignedFull(x, y, 1^y.sign, cap)
+	return z.normalizeSign()
+}
+
+// SaturatingAdd calculates z <- x + y, clamping the absolute value to 2^cap - 1.
+//
+// The sign of the result is always correct. This doesn't leak whether or not
+// the result was clamped.
+//
+// If cap < 0, cap gets set to max(x.AnnouncedLen(), y.AnnouncedLen()) + 1
+func (z *Int) SaturatingAdd(x *Int, y *Int, cap int) *Int {
+	z.abs.saturate(z.addSignedFull(x, y, y.sign, cap))
+	return z.normalizeSign()
+}
+
+// SaturatingSub calculates z <- x - y, clamping the absolute value to 2^cap - 1.
+//
+// See SaturatingAdd.
+func (z *Int) SaturatingSub(x *Int, y *Int, cap int) *Int {
+	z.abs.saturate(z.addSignedFull(x, y, 1^y.sign, cap))
+	return z.normalizeSign()
+}
+
+// addSigned calculates z <- x + (-1)^ySign * |y|.
+//
+// This is the common implementation behind Add and Sub.
+func (z *Int) addSigned(x *Int, y *Int, ySign Choice, cap int) *Int {
 	// Rough idea, convert x and y to two's complement representation, add, and
 	// then convert back, before truncating as necessary.
 	if cap < 0 {
@@ -293,7 +916,7 @@ func (z *Int) Add(x *Int, y *Int, cap int) *Int {
 	xTwos := scratch[:size]
 	yTwos := scratch[size:]
 	toTwos(x.sign, xLimbs, xTwos)
-	toTwos(y.sign, yLimbs, yTwos)
+	toTwos(ySign, yLimbs, yTwos)
 	// The addition will now produce the right result
 	addVV(xTwos, xTwos, yTwos)
 	// Convert back from two's complement
@@ -305,5 +928,5 @@ func (z *Int) Add(x *Int, y *Int, cap int) *Int {
 	z.abs.reduced = nil
 	z.abs.announced = cap
 
-	return z
+	return z.normalizeSign()
 }
@@ -2,6 +2,10 @@ package saferith
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
 	"math/rand"
 	"reflect"
 	"testing"
@@ -42,6 +46,42 @@ func TestIntMulCommutative(t *testing.T) {
 	}
 }
 
+func testIntCondSwap(x, y *Int) bool {
+	for _, yes := range []Choice{0, 1} {
+		a := x.Clone()
+		b := y.Clone()
+		a.CondSwap(yes, b)
+		expectedA, expectedB := x, y
+		if yes == 1 {
+			expectedA, expectedB = y, x
+		}
+		if a.Eq(expectedA) != 1 || b.Eq(expectedB) != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestIntCondSwap(t *testing.T) {
+	err := quick.Check(testIntCondSwap, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testIntSelect(x, y *Int) bool {
+	shouldBeX := new(Int).Select(1, x, y)
+	shouldBeY := new(Int).Select(0, x, y)
+	return shouldBeX.Eq(x) == 1 && shouldBeY.Eq(y) == 1
+}
+
+func TestIntSelect(t *testing.T) {
+	err := quick.Check(testIntSelect, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
 func testIntMulZeroIsZero(x *Int) bool {
 	zero := new(Int)
 	timesZero := new(Int).Mul(zero, x, -1)
@@ -116,7 +156,7 @@ func TestIntAddNegZero(t *testing.T) {
 
 func testIntAddCommutative(x *Int, y *Int) bool {
 	way1 := new(Int).Add(x, y, -1)
-	way2 := new(Int).Add(x, y, -1)
+	way2 := new(Int).Add(y, x, -1)
 	return way1.Eq(way2) == 1
 }
 
@@ -158,6 +198,645 @@ func TestIntAddExamples(t *testing.T) {
 	}
 }
 
+func TestIntAddReusedReceiver(t *testing.T) {
+	// z starts out with garbage limbs larger than the operands
+	z := new(Int).SetBytes([]byte{
+		0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF,
+		0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF,
+	})
+	x := new(Int).SetUint64(3)
+	y := new(Int).SetUint64(4).Neg(1)
+	expected := new(Int).SetUint64(1).Neg(1)
+	actual := z.Add(x, y, 100)
+	if expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	x.SetUint64(0xFF).Resize(8)
+	y.SetUint64(1).Resize(8)
+	expected = new(Int).SetUint64(0)
+	actual = z.Add(x, y, 8)
+	if expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+}
+
+func testIntSubSelfIsZero(x *Int) bool {
+	zero := new(Int)
+	shouldBeZero := new(Int).Sub(x, x, -1)
+	return shouldBeZero.Eq(zero) == 1
+}
+
+func TestIntSubSelfIsZero(t *testing.T) {
+	err := quick.Check(testIntSubSelfIsZero, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testIntSubIsAddNeg(x *Int, y *Int) bool {
+	way1 := new(Int).Sub(x, y, -1)
+	way2 := new(Int).Add(x, new(Int).SetInt(y).Neg(1), -1)
+	return way1.Eq(way2) == 1
+}
+
+func TestIntSubIsAddNeg(t *testing.T) {
+	err := quick.Check(testIntSubIsAddNeg, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIntSubExamples(t *testing.T) {
+	x := new(Int).SetUint64(3).Resize(8)
+	y := new(Int).SetUint64(4).Resize(8)
+	expected := new(Int).SetUint64(1).Neg(1)
+	actual := new(Int).Sub(x, y, -1)
+	if expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	y.Neg(1)
+	expected = new(Int).SetUint64(7)
+	actual = x.Sub(x, y, -1)
+	if expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+}
+
+func testIntDivModRoundTrip(x *Int, m Modulus) bool {
+	q := new(Int).Div(x, &m, -1)
+	r := new(Int).SetNat(x.Mod(&m))
+	mInt := new(Int).SetNat(m.Nat())
+	cap := x.AnnouncedLen() + m.BitLen() + 2
+	recovered := new(Int).Mul(q, mInt, cap)
+	recovered.Add(recovered, r, cap)
+	return recovered.Eq(x) == 1
+}
+
+func TestIntDivModRoundTrip(t *testing.T) {
+	err := quick.Check(testIntDivModRoundTrip, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIntDivExamples(t *testing.T) {
+	m := ModulusFromUint64(5)
+	x := new(Int).SetUint64(7).Neg(1)
+	expected := new(Int).SetUint64(2).Neg(1)
+	actual := new(Int).Div(x, m, -1)
+	if expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	x.SetUint64(10).Neg(1)
+	expected.SetUint64(2).Neg(1)
+	actual = x.Div(x, m, -1)
+	if expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	x.SetUint64(7)
+	expected.SetUint64(1)
+	actual = x.Div(x, m, -1)
+	if expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+}
+
+func testIntExactDivRoundTrip(x *Int, y *Int) bool {
+	if y.Abs().EqZero() == 1 {
+		return true
+	}
+	product := new(Int).Mul(x, y, -1)
+	actual := new(Int).ExactDiv(product, y, -1)
+	if actual.AnnouncedLen() != product.AnnouncedLen() {
+		return false
+	}
+	return actual.Big().Cmp(x.Big()) == 0
+}
+
+func TestIntExactDivRoundTrip(t *testing.T) {
+	err := quick.Check(testIntExactDivRoundTrip, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIntExactDivExamples(t *testing.T) {
+	cases := []struct {
+		x, y, q int64
+	}{
+		{0, 3, 0},
+		{12, 4, 3},
+		{-12, 4, -3},
+		{12, -4, -3},
+		{-12, -4, 3},
+		{1 << 40, 1 << 20, 1 << 20},
+		{-3 * 7 * 64, 7 * 16, -12},
+	}
+	for _, c := range cases {
+		x := new(Int).SetInt64(c.x)
+		actual := x.ExactDiv(x, new(Int).SetInt64(c.y), -1)
+		if actual.Big().Cmp(big.NewInt(c.q)) != 0 {
+			t.Errorf("%d / %d: %+v != %+v", c.x, c.y, c.q, actual)
+		}
+	}
+	// The L function from Paillier, with (1 + n)^k = 1 + k * n mod n^2
+	n := new(Int).SetUint64(1009 * 1013)
+	x := new(Int).SetUint64(424242*1009*1013 + 1)
+	actual := new(Int).ExactDiv(new(Int).Sub(x, new(Int).SetUint64(1), -1), n, 32)
+	if actual.Big().Cmp(big.NewInt(424242)) != 0 {
+		t.Errorf("%+v != %+v", 424242, actual)
+	}
+}
+
+func testIntModInverseMatchesBig(x *Int, m Modulus) bool {
+	expected := new(big.Int).ModInverse(x.Big(), m.Big())
+	if expected == nil || m.BitLen() <= 1 {
+		return true
+	}
+	actual := x.ModInverse(&m)
+	if !actual.checkInvariants() {
+		return false
+	}
+	return actual.Big().Cmp(expected) == 0
+}
+
+func TestIntModInverseMatchesBig(t *testing.T) {
+	err := quick.Check(testIntModInverseMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIntModInverseExamples(t *testing.T) {
+	m := ModulusFromUint64(13)
+	// -2 * 6 = -12 = 1 mod 13
+	actual := new(Int).SetInt64(-2).ModInverse(m)
+	expected := new(Nat).SetUint64(6)
+	if expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	// This also works for even moduli: -3 * 5 = -15 = 1 mod 16
+	actual = new(Int).SetInt64(-3).ModInverse(ModulusFromUint64(16))
+	expected.SetUint64(5)
+	if expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+}
+
+func testIntModSubAndNegMatchBig(x *Int, y *Int, m Modulus) bool {
+	diff := new(big.Int).Sub(x.Big(), y.Big())
+	expected := diff.Mod(diff, m.Big())
+	actual := x.ModSub(y, &m)
+	if !actual.checkInvariants() || actual.AnnouncedLen() != m.BitLen() || actual.Big().Cmp(expected) != 0 {
+		return false
+	}
+	neg := new(big.Int).Neg(x.Big())
+	expected = neg.Mod(neg, m.Big())
+	actual = x.ModNeg(&m)
+	return actual.checkInvariants() && actual.Big().Cmp(expected) == 0
+}
+
+func TestIntModSubAndNegMatchBig(t *testing.T) {
+	err := quick.Check(testIntModSubAndNegMatchBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIntModSubExamples(t *testing.T) {
+	m := ModulusFromUint64(13)
+	// -5 - 10 = -15 = 11 mod 13
+	actual := new(Int).SetInt64(-5).ModSub(new(Int).SetInt64(10), m)
+	expected := new(Nat).SetUint64(11)
+	if expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	// -(-5) = 5 mod 13
+	actual = new(Int).SetInt64(-5).ModNeg(m)
+	expected.SetUint64(5)
+	if expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+}
+
+func testIntModInverseCheckedMatchesBig(x *Int, m Modulus) bool {
+	if m.BitLen() <= 1 {
+		return true
+	}
+	expected := new(big.Int).ModInverse(x.Big(), m.Big())
+	actual, ok := x.ModInverseChecked(&m)
+	if ok != ChoiceFromBool(expected != nil) || ok != x.Mod(&m).IsUnit(&m) {
+		return false
+	}
+	return expected == nil || actual.Big().Cmp(expected) == 0
+}
+
+func TestIntModInverseCheckedMatchesBig(t *testing.T) {
+	err := quick.Check(testIntModInverseCheckedMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIntModInverseCheckedExamples(t *testing.T) {
+	// -4 shares a factor with 16, but not with 13
+	x := new(Int).SetInt64(-4)
+	if _, ok := x.ModInverseChecked(ModulusFromUint64(16)); ok != 0 {
+		t.Errorf("%+v shouldn't be invertible mod 16", x)
+	}
+	// -4 * 3 = -12 = 1 mod 13
+	actual, ok := x.ModInverseChecked(ModulusFromUint64(13))
+	expected := new(Nat).SetUint64(3)
+	if ok != 1 || expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+}
+
+func testIntSaturatingAndWrappingMatchBig(x *Int, y *Int, cap uint8) bool {
+	bound := new(big.Int).Lsh(big.NewInt(1), uint(cap))
+	max := new(big.Int).Sub(bound, big.NewInt(1))
+	// Both operations keep the sign, and only differ in how they treat the absolute value
+	expect := func(v *big.Int, saturate bool) *big.Int {
+		abs := new(big.Int).Abs(v)
+		if saturate && abs.Cmp(max) > 0 {
+			abs.Set(max)
+		} else {
+			abs.Mod(abs, bound)
+		}
+		if v.Sign() < 0 {
+			abs.Neg(abs)
+		}
+		return abs
+	}
+	check := func(actual *Int, expected *big.Int) bool {
+		return actual.AnnouncedLen() == int(cap) && actual.Big().Cmp(expected) == 0
+	}
+	sum := new(big.Int).Add(x.Big(), y.Big())
+	diff := new(big.Int).Sub(x.Big(), y.Big())
+	return check(new(Int).SaturatingAdd(x, y, int(cap)), expect(sum, true)) &&
+		check(new(Int).SaturatingSub(x, y, int(cap)), expect(diff, true)) &&
+		check(new(Int).WrappingAdd(x, y, int(cap)), expect(sum, false)) &&
+		check(new(Int).WrappingSub(x, y, int(cap)), expect(diff, false))
+}
+
+func TestIntSaturatingAndWrappingMatchBig(t *testing.T) {
+	err := quick.Check(testIntSaturatingAndWrappingMatchBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIntSaturatingExamples(t *testing.T) {
+	x := new(Int).SetInt64(-200)
+	y := new(Int).SetInt64(100)
+	expected := new(Int).SetInt64(-255)
+	actual := new(Int).SaturatingSub(x, y, 8)
+	if expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	expected.SetInt64(-100)
+	actual = new(Int).SaturatingAdd(x, y, 8)
+	if expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	// 300 mod 256 = 44, keeping the sign
+	expected.SetInt64(-44)
+	actual = x.WrappingSub(x, y, 8)
+	if expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+}
+
+func testIntEqInt64MatchesEq(z *Int, x int64) bool {
+	if z.EqInt64(x) != z.Eq(new(Int).SetInt64(x)) {
+		return false
+	}
+	return new(Int).SetInt64(x).EqInt64(x) == 1
+}
+
+func TestIntEqInt64MatchesEq(t *testing.T) {
+	err := quick.Check(testIntEqInt64MatchesEq, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIntEqInt64Examples(t *testing.T) {
+	negZero := negativeZero(64)
+	if negZero.EqInt64(0) != 1 {
+		t.Errorf("-0 != 0")
+	}
+	if new(Int).SetInt64(-1).EqInt64(1) != 0 {
+		t.Errorf("-1 == 1")
+	}
+	if new(Int).SetInt64(math.MinInt64).EqInt64(math.MinInt64) != 1 {
+		t.Errorf("MinInt64 != MinInt64")
+	}
+	if new(Int).SetUint64(1<<63).EqInt64(math.MinInt64) != 0 {
+		t.Errorf("2^63 == MinInt64")
+	}
+}
+
+func testIntExpModNegation(x *Int, e Nat, m Modulus) bool {
+	neg := new(Int).SetInt(x).Neg(1)
+	way1 := neg.ExpMod(&e, &m)
+	way2 := x.ExpMod(&e, &m)
+	// (-x)^e is x^e when e is even, and -x^e otherwise
+	negated := new(Nat).ModNeg(way2, &m)
+	way2.CondAssign(Choice(e.Byte(0)&1), negated)
+	return way1.Eq(way2) == 1
+}
+
+func TestIntExpModNegation(t *testing.T) {
+	err := quick.Check(testIntExpModNegation, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIntExpModExamples(t *testing.T) {
+	m := ModulusFromUint64(13)
+	x := new(Int).SetUint64(2).Neg(1)
+	e := new(Nat).SetUint64(3)
+	expected := new(Nat).SetUint64(13 - 8)
+	actual := x.ExpMod(e, m)
+	if expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+}
+
+func testIntCmpMatchesBig(x *Int, y *Int) bool {
+	gt, eq, lt := x.Cmp(y)
+	switch x.Big().Cmp(y.Big()) {
+	case 1:
+		return gt == 1 && eq == 0 && lt == 0
+	case 0:
+		return gt == 0 && eq == 1 && lt == 0
+	default:
+		return gt == 0 && eq == 0 && lt == 1
+	}
+}
+
+func TestIntCmpMatchesBig(t *testing.T) {
+	err := quick.Check(testIntCmpMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIntCmpExamples(t *testing.T) {
+	negZero := negativeZero(64)
+	zero := new(Int).SetUint64(0)
+	_, eq, _ := negZero.Cmp(zero)
+	if eq != 1 {
+		t.Errorf("-0 != +0")
+	}
+	minusOne := new(Int).SetUint64(1).Neg(1)
+	gt, _, _ := zero.Cmp(minusOne)
+	if gt != 1 {
+		t.Errorf("0 <= -1")
+	}
+	minusTwo := new(Int).SetUint64(2).Neg(1)
+	_, _, lt := minusTwo.Cmp(minusOne)
+	if lt != 1 {
+		t.Errorf("-2 >= -1")
+	}
+}
+
+func testIntInt64RoundTrip(x int64) bool {
+	z := new(Int).SetInt64(x)
+	return z.Int64() == x && z.Uint64() == uint64(x) && z.Big().Int64() == x
+}
+
+func TestIntInt64RoundTrip(t *testing.T) {
+	err := quick.Check(testIntInt64RoundTrip, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIntInt64Examples(t *testing.T) {
+	x := new(Int).SetInt64(-5)
+	expected := new(Int).SetUint64(5).Neg(1)
+	if expected.Eq(x) != 1 {
+		t.Errorf("%+v != %+v", expected, x)
+	}
+	x.SetInt64(-(1 << 63))
+	if x.Int64() != -(1 << 63) {
+		t.Errorf("%+v != %+v", x.Int64(), int64(-(1 << 63)))
+	}
+	// Truncation keeps the low 64 bits of the absolute value
+	x.SetBytes([]byte{1, 0, 0, 0, 0, 0, 0, 0, 7}).Neg(1)
+	if x.Int64() != -7 {
+		t.Errorf("%+v != %+v", x.Int64(), -7)
+	}
+}
+
+func testIntBytesRoundTrip(x *Int) bool {
+	y := new(Int).SetBytesSigned(x.Bytes())
+	return y.Eq(x) == 1 && y.Big().Cmp(x.Big()) == 0
+}
+
+func TestIntBytesRoundTrip(t *testing.T) {
+	err := quick.Check(testIntBytesRoundTrip, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIntBytesExamples(t *testing.T) {
+	x := new(Int).SetUint64(1).Neg(1).Resize(8)
+	expected := []byte{0xFF, 0xFF}
+	actual := x.Bytes()
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	x.SetUint64(0x80).Resize(8)
+	expected = []byte{0x00, 0x80}
+	actual = x.Bytes()
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	x.SetBytesSigned([]byte{0x80})
+	expectedInt := new(Int).SetUint64(0x80).Neg(1)
+	if expectedInt.Eq(x) != 1 {
+		t.Errorf("%+v != %+v", expectedInt, x)
+	}
+	x.SetBytesSigned([]byte{0xFF, 0x00})
+	expectedInt.SetUint64(0x100).Neg(1)
+	if expectedInt.Eq(x) != 1 {
+		t.Errorf("%+v != %+v", expectedInt, x)
+	}
+}
+
+func testIntJSONRoundTrip(x *Int) bool {
+	out, err := json.Marshal(x)
+	if err != nil {
+		return false
+	}
+	y := new(Int)
+	err = json.Unmarshal(out, y)
+	if err != nil {
+		return false
+	}
+	return x.Eq(y) == 1 && x.AnnouncedLen() == y.AnnouncedLen()
+}
+
+func TestIntJSONRoundTrip(t *testing.T) {
+	err := quick.Check(testIntJSONRoundTrip, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIntJSONExamples(t *testing.T) {
+	x := new(Int).SetUint64(0xAB).Resize(8).Neg(1)
+	expected := `"-AB"`
+	actual, _ := json.Marshal(x)
+	if expected != string(actual) {
+		t.Errorf("%+v != %+v", expected, string(actual))
+	}
+	y := new(Int)
+	if err := json.Unmarshal([]byte(`"AB"`), y); err != nil {
+		t.Error(err)
+	}
+	expectedInt := new(Int).SetUint64(0xAB)
+	if expectedInt.Eq(y) != 1 {
+		t.Errorf("%+v != %+v", expectedInt, y)
+	}
+}
+
+func testIntBigConversion(x *Int) bool {
+	y := new(Int).SetBig(x.Big(), x.AnnouncedLen())
+	return x.Eq(y) == 1 && y.AnnouncedLen() == x.AnnouncedLen()
+}
+
+func TestIntBigConversion(t *testing.T) {
+	err := quick.Check(testIntBigConversion, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIntBigExamples(t *testing.T) {
+	x := new(Int).SetBig(big.NewInt(-1234), 16)
+	expected := new(Int).SetUint64(1234).Neg(1)
+	if expected.Eq(x) != 1 {
+		t.Errorf("%+v != %+v", expected, x)
+	}
+	if x.Big().Cmp(big.NewInt(-1234)) != 0 {
+		t.Errorf("%+v != %+v", x.Big(), -1234)
+	}
+}
+
+func testIntBytesLEMatchesBytes(x *Int) bool {
+	le := x.BytesLE()
+	if !bytes.Equal(reversed(le), x.Bytes()) {
+		return false
+	}
+	y := new(Int).SetBytesSignedLE(le)
+	return y.Eq(x) == 1
+}
+
+func TestIntBytesLEMatchesBytes(t *testing.T) {
+	err := quick.Check(testIntBytesLEMatchesBytes, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIntSetBytesLEExamples(t *testing.T) {
+	x := new(Int).SetBytesLE([]byte{0x34, 0x12})
+	expected := new(Int).SetUint64(0x1234)
+	if expected.Eq(x) != 1 {
+		t.Errorf("%+v != %+v", expected, x)
+	}
+	x.SetBytesSignedLE([]byte{0x00, 0xFF})
+	expected.SetInt64(-0x100)
+	if expected.Eq(x) != 1 {
+		t.Errorf("%+v != %+v", expected, x)
+	}
+}
+
+func TestIntFillBytesExamples(t *testing.T) {
+	x := new(Int).SetUint64(2).Neg(1)
+	expected := []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFE}
+	actual := x.FillBytes(make([]byte, 12))
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	actual = x.FillBytesLE(make([]byte, 12))
+	if !bytes.Equal(reversed(expected), actual) {
+		t.Errorf("%+v != %+v", reversed(expected), actual)
+	}
+	x.SetUint64(0x1234)
+	expected = []byte{0x34}
+	actual = x.FillBytes(make([]byte, 1))
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+}
+
+func testIntSetDecimalMatchesBig(x *Int) bool {
+	y, err := new(Int).SetDecimal(x.Big().String())
+	if err != nil {
+		return false
+	}
+	return x.Eq(y) == 1
+}
+
+func TestIntSetDecimalMatchesBig(t *testing.T) {
+	err := quick.Check(testIntSetDecimalMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIntSetHexExamples(t *testing.T) {
+	x, err := new(Int).SetHex("-ABCD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := new(Int).SetInt64(-0xABCD)
+	if expected.Eq(x) != 1 {
+		t.Errorf("%+v != %+v", expected, x)
+	}
+	x, err = new(Int).SetHex("+12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected.SetInt64(0x12)
+	if expected.Eq(x) != 1 {
+		t.Errorf("%+v != %+v", expected, x)
+	}
+	if _, err := new(Int).SetHex("--12"); err == nil {
+		t.Errorf("expected error parsing --12")
+	}
+}
+
+func TestIntFormatExamples(t *testing.T) {
+	x := new(Int).SetInt64(-171)
+	cases := []struct {
+		format   string
+		expected string
+	}{
+		{"%x", "-ab"},
+		{"%d", "-171"},
+		{"%07d", "-000171"},
+		{"%v", x.String()},
+	}
+	for _, c := range cases {
+		actual := fmt.Sprintf(c.format, x)
+		if c.expected != actual {
+			t.Errorf("%s: %+v != %+v", c.format, c.expected, actual)
+		}
+	}
+	x.SetInt64(5)
+	if actual := fmt.Sprintf("%+d", x); actual != "+5" {
+		t.Errorf("%+v != %+v", "+5", actual)
+	}
+}
+
 func testIntMarshalBinaryRoundTrip(x *Int) bool {
 	out, err := x.MarshalBinary()
 	if err != nil {
@@ -178,22 +857,269 @@ func TestIntMarshalBinaryRoundTrip(t *testing.T) {
 	}
 }
 
-func testInvalidInt(expected []byte) bool {
+func TestInvalidInt(t *testing.T) {
 	x := new(Int)
-	err := x.UnmarshalBinary(expected)
-	// empty slice is invalid, so we expect an error
-	if len(expected) == 0 {
-		return err != nil
+	invalid := [][]byte{
+		// empty
+		{},
+		// unknown version
+		{0, 0, 0, 0, 0, 8, 0xAA},
+		// missing sign
+		{binaryVersion},
+		// invalid sign
+		{binaryVersion, 2, 0, 0, 0, 8, 0xAA},
+		// missing length
+		{binaryVersion, 1, 0, 0},
+		// too short for the length
+		{binaryVersion, 1, 0, 0, 0, 16, 0xAA},
+		// bits set past the length
+		{binaryVersion, 1, 0, 0, 0, 7, 0xAA},
+		// trailing data
+		{binaryVersion, 1, 0, 0, 0, 8, 0xAA, 0xBB},
+	}
+	for _, data := range invalid {
+		if x.UnmarshalBinary(data) == nil {
+			t.Errorf("expected error unmarshalling %+v", data)
+		}
+	}
+	err := x.UnmarshalBinary([]byte{binaryVersion, 1, 0, 0, 0, 7, 0x7A})
+	if err != nil {
+		t.Error(err)
+	}
+	expected := new(Int).SetUint64(0x7A).Resize(7).Neg(1)
+	if expected.Eq(x) != 1 || x.AnnouncedLen() != 7 {
+		t.Errorf("%+v != %+v", expected, x)
 	}
-	expectedBytes := expected[1:]
-	expectedSign := Choice(expected[0]) & 1
-	actualBytes := x.Abs().Bytes()
-	actualSign := x.sign
-	return (expectedSign == actualSign) && bytes.Equal(expectedBytes, actualBytes)
 }
 
-func TestInvalidInt(t *testing.T) {
-	err := quick.Check(testInvalidInt, &quick.Config{})
+func TestIntClear(t *testing.T) {
+	x := new(Int).SetInt64(-0x1234)
+	backing := x.abs.limbs[:cap(x.abs.limbs)]
+	x.Clear()
+	if x.Eq(new(Int)) != 1 || x.IsNegative() != 0 {
+		t.Errorf("%+v is not cleared", x)
+	}
+	for _, limb := range backing {
+		if limb != 0 {
+			t.Errorf("limb %+v was not cleared", limb)
+		}
+	}
+}
+
+func testIntLshMatchesBig(x *Int, shift uint8) bool {
+	actual := new(Int).Lsh(x, uint(shift), -1)
+	expected := new(big.Int).Lsh(x.Big(), uint(shift))
+	return actual.Big().Cmp(expected) == 0
+}
+
+func TestIntLshMatchesBig(t *testing.T) {
+	err := quick.Check(testIntLshMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testIntRshMatchesBig(x *Int, shift uint8) bool {
+	actual := new(Int).Rsh(x, uint(shift), -1)
+	expected := new(big.Int).Rsh(x.Big(), uint(shift))
+	return actual.Big().Cmp(expected) == 0
+}
+
+func TestIntRshMatchesBig(t *testing.T) {
+	err := quick.Check(testIntRshMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIntRshExamples(t *testing.T) {
+	for _, c := range []struct{ x, expected int64 }{{-5, -3}, {5, 2}, {-4, -2}, {-1, -1}, {0, 0}} {
+		actual := new(Int).Rsh(new(Int).SetInt64(c.x), 1, -1)
+		expected := new(Int).SetInt64(c.expected)
+		if actual.Eq(expected) != 1 {
+			t.Errorf("%+v != %+v", expected, actual)
+		}
+	}
+}
+
+func testIntNormalizePreservesValue(x *Int) bool {
+	normalized := x.Clone().Normalize()
+	if normalized.AnnouncedLen() != x.TrueLen() || normalized.TrueLen() != x.TrueLen() {
+		return false
+	}
+	return normalized.Big().Cmp(x.Big()) == 0
+}
+
+func TestIntNormalizePreservesValue(t *testing.T) {
+	err := quick.Check(testIntNormalizePreservesValue, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// negativeZero returns -0, which operations never produce, bypassing normalization
+func negativeZero(size int) *Int {
+	z := new(Int).SetNat(new(Nat).Resize(size))
+	z.sign = 1
+	return z
+}
+
+// noNegativeZero checks that z isn't -0
+func noNegativeZero(z *Int) bool {
+	return z.sign == 0 || z.abs.EqZero() == 0
+}
+
+func testIntNoNegativeZero(x *Int, y *Int, m Modulus, cap uint8) bool {
+	c := int(cap)
+	results := []*Int{
+		new(Int).Sub(x, x, -1),
+		new(Int).Add(x, new(Int).SetInt(x).Neg(1), -1),
+		new(Int).Add(x, y, c),
+		new(Int).Sub(x, y, c),
+		new(Int).WrappingAdd(x, y, c),
+		new(Int).WrappingSub(x, y, c),
+		new(Int).SaturatingAdd(x, y, c),
+		new(Int).SaturatingSub(x, y, c),
+		new(Int).Mul(x, y, c),
+		new(Int).Mul(x, new(Int), -1),
+		new(Int).Lsh(x, 3, c),
+		new(Int).Rsh(x, 3, c),
+		new(Int).Div(x, &m, c),
+		new(Int).SetInt(x).Resize(c),
+		new(Int).SetInt(x).Neg(1).Neg(1),
+		new(Int).ExactDiv(new(Int), new(Int).SetUint64(3).Neg(1), -1),
+		new(Int).SetModSymmetric(new(Nat), &m),
+		new(Int).SetModSymmetric(x.Abs(), &m),
+		new(Int).SetBytesSigned(x.Bytes()),
+	}
+	for _, z := range results {
+		if !noNegativeZero(z) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestIntNoNegativeZero(t *testing.T) {
+	skipIfCheckingCaps(t)
+	err := quick.Check(testIntNoNegativeZero, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIntNegativeZeroParsing(t *testing.T) {
+	parsed := []*Int{}
+	z, _ := new(Int).SetHex("-0")
+	parsed = append(parsed, z)
+	z, _ = new(Int).SetDecimal("-000")
+	parsed = append(parsed, z)
+	data, _ := new(Int).MarshalBinary()
+	data[1] = 1
+	z = new(Int)
+	if err := z.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	parsed = append(parsed, z)
+	z = new(Int)
+	if err := json.Unmarshal([]byte(`"-0"`), z); err != nil {
+		t.Fatal(err)
+	}
+	parsed = append(parsed, z)
+	for _, z := range parsed {
+		if !noNegativeZero(z) {
+			t.Errorf("parsed -0: %v", z)
+		}
+	}
+}
+
+// Every operation should treat -0 exactly like +0
+func TestIntNegativeZeroLikeZero(t *testing.T) {
+	m := ModulusFromUint64(13)
+	x := new(Int).SetInt64(-5)
+	zero, negZero := new(Int).Resize(64), negativeZero(64)
+	same := func(name string, a, b *Int) {
+		if a.sign != b.sign || a.Eq(b) != 1 || a.AnnouncedLen() != b.AnnouncedLen() {
+			t.Errorf("%s: %v != %v", name, a, b)
+		}
+	}
+	for _, op := range []struct {
+		name string
+		f    func(z *Int) *Int
+	}{
+		{"Add", func(z *Int) *Int { return new(Int).Add(z, x, -1) }},
+		{"Sub", func(z *Int) *Int { return new(Int).Sub(z, x, -1) }},
+		{"Mul", func(z *Int) *Int { return new(Int).Mul(z, x, -1) }},
+		{"Lsh", func(z *Int) *Int { return new(Int).Lsh(z, 5, -1) }},
+		{"Rsh", func(z *Int) *Int { return new(Int).Rsh(z, 5, -1) }},
+		{"Div", func(z *Int) *Int { return new(Int).Div(z, m, -1) }},
+		{"Neg", func(z *Int) *Int { return z.Clone().Neg(1) }},
+		{"Resize", func(z *Int) *Int { return z.Clone().Resize(8) }},
+		{"SetBytesSigned", func(z *Int) *Int { return new(Int).SetBytesSigned(z.Bytes()) }},
+	} {
+		same(op.name, op.f(zero), op.f(negZero))
+	}
+	if zero.Mod(m).Eq(negZero.Mod(m)) != 1 {
+		t.Errorf("Mod: -0 != +0")
+	}
+	if zero.Int64() != negZero.Int64() {
+		t.Errorf("Int64: %d != %d", zero.Int64(), negZero.Int64())
+	}
+	if !bytes.Equal(zero.Bytes(), negZero.Bytes()) {
+		t.Errorf("Bytes: %x != %x", zero.Bytes(), negZero.Bytes())
+	}
+	if zero.IsNegative() != negZero.IsNegative() {
+		t.Errorf("IsNegative: -0 != +0")
+	}
+	n0, z0, p0 := zero.Sign()
+	n1, z1, p1 := negZero.Sign()
+	if n0 != n1 || z0 != z1 || p0 != p1 {
+		t.Errorf("Sign: -0 != +0")
+	}
+}
+
+func TestIntSignExamples(t *testing.T) {
+	examples := []struct {
+		x                        *Int
+		negative, zero, positive Choice
+	}{
+		{new(Int), 0, 1, 0},
+		{negativeZero(128), 0, 1, 0},
+		{new(Int).SetInt64(7), 0, 0, 1},
+		{new(Int).SetInt64(-7), 1, 0, 0},
+		{new(Int).SetInt64(math.MinInt64), 1, 0, 0},
+	}
+	for _, e := range examples {
+		negative, zero, positive := e.x.Sign()
+		if negative != e.negative || zero != e.zero || positive != e.positive {
+			t.Errorf("%v: (%d, %d, %d) != (%d, %d, %d)", e.x, e.negative, e.zero, e.positive, negative, zero, positive)
+		}
+		if e.x.IsZero() != e.zero {
+			t.Errorf("%v: IsZero() = %d", e.x, e.x.IsZero())
+		}
+		if e.x.IsNegative() != e.negative {
+			t.Errorf("%v: IsNegative() = %d", e.x, e.x.IsNegative())
+		}
+		if e.x.IsPositive() != e.positive {
+			t.Errorf("%v: IsPositive() = %d", e.x, e.x.IsPositive())
+		}
+	}
+}
+
+func testIntSignMatchesBig(x *Int) bool {
+	negative, zero, positive := x.Sign()
+	switch x.Big().Sign() {
+	case 1:
+		return negative == 0 && zero == 0 && positive == 1 && x.IsPositive() == 1
+	case 0:
+		return negative == 0 && zero == 1 && positive == 0 && x.IsZero() == 1
+	default:
+		return negative == 1 && zero == 0 && positive == 0 && x.IsNegative() == 1
+	}
+}
+
+func TestIntSignMatchesBig(t *testing.T) {
+	err := quick.Check(testIntSignMatchesBig, &quick.Config{})
 	if err != nil {
 		t.Error(err)
 	}
@@ -0,0 +1,139 @@
+// Package cttest provides statistical tests for constant-time behavior.
+//
+// This follows the approach of dudect: an operation is timed many times, on
+// inputs drawn from two classes, usually one fixed input, and random inputs.
+// If the operation runs in constant time, the two distributions of timings
+// should be indistinguishable, which we check with Welch's t-test.
+//
+// A test passing doesn't prove anything, but a test failing is a strong signal
+// that something leaks, which makes this useful to catch regressions.
+package cttest
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Threshold is the value of |t| above which we consider an operation to leak.
+//
+// This is the same threshold used by dudect.
+const Threshold = 10
+
+// Welch accumulates the statistics needed for Welch's t-test, online.
+//
+// Samples are split into two classes, 0 and 1.
+type Welch struct {
+	n    [2]float64
+	mean [2]float64
+	m2   [2]float64
+}
+
+// Push adds a new sample x to a given class.
+func (w *Welch) Push(class int, x float64) {
+	w.n[class]++
+	delta := x - w.mean[class]
+	w.mean[class] += delta / w.n[class]
+	w.m2[class] += delta * (x - w.mean[class])
+}
+
+// Samples returns the total number of samples pushed so far.
+func (w *Welch) Samples() int {
+	return int(w.n[0] + w.n[1])
+}
+
+// T returns the t statistic comparing both classes.
+//
+// This is 0 if either class has fewer than 2 samples.
+func (w *Welch) T() float64 {
+	if w.n[0] < 2 || w.n[1] < 2 {
+		return 0
+	}
+	var0 := w.m2[0] / (w.n[0] - 1)
+	var1 := w.m2[1] / (w.n[1] - 1)
+	den := math.Sqrt(var0/w.n[0] + var1/w.n[1])
+	if den == 0 {
+		return 0
+	}
+	return (w.mean[0] - w.mean[1]) / den
+}
+
+// Test describes an operation to check.
+type Test struct {
+	// Name identifies the test.
+	Name string
+	// Input prepares an input of a given class, returning the operation to time.
+	//
+	// Class 0 should be a fixed input, and class 1 a random input. The work done
+	// by Input itself isn't timed.
+	Input func(class int, rand *rand.Rand) func()
+}
+
+// Result holds the outcome of running a Test.
+type Result struct {
+	Name    string
+	Samples int
+	// T is the t statistic with the largest magnitude, among all croppings.
+	T float64
+}
+
+// Leaky returns true if the timings of the two classes were distinguishable.
+func (r Result) Leaky() bool {
+	return math.Abs(r.T) > Threshold
+}
+
+// The percentiles at which timings get cropped, to remove outliers caused
+// by interrupts and the like, which would otherwise drown out smaller leaks.
+var cropPercentiles = []float64{1, 0.5, 0.75, 0.9, 0.95, 0.99}
+
+type measurement struct {
+	class    int
+	duration float64
+}
+
+// Run runs a test, taking the given number of samples.
+//
+// The classes are interleaved randomly, so that any drift in timings over
+// the course of the test affects both of them equally. At least one sample
+// is needed, and an error is returned otherwise.
+func Run(test Test, samples int, seed int64) (Result, error) {
+	if samples <= 0 {
+		return Result{}, errors.New("cttest: number of samples must be positive")
+	}
+	r := rand.New(rand.NewSource(seed))
+	classes := make([]int, samples)
+	ops := make([]func(), samples)
+	for i := range ops {
+		classes[i] = r.Intn(2)
+		ops[i] = test.Input(classes[i], r)
+	}
+	measurements := make([]measurement, samples)
+	for i, op := range ops {
+		start := time.Now()
+		op()
+		measurements[i] = measurement{classes[i], float64(time.Since(start))}
+	}
+
+	sorted := make([]float64, samples)
+	for i, m := range measurements {
+		sorted[i] = m.duration
+	}
+	sort.Float64s(sorted)
+
+	result := Result{Name: test.Name, Samples: samples}
+	for _, p := range cropPercentiles {
+		cutoff := sorted[int(p*float64(samples-1))]
+		var w Welch
+		for _, m := range measurements {
+			if m.duration <= cutoff {
+				w.Push(m.class, m.duration)
+			}
+		}
+		if t := w.T(); math.Abs(t) > math.Abs(result.T) {
+			result.T = t
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,92 @@
+package cttest
+
+import (
+	"flag"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+var samples = flag.Int("cttest.samples", 0, "run the timing suite, taking this many samples per test")
+
+func TestWelch(t *testing.T) {
+	var w Welch
+	for _, x := range []float64{1, 2, 3, 4} {
+		w.Push(0, x)
+	}
+	for _, x := range []float64{3, 4, 5, 6} {
+		w.Push(1, x)
+	}
+	if w.Samples() != 8 {
+		t.Errorf("%d != 8", w.Samples())
+	}
+	// Both classes have variance 5/3, so t = -2 / sqrt(5/6)
+	expected := -2 / math.Sqrt(5.0/6.0)
+	if math.Abs(w.T()-expected) > 1e-9 {
+		t.Errorf("%v != %v", expected, w.T())
+	}
+}
+
+func TestWelchNotEnoughSamples(t *testing.T) {
+	var w Welch
+	w.Push(0, 1)
+	w.Push(1, 2)
+	if w.T() != 0 {
+		t.Errorf("%v != 0", w.T())
+	}
+}
+
+var sink int
+
+func TestRunDetectsLeak(t *testing.T) {
+	leaky := Test{"leaky", func(class int, r *rand.Rand) func() {
+		n := 100
+		if class == 1 {
+			n = 10000
+		}
+		return func() {
+			for i := 0; i < n; i++ {
+				sink += i
+			}
+		}
+	}}
+	result, err := Run(leaky, 2000, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Leaky() {
+		t.Errorf("leak not detected, t = %v", result.T)
+	}
+}
+
+func TestRunRequiresSamples(t *testing.T) {
+	test := Test{"empty", func(class int, r *rand.Rand) func() {
+		return func() {}
+	}}
+	for _, samples := range []int{0, -1} {
+		if _, err := Run(test, samples, 1); err == nil {
+			t.Errorf("expected error for %d samples", samples)
+		}
+	}
+	if _, err := Run(test, 1, 1); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestSuite runs the timing suite, which takes a while, so it needs to be
+// enabled with -cttest.samples. Around 100000 samples gives reliable results.
+func TestSuite(t *testing.T) {
+	if *samples == 0 {
+		t.Skip("pass -cttest.samples to run the timing suite")
+	}
+	for _, test := range Suite() {
+		result, err := Run(test, *samples, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("%s: t = %.2f", result.Name, result.T)
+		if result.Leaky() {
+			t.Errorf("%s leaks timing information: t = %.2f", result.Name, result.T)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package cttest
+
+import (
+	"math/rand"
+
+	"github.com/cronokirby/saferith"
+)
+
+// suiteBytes is the size of the inputs used in the suite, in bytes.
+const suiteBytes = 128
+
+// suiteModulus is a fixed, odd, 1024 bit modulus.
+var suiteModulus = func() *saferith.Modulus {
+	bytes := make([]byte, suiteBytes)
+	for i := range bytes {
+		bytes[i] = 0xA5
+	}
+	bytes[0] |= 0x80
+	bytes[len(bytes)-1] |= 1
+	return saferith.ModulusFromBytes(bytes)
+}()
+
+// secret returns a Nat with a fixed size, which is 0 for class 0, and
+// random for class 1.
+func secret(class int, r *rand.Rand) *saferith.Nat {
+	bytes := make([]byte, suiteBytes)
+	if class == 1 {
+		r.Read(bytes)
+	}
+	return new(saferith.Nat).SetBytes(bytes)
+}
+
+// random returns a random Nat, independently of the class.
+func random(r *rand.Rand) *saferith.Nat {
+	bytes := make([]byte, suiteBytes)
+	r.Read(bytes)
+	return new(saferith.Nat).SetBytes(bytes)
+}
+
+// Suite returns tests for the operations in saferith which matter the most.
+//
+// Each of these operates on a secret input which is either fixed to 0,
+// or random, with every other input being random.
+func Suite() []Test {
+	m := suiteModulus
+	return []Test{
+		{"Nat.Mul", func(class int, r *rand.Rand) func() {
+			x, y := secret(class, r), random(r)
+			return func() { new(saferith.Nat).Mul(x, y, -1) }
+		}},
+		{"Nat.ModMul", func(class int, r *rand.Rand) func() {
+			x, y := secret(class, r), random(r)
+			return func() { new(saferith.Nat).ModMul(x, y, m) }
+		}},
+		{"Nat.ModAdd", func(class int, r *rand.Rand) func() {
+			x, y := secret(class, r), random(r)
+			return func() { new(saferith.Nat).ModAdd(x, y, m) }
+		}},
+		{"Nat.Mod", func(class int, r *rand.Rand) func() {
+			x := secret(class, r)
+			return func() { new(saferith.Nat).Mod(x, m) }
+		}},
+		{"Nat.Exp", func(class int, r *rand.Rand) func() {
+			x, e := random(r), secret(class, r)
+			return func() { new(saferith.Nat).Exp(x, e, m) }
+		}},
+		{"Nat.Cmp", func(class int, r *rand.Rand) func() {
+			x, y := secret(class, r), random(r)
+			return func() { x.Cmp(y) }
+		}},
+		{"Nat.Cmp (mixed sizes)", func(class int, r *rand.Rand) func() {
+			// Comparing with a shorter number should only leak both lengths
+			x, y := secret(class, r), random(r).Resize(8*suiteBytes/2)
+			return func() { x.Cmp(y) }
+		}},
+		{"Nat.EqTrimmed", func(class int, r *rand.Rand) func() {
+			x, y := secret(class, r), random(r).Resize(8*suiteBytes/2)
+			return func() { x.EqTrimmed(y) }
+		}},
+		{"Nat.ModInverse", func(class int, r *rand.Rand) func() {
+			x := secret(class, r)
+			return func() { new(saferith.Nat).ModInverse(x, m) }
+		}},
+	}
+}
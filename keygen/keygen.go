@@ -0,0 +1,163 @@
+// Package keygen provides helpers for generating random primes, built on saferith.
+//
+// This includes plain primes, safe primes, and Blum integers, which show up in
+// many protocols, such as RSA, or Paillier encryption. Generation is done by rejection
+// sampling, so the number of candidates tried is leaked, as in most implementations.
+package keygen
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/cronokirby/saferith"
+)
+
+// mrRounds is the number of Miller-Rabin rounds used to check candidates.
+//
+// This gives us an error probability of at most 2^-128, even for adversarial inputs.
+const mrRounds = 64
+
+// randomCandidate samples an odd number of exactly bits bits, with its top two bits set
+//
+// Setting the top two bits ensures that the product of two such numbers has exactly
+// twice the number of bits. lowBits is OR'd into the least significant byte.
+func randomCandidate(rand io.Reader, bits int, lowBits byte) (*saferith.Nat, error) {
+	buf := make([]byte, (bits+7)/8)
+	if _, err := io.ReadFull(rand, buf); err != nil {
+		return nil, err
+	}
+	excess := uint(8*len(buf) - bits)
+	buf[0] &= 0xFF >> excess
+	setBit := func(i int) {
+		buf[len(buf)-1-i/8] |= 1 << uint(i%8)
+	}
+	setBit(bits - 1)
+	setBit(bits - 2)
+	buf[len(buf)-1] |= lowBits
+	return new(saferith.Nat).SetBytes(buf).Resize(bits), nil
+}
+
+// Prime returns a random prime of exactly the given number of bits.
+//
+// The top two bits of the prime will be set, so that the product of two
+// such primes has exactly twice as many bits.
+func Prime(rand io.Reader, bits int) (*saferith.Nat, error) {
+	if bits < 2 {
+		return nil, errors.New("keygen: prime size must be at least 2 bits")
+	}
+	for {
+		p, err := randomCandidate(rand, bits, 1)
+		if err != nil {
+			return nil, err
+		}
+		if p.ProbablyPrime(mrRounds, rand) {
+			return p, nil
+		}
+	}
+}
+
+// BlumPrime returns a random prime p = 3 mod 4, of exactly the given number of bits.
+//
+// Like with Prime, the top two bits will be set. This requires at least 5 bits,
+// since no 4 bit number of that form is prime.
+func BlumPrime(rand io.Reader, bits int) (*saferith.Nat, error) {
+	if bits < 5 {
+		return nil, errors.New("keygen: Blum prime size must be at least 5 bits")
+	}
+	for {
+		p, err := randomCandidate(rand, bits, 0b11)
+		if err != nil {
+			return nil, err
+		}
+		if p.ProbablyPrime(mrRounds, rand) {
+			return p, nil
+		}
+	}
+}
+
+// SafePrime returns a random safe prime p = 2q + 1, with q prime, of exactly the given number of bits.
+//
+// Both p and q are returned. Like with Prime, the top two bits of p will be set.
+// This requires at least 6 bits, since there are no such safe primes with fewer bits.
+//
+// This is considerably slower than generating a normal prime.
+func SafePrime(rand io.Reader, bits int) (p *saferith.Nat, q *saferith.Nat, err error) {
+	if bits < 6 {
+		return nil, nil, errors.New("keygen: safe prime size must be at least 6 bits")
+	}
+	one := new(saferith.Nat).SetUint64(1)
+	for {
+		q, err = randomCandidate(rand, bits-1, 1)
+		if err != nil {
+			return nil, nil, err
+		}
+		p = new(saferith.Nat).Lsh(q, 1, bits)
+		p.Add(p, one, bits)
+		// Checking p first is cheaper on average, since p can be rejected with a single round
+		if p.ProbablyPrime(1, rand) && q.ProbablyPrime(mrRounds, rand) && p.ProbablyPrime(mrRounds, rand) {
+			return p, q, nil
+		}
+	}
+}
+
+// BlumInteger returns a random Blum integer n = p * q, of exactly the given number of bits.
+//
+// The factors p and q are distinct Blum primes, each with half of the bits.
+// This requires at least 14 bits, since BlumPrime only has a single candidate
+// below 7 bits, which would leave us unable to find a distinct q.
+func BlumInteger(rand io.Reader, bits int) (n *saferith.Nat, p *saferith.Nat, q *saferith.Nat, err error) {
+	if bits < 14 || bits%2 != 0 {
+		return nil, nil, nil, errors.New("keygen: Blum integer size must be even, and at least 14 bits")
+	}
+	p, err = BlumPrime(rand, bits/2)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for {
+		q, err = BlumPrime(rand, bits/2)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if p.Eq(q) != 1 {
+			break
+		}
+	}
+	n = new(saferith.Nat).Mul(p, q, bits)
+	return n, p, q, nil
+}
+
+// deterministicReader produces an endless stream of bytes derived from a seed.
+type deterministicReader struct {
+	seed    [sha256.Size]byte
+	counter uint64
+	buf     []byte
+}
+
+// NewDeterministicReader creates a reader deterministically producing random looking bytes from a seed.
+//
+// This is useful for tests, in order to generate reproducible primes. The output is
+// SHA-256(seed || counter), for an incrementing counter. This should NOT be used to
+// generate keys in production, where crypto/rand.Reader should be used instead.
+func NewDeterministicReader(seed []byte) io.Reader {
+	return &deterministicReader{seed: sha256.Sum256(seed)}
+}
+
+func (r *deterministicReader) Read(out []byte) (int, error) {
+	n := 0
+	for n < len(out) {
+		if len(r.buf) == 0 {
+			var block [sha256.Size + 8]byte
+			copy(block[:], r.seed[:])
+			binary.BigEndian.PutUint64(block[sha256.Size:], r.counter)
+			r.counter++
+			digest := sha256.Sum256(block[:])
+			r.buf = digest[:]
+		}
+		copied := copy(out[n:], r.buf)
+		r.buf = r.buf[copied:]
+		n += copied
+	}
+	return n, nil
+}
@@ -0,0 +1,104 @@
+package keygen
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestPrime(t *testing.T) {
+	for _, bits := range []int{2, 3, 16, 64, 256} {
+		p, err := Prime(rand.Reader, bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pBig := p.Big()
+		if pBig.BitLen() != bits || !pBig.ProbablyPrime(20) {
+			t.Errorf("%+v is not a %d bit prime", p, bits)
+		}
+	}
+	if _, err := Prime(rand.Reader, 1); err == nil {
+		t.Errorf("expected error for 1 bit prime")
+	}
+}
+
+func TestBlumPrime(t *testing.T) {
+	for _, bits := range []int{5, 6, 7, 128} {
+		p, err := BlumPrime(rand.Reader, bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pBig := p.Big()
+		if pBig.BitLen() != bits || !pBig.ProbablyPrime(20) || pBig.Bit(0) != 1 || pBig.Bit(1) != 1 {
+			t.Errorf("%+v is not a %d bit Blum prime", p, bits)
+		}
+	}
+	if _, err := BlumPrime(rand.Reader, 4); err == nil {
+		t.Errorf("expected error for 4 bit Blum prime")
+	}
+}
+
+func TestSafePrime(t *testing.T) {
+	for _, bits := range []int{6, 7, 8, 64} {
+		p, q, err := SafePrime(rand.Reader, bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pBig := p.Big()
+		qBig := q.Big()
+		if pBig.BitLen() != bits || !pBig.ProbablyPrime(20) || !qBig.ProbablyPrime(20) {
+			t.Errorf("%+v is not a %d bit safe prime", p, bits)
+		}
+		expected := new(big.Int).Lsh(qBig, 1)
+		expected.Add(expected, big.NewInt(1))
+		if expected.Cmp(pBig) != 0 {
+			t.Errorf("%+v != %+v", expected, pBig)
+		}
+	}
+	if _, _, err := SafePrime(rand.Reader, 5); err == nil {
+		t.Errorf("expected error for 5 bit safe prime")
+	}
+}
+
+func TestBlumInteger(t *testing.T) {
+	for _, bits := range []int{14, 16, 256} {
+		n, p, q, err := BlumInteger(rand.Reader, bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := new(big.Int).Mul(p.Big(), q.Big())
+		if n.Big().Cmp(expected) != 0 || expected.BitLen() != bits {
+			t.Errorf("%+v != %+v", expected, n)
+		}
+		if p.Eq(q) == 1 {
+			t.Errorf("factors should be distinct")
+		}
+	}
+	if _, _, _, err := BlumInteger(rand.Reader, 65); err == nil {
+		t.Errorf("expected error for odd size")
+	}
+	if _, _, _, err := BlumInteger(rand.Reader, 12); err == nil {
+		t.Errorf("expected error for 12 bit Blum integer")
+	}
+}
+
+func TestDeterministicReader(t *testing.T) {
+	p1, err := Prime(NewDeterministicReader([]byte("seed")), 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, err := Prime(NewDeterministicReader([]byte("seed")), 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p1.Eq(p2) != 1 {
+		t.Errorf("%+v != %+v", p1, p2)
+	}
+	p3, err := Prime(NewDeterministicReader([]byte("other seed")), 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p1.Eq(p3) == 1 {
+		t.Errorf("different seeds produced the same prime")
+	}
+}
@@ -0,0 +1,134 @@
+package keygen
+
+import (
+	"errors"
+	"io"
+
+	"github.com/cronokirby/saferith"
+)
+
+// provableBaseBits is the size below which primes are proven directly.
+//
+// saferith.Nat.DeterministicIsPrime is exact for numbers this small.
+const provableBaseBits = 64
+
+// PrimeCertificate proves that P is prime, using Pocklington's criterion.
+//
+// If Q is nil, then P is small enough to be checked directly, with DeterministicIsPrime.
+// Otherwise, Q is a prime with Q^2 > P, and Q dividing P - 1, whose primality is proven
+// by Sub. The witness A then satisfies A^(P - 1) = 1 mod P, and gcd(A^((P - 1) / Q) - 1, P) = 1,
+// which means that every prime factor of P is larger than sqrt(P), so P must be prime.
+type PrimeCertificate struct {
+	P   *saferith.Nat
+	Q   *saferith.Nat
+	A   *saferith.Nat
+	Sub *PrimeCertificate
+}
+
+// Verify checks that this certificate is valid, proving that P is prime.
+//
+// This isn't constant-time, since certificates are meant to be public.
+func (c *PrimeCertificate) Verify() bool {
+	if c == nil || c.P == nil {
+		return false
+	}
+	if c.Q == nil {
+		prime, err := c.P.DeterministicIsPrime()
+		return err == nil && prime
+	}
+	if c.A == nil || c.Sub == nil || !c.Sub.Verify() || c.Sub.P.Eq(c.Q) != 1 {
+		return false
+	}
+	// P should be odd, and at least 3, so that P - 1 is a valid modulus
+	if c.P.Big().Bit(0) != 1 || c.P.Big().BitLen() < 2 {
+		return false
+	}
+	// Q^2 > P, so any prime factor of P larger than sqrt(P) makes it prime
+	if gt, _, _ := new(saferith.Nat).Mul(c.Q, c.Q, -1).Cmp(c.P); gt != 1 {
+		return false
+	}
+	one := new(saferith.Nat).SetUint64(1)
+	pMinus1 := new(saferith.Nat).Sub(c.P, one, -1)
+	r, rem := new(saferith.Nat).DivRem(pMinus1, c.Q, -1)
+	if rem.EqZero() != 1 {
+		return false
+	}
+	m := saferith.ModulusFromNat(c.P)
+	return pocklington(c.A, pMinus1, r, m)
+}
+
+// pocklington checks the conditions on the witness a, with (p - 1) / q = r.
+func pocklington(a *saferith.Nat, pMinus1 *saferith.Nat, r *saferith.Nat, m *saferith.Modulus) bool {
+	if new(saferith.Nat).Exp(a, pMinus1, m).EqUint64(1) != 1 {
+		return false
+	}
+	y := new(saferith.Nat).Exp(a, r, m)
+	y.ModSub(y, new(saferith.Nat).SetUint64(1), m)
+	return y.Coprime(m.Nat()) == 1
+}
+
+// ProvablePrime returns a random prime of exactly the given number of bits, along with a proof.
+//
+// This uses Maurer's method: we first generate a provable prime q, of just over half the
+// bits, and then look for a prime p = 2rq + 1, for random r, which can be proven prime
+// using Pocklington's criterion and q. The certificate can be checked with Verify.
+//
+// Like with Prime, the top two bits of p will be set. Generation is slower than with Prime,
+// and the primes produced aren't uniformly distributed, but no probabilistic test is involved.
+func ProvablePrime(rand io.Reader, bits int) (*saferith.Nat, *PrimeCertificate, error) {
+	if bits < 2 {
+		return nil, nil, errors.New("keygen: prime size must be at least 2 bits")
+	}
+	if bits <= provableBaseBits {
+		for {
+			p, err := randomCandidate(rand, bits, 1)
+			if err != nil {
+				return nil, nil, err
+			}
+			if prime, _ := p.DeterministicIsPrime(); prime {
+				return p, &PrimeCertificate{P: p}, nil
+			}
+		}
+	}
+
+	// q >= 2^(qBits - 1) > sqrt(2^bits) > sqrt(p)
+	qBits := (bits + 3) / 2
+	q, sub, err := ProvablePrime(rand, qBits)
+	if err != nil {
+		return nil, nil, err
+	}
+	one := new(saferith.Nat).SetUint64(1)
+	two := new(saferith.Nat).SetUint64(2)
+	twoQ := saferith.ModulusFromNat(new(saferith.Nat).Lsh(q, 1, qBits+1))
+	// We need 3 * 2^(bits - 2) <= 2rq + 1 < 2^bits, so that the top two bits are set
+	lo := new(saferith.Nat).Lsh(new(saferith.Nat).SetUint64(3), uint(bits-2), bits)
+	lo.Sub(lo, one, bits)
+	rLo := new(saferith.Nat).Div(lo, twoQ, bits)
+	rLo.Add(rLo, one, bits)
+	hi := new(saferith.Nat).Lsh(one, uint(bits), bits+1)
+	hi.Sub(hi, two, bits)
+	rHi := new(saferith.Nat).Div(hi, twoQ, bits)
+
+	r := new(saferith.Nat)
+	for {
+		if _, err := r.RandRange(rLo, rHi, rand); err != nil {
+			return nil, nil, err
+		}
+		p := new(saferith.Nat).Mul(r, twoQ.Nat(), bits)
+		p.Add(p, one, bits)
+		if p.TrialDivide() != 1 {
+			continue
+		}
+		pMinus1 := new(saferith.Nat).Sub(p, one, bits)
+		pMinus2 := new(saferith.Nat).Sub(p, two, bits)
+		a, err := new(saferith.Nat).RandRange(two, pMinus2, rand)
+		if err != nil {
+			return nil, nil, err
+		}
+		// (p - 1) / q = 2r
+		twoR := new(saferith.Nat).Lsh(r, 1, bits)
+		if pocklington(a, pMinus1, twoR, saferith.ModulusFromNat(p)) {
+			return p, &PrimeCertificate{P: p, Q: q, A: a, Sub: sub}, nil
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package keygen
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/saferith"
+)
+
+func TestProvablePrime(t *testing.T) {
+	for _, bits := range []int{2, 3, 16, 64, 65, 128, 257, 512} {
+		p, cert, err := ProvablePrime(rand.Reader, bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pBig := p.Big()
+		if pBig.BitLen() != bits || !pBig.ProbablyPrime(20) || pBig.Bit(bits-2) != 1 {
+			t.Errorf("%+v is not a %d bit prime", p, bits)
+		}
+		if cert.P.Eq(p) != 1 || !cert.Verify() {
+			t.Errorf("invalid certificate for %+v", p)
+		}
+	}
+	if _, _, err := ProvablePrime(rand.Reader, 1); err == nil {
+		t.Errorf("expected error for 1 bit prime")
+	}
+}
+
+func TestProvablePrimeDeterministic(t *testing.T) {
+	p1, _, err := ProvablePrime(NewDeterministicReader([]byte("seed")), 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, _, err := ProvablePrime(NewDeterministicReader([]byte("seed")), 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p1.Eq(p2) != 1 {
+		t.Errorf("%+v != %+v", p1, p2)
+	}
+}
+
+func TestPrimeCertificateRejectsForgeries(t *testing.T) {
+	p, cert, err := ProvablePrime(rand.Reader, 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Claiming a composite number is prime, with the same proof
+	composite := new(saferith.Nat).Add(p, new(saferith.Nat).SetUint64(2), -1)
+	forged := *cert
+	forged.P = composite
+	if forged.Verify() {
+		t.Errorf("certificate for %+v shouldn't be valid", composite)
+	}
+	// A witness with A^((P - 1) / Q) = 1, since (P - 1) / Q is even
+	forged = *cert
+	forged.A = new(saferith.Nat).Sub(p, new(saferith.Nat).SetUint64(1), -1)
+	if forged.Verify() {
+		t.Errorf("certificate with witness %+v shouldn't be valid", forged.A)
+	}
+	// A factor without its own proof
+	forged = *cert
+	forged.Sub = nil
+	if forged.Verify() {
+		t.Errorf("certificate without a sub certificate shouldn't be valid")
+	}
+	// Small composite numbers
+	forged = PrimeCertificate{P: new(saferith.Nat).SetUint64(91)}
+	if forged.Verify() {
+		t.Errorf("certificate for 91 shouldn't be valid")
+	}
+	var missing *PrimeCertificate
+	if missing.Verify() {
+		t.Errorf("nil certificate shouldn't be valid")
+	}
+}
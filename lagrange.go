@@ -0,0 +1,77 @@
+package saferith
+
+import (
+	"errors"
+)
+
+// Share represents a point (X, Y) on a polynomial, modulo some prime.
+//
+// In Shamir secret sharing, each party holds a share, with the secret being
+// the value of the polynomial at 0.
+type Share struct {
+	X *Nat
+	Y *Nat
+}
+
+// LagrangeCoefficients calculates the Lagrange coefficients for the points xs, evaluated at a point.
+//
+// The coefficient for xs[i] is the product of (at - xs[j]) / (xs[i] - xs[j]) for j != i,
+// modulo p. Multiplying each coefficient with the value of a polynomial at xs[i],
+// and summing the results, gives the value of the polynomial at that point.
+//
+// p must be prime. An error is returned if the points aren't distinct modulo p, in
+// which case whether or not this happened is leaked. Otherwise, this only leaks
+// the number of points, and their announced sizes.
+func LagrangeCoefficients(xs []*Nat, at *Nat, p *Modulus) ([]*Nat, error) {
+	reduced := make([]*Nat, len(xs))
+	for i, x := range xs {
+		reduced[i] = new(Nat).Mod(x, p)
+	}
+	atModP := new(Nat).Mod(at, p)
+
+	coefficients := make([]*Nat, len(xs))
+	diff := new(Nat)
+	for i := range reduced {
+		num := new(Nat).SetUint64(1)
+		num.Mod(num, p)
+		den := new(Nat).SetNat(num)
+		for j := range reduced {
+			if i == j {
+				continue
+			}
+			num.ModMul(num, diff.ModSub(atModP, reduced[j], p), p)
+			den.ModMul(den, diff.ModSub(reduced[i], reduced[j], p), p)
+		}
+		inv, ok := new(Nat).ModInverseChecked(den, p)
+		if ok != 1 {
+			return nil, errors.New("Lagrange points are not distinct")
+		}
+		coefficients[i] = inv.ModMul(inv, num, p)
+	}
+	return coefficients, nil
+}
+
+// Interpolate calculates the value at a given point of the polynomial passing through some shares.
+//
+// Using at = 0 recovers the secret in Shamir secret sharing. The same conditions as
+// LagrangeCoefficients apply, with the values of the shares not being leaked.
+//
+// The capacity of the resulting number matches the capacity of the modulus.
+func Interpolate(shares []Share, at *Nat, p *Modulus) (*Nat, error) {
+	xs := make([]*Nat, len(shares))
+	for i, share := range shares {
+		xs[i] = share.X
+	}
+	coefficients, err := LagrangeCoefficients(xs, at, p)
+	if err != nil {
+		return nil, err
+	}
+	out := new(Nat).SetUint64(0)
+	out.Mod(out, p)
+	term := new(Nat)
+	for i, share := range shares {
+		term.ModMul(coefficients[i], share.Y, p)
+		out.ModAdd(out, term, p)
+	}
+	return out, nil
+}
@@ -0,0 +1,73 @@
+package saferith
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// evalPolynomial evaluates the polynomial with the given coefficients at x, modulo p
+func evalPolynomial(coefficients []*Nat, x *Nat, p *Modulus) *Nat {
+	out := new(Nat).SetUint64(0)
+	out.Mod(out, p)
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		out.ModMul(out, x, p)
+		out.ModAdd(out, coefficients[i], p)
+	}
+	return out
+}
+
+func TestInterpolateRecoversSecret(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	p, _ := ModulusFromHex("FFFFFFFF00000001000000000000000000000000FFFFFFFFFFFFFFFFFFFFFFFF")
+	for threshold := 1; threshold < 6; threshold++ {
+		coefficients := make([]*Nat, threshold)
+		for i := range coefficients {
+			c, err := new(Nat).Rand(p, r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			coefficients[i] = c
+		}
+		shares := make([]Share, threshold)
+		for i := range shares {
+			x := new(Nat).SetUint64(uint64(3*i + 1))
+			shares[i] = Share{X: x, Y: evalPolynomial(coefficients, x, p)}
+		}
+		secret, err := Interpolate(shares, new(Nat).SetUint64(0), p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !secret.checkInvariants() || secret.Eq(coefficients[0]) != 1 {
+			t.Errorf("%+v != %+v", coefficients[0], secret)
+		}
+		// Interpolating at another point should give the value of the polynomial there
+		at := new(Nat).SetUint64(1000)
+		actual, err := Interpolate(shares, at, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := evalPolynomial(coefficients, at, p); actual.Eq(expected) != 1 {
+			t.Errorf("%+v != %+v", expected, actual)
+		}
+	}
+}
+
+func TestLagrangeCoefficientsExamples(t *testing.T) {
+	p := ModulusFromUint64(13)
+	xs := []*Nat{new(Nat).SetUint64(1), new(Nat).SetUint64(2)}
+	// At 0: 2 / (2 - 1) = 2, and 1 / (1 - 2) = -1 = 12
+	coefficients, err := LagrangeCoefficients(xs, new(Nat).SetUint64(0), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, expected := range []uint64{2, 12} {
+		if coefficients[i].Eq(new(Nat).SetUint64(expected)) != 1 {
+			t.Errorf("%+v != %+v", expected, coefficients[i])
+		}
+	}
+	// 14 = 1 mod 13, so these points aren't distinct
+	xs = append(xs, new(Nat).SetUint64(14))
+	if _, err := LagrangeCoefficients(xs, new(Nat).SetUint64(0), p); err == nil {
+		t.Errorf("expected error for duplicate points")
+	}
+}
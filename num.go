@@ -1,10 +1,14 @@
 package saferith
 
 import (
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
 	"math/big"
 	"math/bits"
 	"strings"
+	"sync/atomic"
 )
 
 // General utilities
@@ -36,6 +40,53 @@ func add(a, b, carry Word) (sum Word, newCarry Word) {
 //     !a     => 1 ^ a
 type Choice Word
 
+// ChoiceFromBool converts a bool into a Choice.
+//
+// Go doesn't guarantee that converting a bool is free of branches, so this should
+// only be used for bools which aren't secret, at the boundary with other code.
+func ChoiceFromBool(b bool) Choice {
+	var c Choice
+	if b {
+		c = 1
+	}
+	return c
+}
+
+// Bool converts this Choice into a bool.
+//
+// This is the same as c == 1. Branching on the result will naturally leak its
+// value, so this should only be used once a decision can be made public.
+func (c Choice) Bool() bool {
+	return c == 1
+}
+
+// Not returns the negation of this Choice, i.e. 1 ^ c.
+func (c Choice) Not() Choice {
+	return 1 ^ c
+}
+
+// And returns 1 if both c and d are 1, i.e. c & d.
+func (c Choice) And(d Choice) Choice {
+	return c & d
+}
+
+// Or returns 1 if either of c or d is 1, i.e. c | d.
+func (c Choice) Or(d Choice) Choice {
+	return c | d
+}
+
+// Xor returns 1 if exactly one of c or d is 1, i.e. c ^ d.
+func (c Choice) Xor(d Choice) Choice {
+	return c ^ d
+}
+
+// Select returns x if c = 1, and y otherwise.
+//
+// This doesn't leak which value was selected.
+func (c Choice) Select(x, y Word) Word {
+	return ctIfElse(c, x, y)
+}
+
 // ctEq compares x and y for equality, returning 1 if equal, and 0 otherwise
 //
 // This doesn't leak any information about either of them
@@ -76,8 +127,15 @@ func ctCondCopy(v Choice, x, y []Word) {
 	if len(x) != len(y) {
 		panic("ctCondCopy: mismatched arguments")
 	}
+	condCopyVV(Word(v), x, y)
+}
+
+// condCopyVV_g is the generic implementation of ctCondCopy, for when no assembly is available
+//
+// x and y must have the same length, which is checked by ctCondCopy.
+func condCopyVV_g(v Word, x, y []Word) {
 	for i := 0; i < len(x); i++ {
-		x[i] = ctIfElse(v, y[i], x[i])
+		x[i] = ctIfElse(Choice(v), y[i], x[i])
 	}
 }
 
@@ -104,7 +162,7 @@ func ctCondSwap(v Choice, a, b []Word) {
 func (z *Nat) CondAssign(yes Choice, x *Nat) *Nat {
 	maxBits := z.maxAnnounced(x)
 
-	xLimbs := x.resizedLimbs(maxBits)
+	xLimbs := x.extendedLimbs(maxBits)
 	z.limbs = z.resizedLimbs(maxBits)
 
 	ctCondCopy(yes, z.limbs, xLimbs)
@@ -119,6 +177,54 @@ func (z *Nat) CondAssign(yes Choice, x *Nat) *Nat {
 	return z
 }
 
+// CondSwap swaps the values of z and x if yes = 1, and does nothing otherwise.
+//
+// This function doesn't leak any information about whether the swap happened.
+//
+// The announced size of both numbers will be the largest size between z and x.
+func (z *Nat) CondSwap(yes Choice, x *Nat) {
+	maxBits := z.maxAnnounced(x)
+
+	z.limbs = z.resizedLimbs(maxBits)
+	x.limbs = x.resizedLimbs(maxBits)
+
+	ctCondSwap(yes, z.limbs, x.limbs)
+
+	// Like in CondAssign, we can't keep track of differing reductions
+	if z.reduced != x.reduced {
+		z.reduced = nil
+		x.reduced = nil
+	}
+	z.announced = maxBits
+	x.announced = maxBits
+}
+
+// Select sets z <- yes ? x : y, returning z.
+//
+// This function doesn't leak any information about which value was selected.
+// z is allowed to alias x or y.
+//
+// The announced size of the result will be the largest size between x and y.
+func (z *Nat) Select(yes Choice, x *Nat, y *Nat) *Nat {
+	maxBits := x.maxAnnounced(y)
+
+	xLimbs := x.extendedLimbs(maxBits)
+	yLimbs := y.extendedLimbs(maxBits)
+	reduced := x.reduced
+	if x.reduced != y.reduced {
+		reduced = nil
+	}
+	z.limbs = z.resizedLimbs(maxBits)
+
+	for i := 0; i < len(z.limbs); i++ {
+		z.limbs[i] = ctIfElse(yes, xLimbs[i], yLimbs[i])
+	}
+	z.reduced = reduced
+	z.announced = maxBits
+
+	return z
+}
+
 // "Missing" Functions
 // These are routines that could in theory be implemented in assembly,
 // but aren't already present in Go's big number routines
@@ -253,6 +359,24 @@ func (z *Nat) resizedLimbs(bits int) []Word {
 	return res
 }
 
+// extendedLimbs returns the limbs of z, truncated or zero extended to accommodate a number of bits.
+//
+// Unlike resizedLimbs, this never modifies z, and doesn't mask off the bits past
+// the end, which makes it suitable for reading inputs, in operations where these
+// extra bits don't matter. The limbs are only copied if z has too few of them.
+//
+// LEAK: the current number of limbs, and bits
+// OK: both are public
+func (z *Nat) extendedLimbs(bits int) []Word {
+	size := limbCount(bits)
+	if size <= len(z.limbs) {
+		return z.limbs[:size]
+	}
+	res := make([]Word, size)
+	copy(res, z.limbs)
+	return res
+}
+
 // maskEnd applies the correct bit mask to some limbs
 func maskEnd(limbs []Word, bits int) {
 	if len(limbs) <= 0 {
@@ -309,10 +433,26 @@ func (z *Nat) TrueLen() int {
 	return size
 }
 
+// Normalize shrinks the announced length of z to its true length, returning z.
+//
+// Like TrueLen, this violates the standard contract around announced lengths,
+// since the new announced length depends on the value of z, and is leaked by
+// every subsequent operation. This should only be used on public values, or
+// when deliberately choosing to reveal the size of a number.
+func (z *Nat) Normalize() *Nat {
+	z.Resize(z.TrueLen())
+	z.reduced = nil
+	return z
+}
+
 // FillBytes writes out the big endian bytes of a natural number.
 //
 // This will always write out the full capacity of the number, without
-// any kind trimming.
+// any kind trimming. If buf is longer than necessary, the most significant bytes
+// are filled with zeros. If buf is too short, unlike big.Int.FillBytes, this
+// doesn't panic, and instead truncates the most significant bytes of the number.
+//
+// This doesn't allocate, and only leaks the length of buf, and the announced length of z.
 func (z *Nat) FillBytes(buf []byte) []byte {
 	for i := 0; i < len(buf); i++ {
 		buf[i] = 0
@@ -358,6 +498,162 @@ func (z *Nat) SetBytes(buf []byte) *Nat {
 	return z
 }
 
+// FillBytesLE writes out the little endian bytes of a natural number.
+//
+// This is like FillBytes, except that the least significant byte comes first.
+// The remainder of buf will be filled with zeros, and if buf is too short,
+// the most significant bytes will be truncated.
+func (z *Nat) FillBytesLE(buf []byte) []byte {
+	for i := 0; i < len(buf); i++ {
+		buf[i] = 0
+	}
+
+	i := 0
+	// LEAK: Number of limbs
+	// OK: The number of limbs is public
+	// LEAK: The addresses touched in the out array
+	// OK: Every member of out is touched
+Outer:
+	for _, x := range z.limbs {
+		y := x
+		for j := 0; j < _S; j++ {
+			if i >= len(buf) {
+				break Outer
+			}
+			buf[i] = byte(y)
+			y >>= 8
+			i++
+		}
+	}
+	return buf
+}
+
+// SetBytesLE interprets a number in little-endian format, stores it in z, and returns z.
+//
+// The same rules as SetBytes apply, in particular, the length of the buffer dictates
+// the capacity of the result, and should be public.
+func (z *Nat) SetBytesLE(buf []byte) *Nat {
+	z.reduced = nil
+	z.announced = 8 * len(buf)
+	z.limbs = z.resizedLimbs(z.announced)
+	bufI := 0
+	for i := 0; i < len(z.limbs) && bufI < len(buf); i++ {
+		z.limbs[i] = 0
+		for shift := 0; shift < _W && bufI < len(buf); shift += 8 {
+			z.limbs[i] |= Word(buf[bufI]) << shift
+			bufI++
+		}
+	}
+	return z
+}
+
+// BytesLE creates a slice containing the contents of this Nat, in little endian
+//
+// Like Bytes, the length of the output depends only on the announced length of this Nat.
+func (z *Nat) BytesLE() []byte {
+	length := (z.announced + 7) / 8
+	out := make([]byte, length)
+	return z.FillBytesLE(out)
+}
+
+// SetBytesFromReader reads exactly n bytes from r, and interprets them as a number
+// in big-endian format, storing the result in z, and returning z.
+//
+// This behaves like calling SetBytes on the n bytes read, including for the
+// capacity of the result, but reads directly into the limbs of z, without
+// allocating a buffer to hold all of the bytes.
+//
+// If fewer than n bytes can be read, an error is returned, and the value of z is undefined.
+func (z *Nat) SetBytesFromReader(r io.Reader, n int) (*Nat, error) {
+	if n < 0 {
+		return nil, errors.New("negative number of bytes")
+	}
+	z.reduced = nil
+	z.announced = 8 * n
+	z.limbs = z.resizedLimbs(z.announced)
+	for i := 0; i < len(z.limbs); i++ {
+		z.limbs[i] = 0
+	}
+	var chunk [256]byte
+	// The position of the next byte, counting from the least significant end
+	remaining := n
+	for remaining > 0 {
+		buf := chunk[:]
+		if remaining < len(buf) {
+			buf = buf[:remaining]
+		}
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		for _, b := range buf {
+			remaining--
+			z.limbs[remaining/_S] |= Word(b) << (8 * (remaining % _S))
+		}
+	}
+	return z, nil
+}
+
+// Rand sets z to a uniformly random number in [0, m), reading randomness from r
+//
+// This uses rejection sampling, drawing numbers with the same bit length as m,
+// until one of them is smaller than m. This needs fewer than 2 attempts on average,
+// and the number of attempts doesn't leak anything about the value returned.
+//
+// The capacity of the resulting number matches the capacity of the modulus.
+//
+// If reading from r fails, an error is returned, and the value of z is undefined.
+func (z *Nat) Rand(m *Modulus, r io.Reader) (*Nat, error) {
+	for {
+		if _, err := z.SetBytesFromReader(r, (m.nat.announced+7)/8); err != nil {
+			return nil, err
+		}
+		z.Resize(m.nat.announced)
+		if _, _, lt := z.CmpMod(m); lt == 1 {
+			z.reduced = m
+			return z, nil
+		}
+	}
+}
+
+// RandBits sets z to a uniformly random number in [0, 2^bits), reading randomness from r
+//
+// The capacity of the resulting number is exactly bits. Note that the number itself
+// may be smaller, since its top bits can be zero.
+//
+// If reading from r fails, an error is returned, and the value of z is undefined.
+func (z *Nat) RandBits(bits int, r io.Reader) (*Nat, error) {
+	if bits < 0 {
+		return nil, errors.New("negative number of bits")
+	}
+	if _, err := z.SetBytesFromReader(r, (bits+7)/8); err != nil {
+		return nil, err
+	}
+	return z.Resize(bits), nil
+}
+
+// RandRange sets z to a uniformly random number in [lo, hi], reading randomness from r
+//
+// Both ends of the range are inclusive, so RandRange(2, p - 2) samples a non-trivial
+// element modulo p. The capacity of the resulting number is that of hi.
+//
+// The size of the range, hi - lo, is assumed to be public, and its true size is leaked.
+// An error is returned if lo > hi, or reading from r fails.
+func (z *Nat) RandRange(lo *Nat, hi *Nat, r io.Reader) (*Nat, error) {
+	if _, _, lt := hi.Cmp(lo); lt == 1 {
+		return nil, errors.New("empty range")
+	}
+	cap := hi.maxAnnounced(lo) + 1
+	width := new(Nat).Sub(hi, lo, cap)
+	width.Add(width, new(Nat).SetUint64(1), cap)
+	// z might alias lo or hi, so we need to save these values
+	lo = new(Nat).SetNat(lo)
+	outCap := hi.announced
+	if _, err := z.Rand(ModulusFromNat(width), r); err != nil {
+		return nil, err
+	}
+	return z.Add(z, lo, outCap), nil
+}
+
 // Bytes creates a slice containing the contents of this Nat, in big endian
 //
 // This will always fill the output byte slice based on the announced length of this Nat.
@@ -367,16 +663,69 @@ func (z *Nat) Bytes() []byte {
 	return z.FillBytes(out)
 }
 
+// binaryVersion is the first byte of every binary encoding we produce.
+//
+// This allows us to change the format later, while still recognizing old data.
+const binaryVersion = 1
+
+// appendBinary appends the announced length of z, as 4 big endian bytes, followed
+// by the contents of z.Bytes(), to out.
+func (z *Nat) appendBinary(out []byte) []byte {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(z.announced))
+	out = append(out, length[:]...)
+	return append(out, z.Bytes()...)
+}
+
+// parseBinary reads the output of appendBinary into z, returning the remaining data.
+//
+// This will leak whether or not the data is valid, but nothing more about its value.
+func (z *Nat) parseBinary(data []byte) ([]byte, error) {
+	if len(data) < 4 {
+		return nil, errors.New("binary data is missing a length")
+	}
+	announced := binary.BigEndian.Uint32(data)
+	data = data[4:]
+	byteLen := (uint64(announced) + 7) / 8
+	if uint64(len(data)) < byteLen {
+		return nil, fmt.Errorf("binary data too short for %d bits", announced)
+	}
+	z.SetBytes(data[:byteLen])
+	// The bits past the announced length must all be clear
+	excessBits := 8*byteLen - uint64(announced)
+	if excessBits > 0 && data[0]>>(8-excessBits) != 0 {
+		return nil, fmt.Errorf("binary data has bits set past %d bits", announced)
+	}
+	z.Resize(int(announced))
+	return data[byteLen:], nil
+}
+
 // MarshalBinary implements encoding.BinaryMarshaler.
-// Returns the same value as Bytes().
+//
+// The encoding consists of a version byte, followed by the announced length
+// of this Nat, as 4 big endian bytes, followed by the output of Bytes().
+// This means that the announced length survives a round trip.
 func (i *Nat) MarshalBinary() ([]byte, error) {
-	return i.Bytes(), nil
+	out := make([]byte, 0, 5+(i.announced+7)/8)
+	out = append(out, binaryVersion)
+	return i.appendBinary(out), nil
 }
 
 // UnmarshalBinary implements encoding.BinaryUnmarshaler.
-// Wraps SetBytes
+//
+// This expects the format produced by MarshalBinary, returning an error if the
+// data is malformed, or has an unknown version.
 func (i *Nat) UnmarshalBinary(data []byte) error {
-	i.SetBytes(data)
+	if len(data) < 1 || data[0] != binaryVersion {
+		return errors.New("unknown binary encoding version")
+	}
+	rest, err := i.parseBinary(data[1:])
+	if err != nil {
+		return err
+	}
+	if len(rest) != 0 {
+		return errors.New("trailing binary data")
+	}
 	return nil
 }
 
@@ -424,6 +773,37 @@ func (z *Nat) SetHex(hex string) (*Nat, error) {
 	return z, nil
 }
 
+// SetDecimal modifies the value of z to hold a decimal string, returning z
+//
+// The string must be non-empty, and contain only the characters 0..9, otherwise
+// the value of z will be undefined, and an error will be returned.
+//
+// The capacity of the result is the number of bits needed to hold any decimal
+// number with this many digits, so it only depends on the length of the string.
+//
+// This is intended for parsing public constants, so the value of the string
+// might be leaked, although the parsing itself doesn't branch on the digits.
+func (z *Nat) SetDecimal(dec string) (*Nat, error) {
+	if len(dec) == 0 {
+		return nil, errors.New("empty decimal string")
+	}
+	z.reduced = nil
+	// 3.322 is slightly larger than log2(10)
+	z.announced = (3322*len(dec) + 999) / 1000
+	z.limbs = z.resizedLimbs(z.announced)
+	for i := 0; i < len(z.limbs); i++ {
+		z.limbs[i] = 0
+	}
+	for i := 0; i < len(dec); i++ {
+		digit := Word(dec[i]) - Word('0')
+		if ctGt(digit, 9) == 1 {
+			return nil, fmt.Errorf("invalid decimal character: %c", dec[i])
+		}
+		mulAddVWW(z.limbs, z.limbs, 10, digit)
+	}
+	return z, nil
+}
+
 // Hex converts this number into a hexadecimal string.
 //
 // This string will be a multiple of 8 bits.
@@ -439,6 +819,42 @@ func (z *Nat) Hex() string {
 	return builder.String()
 }
 
+// hexNibbles is like Hex, but produces exactly enough hex digits to hold the announced length.
+//
+// In other words, the string will hold a multiple of 4 bits, rather than 8.
+func (z *Nat) hexNibbles() string {
+	hex := z.Hex()
+	// LEAK: the announced length
+	// OK: this is public
+	return hex[len(hex)-(z.announced+3)/4:]
+}
+
+// MarshalJSON implements json.Marshaler.
+//
+// The value is encoded as a JSON string, holding the same hex digits as Hex,
+// except that the number of digits is ceil(announced / 4). Parsing this string
+// with SetHex thus recovers the announced length, when it's a multiple of 4.
+//
+// This shouldn't leak any information about the value of this Nat, only its length.
+func (z *Nat) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + z.hexNibbles() + `"`), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// This accepts a JSON string containing hex digits, following the same rules
+// as SetHex. The JSON value null leaves z unchanged.
+func (z *Nat) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) < 2 || data[0] != '"' || data[len(data)-1] != '"' {
+		return errors.New("expected a JSON string")
+	}
+	_, err := z.SetHex(string(data[1 : len(data)-1]))
+	return err
+}
+
 // the number of bytes to print in the string representation before an underscore
 const underscoreAfterNBytes = 4
 
@@ -463,6 +879,37 @@ func (z *Nat) String() string {
 	return builder.String()
 }
 
+// formatString writes str to s, respecting the width and '-' flag of s.
+func formatString(s fmt.State, str string) {
+	if width, ok := s.Width(); ok && len(str) < width {
+		padding := strings.Repeat(" ", width-len(str))
+		if s.Flag('-') {
+			str += padding
+		} else {
+			str = padding + str
+		}
+	}
+	_, _ = io.WriteString(s, str)
+}
+
+// Format implements fmt.Formatter.
+//
+// The verbs %v and %s produce the same output as String. The verbs %b, %o, %O,
+// %d, %x, and %X format the value of this number, supporting the same flags
+// as big.Int, such as width, zero padding, and '#' for a base prefix.
+//
+// Formatting with a numeric verb goes through big.Int, and will thus leak
+// the true size of this number. This is intended for debugging, and shouldn't
+// be used with sensitive values.
+func (z *Nat) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v', 's':
+		formatString(s, z.String())
+	default:
+		z.Big().Format(s, verb)
+	}
+}
+
 // Byte will access the ith byte in this nat, with 0 being the least significant byte.
 //
 // This will leak the value of i, and panic if i is < 0.
@@ -478,10 +925,27 @@ func (z *Nat) Byte(i int) byte {
 	return byte(z.limbs[i/bytesPerLimb] >> (8 * (i % bytesPerLimb)))
 }
 
+// Bit returns the ith bit of this Nat, with 0 being the least significant bit.
+//
+// This will leak the value of i, and panic if i is < 0. Bits past the end
+// of the limbs are 0.
+func (z *Nat) Bit(i int) Choice {
+	if i < 0 {
+		panic("negative bit")
+	}
+	limb := i >> _WShift
+	if limb >= len(z.limbs) {
+		return 0
+	}
+	return Choice((z.limbs[limb] >> (i & _WMask)) & 1)
+}
+
 // Big converts a Nat into a big.Int
 //
 // This will leak information about the true size of z, so caution
 // should be exercised when using this method with sensitive values.
+// Furthermore, the operations of big.Int are not constant-time, so any further
+// use of the result will leak information about its value.
 func (z *Nat) Big() *big.Int {
 	res := new(big.Int)
 	// Unfortunate that there's no good way to handle this
@@ -496,7 +960,14 @@ func (z *Nat) Big() *big.Int {
 // SetBig modifies z to contain the value of x
 //
 // The size parameter is used to pad or truncate z to a certain number of bits.
+// This becomes the announced length of z, regardless of the true size of x.
+//
+// Only the absolute value of x is used, since Nat can't hold negative numbers.
+//
+// The conversion itself only leaks the size of x's internal representation, which
+// big.Int trims to its true size.
 func (z *Nat) SetBig(x *big.Int, size int) *Nat {
+	z.reduced = nil
 	z.announced = size
 	z.limbs = z.resizedLimbs(size)
 	bigLimbs := x.Bits()
@@ -516,7 +987,9 @@ func (z *Nat) SetUint64(x uint64) *Nat {
 	z.limbs = z.resizedLimbs(z.announced)
 	for i := 0; i < len(z.limbs); i++ {
 		z.limbs[i] = Word(x)
-		x >>= _W
+		// Shifting by _W all at once would be an oversized shift on 64 bit platforms
+		x >>= _W - 1
+		x >>= 1
 	}
 	return z
 }
@@ -527,7 +1000,8 @@ func (z *Nat) SetUint64(x uint64) *Nat {
 func (z *Nat) Uint64() uint64 {
 	var ret uint64
 	for i := len(z.limbs) - 1; i >= 0; i-- {
-		ret = (ret << _W) | uint64(z.limbs[i])
+		// See SetUint64 for why this shift is split in two
+		ret = (ret << (_W - 1) << 1) | uint64(z.limbs[i])
 	}
 	return ret
 }
@@ -557,6 +1031,30 @@ func (z *Nat) Resize(cap int) *Nat {
 	return z
 }
 
+// clearLimbs overwrites the entire backing array of limbs with zeros.
+//
+// This includes the capacity beyond the length of the slice, which may
+// still contain values from before the slice was truncated.
+func clearLimbs(limbs []Word) {
+	limbs = limbs[:cap(limbs)]
+	for i := 0; i < len(limbs); i++ {
+		limbs[i] = 0
+	}
+}
+
+// Clear overwrites z with zeros, returning z.
+//
+// This clears all of the limbs backing z, and not just the ones currently in use,
+// and sets the announced length of z to 0. This is useful for removing secret
+// values from memory once they're no longer needed.
+func (z *Nat) Clear() *Nat {
+	clearLimbs(z.limbs)
+	z.limbs = z.limbs[:0]
+	z.announced = 0
+	z.reduced = nil
+	return z
+}
+
 // Modulus represents a natural number used for modular reduction
 //
 // Unlike with natural numbers, the number of bits need to contain the modulus
@@ -564,12 +1062,27 @@ func (z *Nat) Resize(cap int) *Nat {
 // a modulus will remove unnecessary zeros.
 //
 // Operations on a Modulus may leak whether or not a Modulus is even.
+//
+// Most operations work for any modulus, using Montgomery multiplication for odd
+// moduli, and Barrett reduction for even moduli. This includes ModMul, Exp, and
+// ModInverse. The exceptions are operations which only make sense for odd primes,
+// or odd moduli in general: ModSqrt only accepts the even prime 2, while Jacobi
+// and NewField reject even moduli. IsOdd can be used to check for this in advance.
 type Modulus struct {
 	nat Nat
 	// the number of leading zero bits
 	leading int
 	// The inverse of the least significant limb, modulo W
 	m0inv Word
+	// The constants used for fast modular reduction, holding a *modulusReduction.
+	//
+	// These are calculated on first use, see Modulus.reduction.
+	precomputed atomic.Value
+	// If not nil, then m = 2^k - c, with k = m.BitLen(), and c small enough to allow
+	// for pseudo-Mersenne reduction.
+	//
+	// This is only set when creating a modulus with ModulusNearPowerOfTwo.
+	c []Word
 	// If true, then this modulus is even
 	even bool
 }
@@ -605,6 +1118,101 @@ func (m *Modulus) precomputeValues() {
 		m.m0inv = invertModW(m.nat.limbs[0])
 		m.m0inv = -m.m0inv
 	}
+	m.precomputeReduction()
+}
+
+// modulusReduction holds the constants needed for fast modular reduction.
+//
+// Once calculated, these are never modified, which allows sharing them between
+// goroutines using the same modulus.
+type modulusReduction struct {
+	// R^2 mod m, with R = 2^(_W * len(limbs)), used to convert into Montgomery form.
+	//
+	// This is only set when the modulus is odd.
+	rr []Word
+	// floor(B^(2n) / m), with B = 2^_W and n = len(limbs), used for Barrett reduction.
+	//
+	// This is only set when the modulus is even. When m = B^(n - 1), this would be
+	// B^(n + 1), which doesn't fit in n + 1 limbs, so we use B^(n + 1) - 1 instead.
+	mu []Word
+}
+
+// precomputeReduction resets the constants needed for fast modular reduction.
+//
+// Instead of being calculated immediately, these will be calculated on first use.
+// This makes creating a modulus cheap, which is useful for constants at startup.
+func (m *Modulus) precomputeReduction() {
+	m.precomputed.Store((*modulusReduction)(nil))
+	m.c = nil
+}
+
+// reduction returns the constants needed for fast modular reduction, calculating them if necessary.
+//
+// For odd moduli, this is R^2 mod m, used for Montgomery multiplication,
+// and for even moduli, this is the Barrett constant instead.
+//
+// This is safe to call from multiple goroutines. They might end up calculating
+// the constants concurrently, but the results are identical.
+func (m *Modulus) reduction() *modulusReduction {
+	r, _ := m.precomputed.Load().(*modulusReduction)
+	if r != nil {
+		return r
+	}
+	r = new(modulusReduction)
+	if m.even {
+		r.mu = m.calculateMu()
+	} else {
+		r.rr = m.calculateRR()
+	}
+	m.precomputed.Store(r)
+	return r
+}
+
+// Precompute calculates the constants used for fast modular reduction.
+//
+// Normally, these are calculated the first time they're needed. Calling this
+// method is useful to avoid paying this cost in the middle of some other operation.
+//
+// Like other operations on a Modulus, this is safe to call concurrently.
+func (m *Modulus) Precompute() {
+	m.reduction()
+}
+
+// calculateRR calculates R^2 mod m, allowing for fast conversion into Montgomery form.
+//
+// This should only be called for odd moduli, and leaks only the size of the modulus.
+func (m *Modulus) calculateRR() []Word {
+	size := len(m.nat.limbs)
+	rr := make([]Word, size)
+	scratch := make([]Word, size)
+	// We can't necessarily start with 1, since m might be 1.
+	rr[0] = Word(1 ^ cmpEq(m.nat.limbs, rr))
+	// Each call multiplies by R, so doing this twice gives us R^2
+	montgomeryRepresentation(rr, scratch, m)
+	montgomeryRepresentation(rr, scratch, m)
+	return rr
+}
+
+// calculateMu calculates floor(B^(2n) / m), allowing for Barrett reduction.
+//
+// If m = B^(n - 1), this result doesn't fit in n + 1 limbs, and we saturate
+// to B^(n + 1) - 1 instead. This makes the quotient estimated by barrettReduce
+// smaller by at most 1, which it accounts for.
+//
+// This leaks only the size of the modulus.
+func (m *Modulus) calculateMu() []Word {
+	size := len(m.nat.limbs)
+	var x Nat
+	x.announced = 2*size*_W + 1
+	x.limbs = make([]Word, 2*size+1)
+	x.limbs[2*size] = 1
+	// Since the top limb of m is not zero, the quotient fits in size + 2 limbs
+	mu := new(Nat).Div(&x, m, (size+2)*_W).limbs
+	overflow := 1 ^ ctEq(mu[size+1], 0)
+	for i := 0; i <= size; i++ {
+		mu[i] = ctIfElse(overflow, ^Word(0), mu[i])
+	}
+	return mu[:size+1]
 }
 
 // ModulusFromUint64 sets the modulus according to an integer
@@ -643,6 +1251,17 @@ func ModulusFromHex(hex string) (*Modulus, error) {
 	return &m, nil
 }
 
+// ModulusFromBig creates a new Modulus, using the absolute value of a big.Int
+//
+// This will remove leading zeros, thus leaking the true size of the modulus.
+// See the documentation for the Modulus type, for more information about this contract.
+func ModulusFromBig(x *big.Int) *Modulus {
+	var m Modulus
+	m.nat.SetBig(x, x.BitLen())
+	m.precomputeValues()
+	return &m
+}
+
 // FromNat creates a new Modulus, using the value of a Nat
 //
 // This will leak the true size of this natural number. Because of this,
@@ -655,10 +1274,84 @@ func ModulusFromNat(nat *Nat) *Modulus {
 	return &m
 }
 
-// Nat returns the value of this modulus as a Nat.
+// ModulusNearPowerOfTwo creates a new Modulus with the value 2^k - c
 //
-// This will create a copy of this modulus value, so the Nat can be safely
-// mutated.
+// Moduli of this form, also called pseudo-Mersenne numbers, allow for fast reduction,
+// by folding the bits past 2^k back in, after multiplying them by c. Modular multiplication
+// and squaring will use this reduction, which is faster than the generic methods.
+// For example, ModulusNearPowerOfTwo(255, 19) is the prime used by Curve25519.
+//
+// c must be non-zero, and at most k / 2 - 1 bits long, otherwise this function panics.
+// Both k and c are leaked, which is fine, since moduli of this form are public constants.
+func ModulusNearPowerOfTwo(k int, c uint64) *Modulus {
+	if c == 0 || bits.Len64(c) > k/2-1 {
+		panic("ModulusNearPowerOfTwo: c is too large")
+	}
+	cNat := new(Nat).SetUint64(c)
+	cNat.Resize(bits.Len64(c))
+	var m Modulus
+	m.nat.SetBit(new(Nat), k, 1)
+	m.nat.Sub(&m.nat, cNat, k)
+	m.precomputeValues()
+	m.c = cNat.limbs
+	return &m
+}
+
+// Square returns a new modulus, with the value m^2.
+//
+// This is useful for schemes like Paillier, which work modulo n^2. The Montgomery
+// constant is derived from that of m, rather than being calculated from scratch.
+//
+// Like other ways of creating a modulus, this will leak the true size of m^2.
+func (m *Modulus) Square() *Modulus {
+	var out Modulus
+	out.nat.Mul(&m.nat, &m.nat, 2*m.nat.announced)
+	announced := out.nat.TrueLen()
+	out.nat.Resize(announced)
+	out.leading = leadingZeros(out.nat.limbs[len(out.nat.limbs)-1])
+	out.even = m.even
+	if !m.even {
+		// m0inv = -m^-1, so (m^2)^-1 = m0inv^2, modulo W
+		out.m0inv = -(m.m0inv * m.m0inv)
+	}
+	out.precomputeReduction()
+	return &out
+}
+
+// PaillierL calculates z <- L(x) = (x - 1) / n.
+//
+// This function shows up in Paillier decryption, where x will be 1 mod n,
+// and less than n^2. The result is then less than n. This only leaks the
+// announced size of x, and the size of n.
+//
+// The capacity of the resulting number matches the capacity of n.
+func (z *Nat) PaillierL(x *Nat, n *Modulus) *Nat {
+	xMinus1 := new(Nat).Sub(x, new(Nat).SetUint64(1), x.announced)
+	return z.Div(xMinus1, n, n.nat.announced)
+}
+
+// Clear overwrites m with zeros, including the values precomputed for reduction.
+//
+// This is useful for moduli which are secret, like the factors of an RSA modulus.
+// After calling Clear, m should no longer be used, until it's set to a new value,
+// e.g. with UnmarshalBinary.
+func (m *Modulus) Clear() {
+	m.nat.Clear()
+	if r, _ := m.precomputed.Load().(*modulusReduction); r != nil {
+		clearLimbs(r.rr)
+		clearLimbs(r.mu)
+	}
+	clearLimbs(m.c)
+	m.precomputeReduction()
+	m.leading = 0
+	m.m0inv = 0
+	m.even = false
+}
+
+// Nat returns the value of this modulus as a Nat.
+//
+// This will create a copy of this modulus value, so the Nat can be safely
+// mutated.
 func (m *Modulus) Nat() *Nat {
 	return new(Nat).SetNat(&m.nat)
 }
@@ -669,17 +1362,148 @@ func (m *Modulus) Bytes() []byte {
 }
 
 // MarshalBinary implements encoding.BinaryMarshaler.
+//
+// Like with Nat, the encoding starts with a version byte, and the announced length
+// of the modulus. This is followed by the precomputed Montgomery constant for
+// this modulus, as 8 big endian bytes, which is zero for even moduli.
+//
+// Finally, the constant used for reduction (see Precompute) is included, calculating
+// it if necessary. Since this depends on the size of a Word, it's preceded by a byte
+// holding that size, and then encoded as big endian Words.
 func (i *Modulus) MarshalBinary() ([]byte, error) {
-	return i.nat.Bytes(), nil
+	r := i.reduction()
+	constant := r.rr
+	if i.even {
+		constant = r.mu
+	}
+	wordBytes := _W / 8
+	out := make([]byte, 0, 14+(i.nat.announced+7)/8+wordBytes*len(constant))
+	out = append(out, binaryVersion)
+	out = i.nat.appendBinary(out)
+	var m0inv [8]byte
+	binary.BigEndian.PutUint64(m0inv[:], uint64(i.m0inv))
+	out = append(out, m0inv[:]...)
+	out = append(out, byte(wordBytes))
+	for j := len(constant) - 1; j >= 0; j-- {
+		for k := wordBytes - 1; k >= 0; k-- {
+			out = append(out, byte(constant[j]>>(8*k)))
+		}
+	}
+	return out, nil
 }
 
 // UnmarshalBinary implements encoding.BinaryUnmarshaler.
+//
+// This expects the format produced by MarshalBinary. The precomputed values
+// are checked for consistency with the modulus, returning an error otherwise.
+//
+// The reduction constant can be omitted, or have been produced on a platform
+// with a different Word size, in which case it will be calculated on first use.
 func (i *Modulus) UnmarshalBinary(data []byte) error {
-	i.nat.SetBytes(data)
-	i.precomputeValues()
+	if len(data) < 1 || data[0] != binaryVersion {
+		return errors.New("unknown binary encoding version")
+	}
+	rest, err := i.nat.parseBinary(data[1:])
+	if err != nil {
+		return err
+	}
+	if len(rest) < 8 {
+		return errors.New("binary data is missing Montgomery constant")
+	}
+	// Moduli are allowed to leak their true size
+	if i.nat.announced <= 0 || i.nat.TrueLen() != i.nat.announced {
+		return errors.New("modulus has leading zeros")
+	}
+	i.leading = leadingZeros(i.nat.limbs[len(i.nat.limbs)-1])
+	i.even = ctEq(i.nat.limbs[0]&1, 0) == 1
+	// On 32 bit platforms, we only need the low half of the constant
+	i.m0inv = Word(binary.BigEndian.Uint64(rest))
+	if !i.even && i.m0inv*i.nat.limbs[0] != ^Word(0) {
+		return errors.New("invalid Montgomery constant")
+	}
+	if i.even && i.m0inv != 0 {
+		return errors.New("invalid Montgomery constant")
+	}
+	i.precomputeReduction()
+	rest = rest[8:]
+	if len(rest) == 0 {
+		return nil
+	}
+	wordBytes := int(rest[0])
+	rest = rest[1:]
+	if wordBytes != 4 && wordBytes != 8 {
+		return errors.New("invalid reduction constant")
+	}
+	// The Barrett constant has one more limb than the modulus
+	constantLen := (i.nat.announced + 8*wordBytes - 1) / (8 * wordBytes)
+	if i.even {
+		constantLen++
+	}
+	if len(rest) != wordBytes*constantLen {
+		return errors.New("invalid reduction constant")
+	}
+	if wordBytes != _W/8 {
+		return nil
+	}
+	constant := make([]Word, constantLen)
+	for j := constantLen - 1; j >= 0; j-- {
+		for k := 0; k < wordBytes; k++ {
+			constant[j] = constant[j]<<8 | Word(rest[0])
+			rest = rest[1:]
+		}
+	}
+	r := new(modulusReduction)
+	if i.even {
+		r.mu = constant
+	} else {
+		r.rr = constant
+	}
+	if r.check(i) != 1 {
+		return errors.New("invalid reduction constant")
+	}
+	i.precomputed.Store(r)
 	return nil
 }
 
+// check returns 1 if these constants are correct for m.
+//
+// This is cheaper than calculating the constants from scratch.
+func (r *modulusReduction) check(m *Modulus) Choice {
+	size := len(m.nat.limbs)
+	if !m.even {
+		// rr must be reduced, and rr / R / R should be 1, or 0 if m = 1
+		scratch := make([]Word, size)
+		if subVV(scratch, r.rr, m.nat.limbs) != 1 {
+			return 0
+		}
+		one := make([]Word, size)
+		one[0] = 1
+		x := make([]Word, size)
+		montgomeryMul(r.rr, one, x, scratch, m)
+		montgomeryMul(x, one, x, scratch, m)
+		one[0] = Word(1 ^ cmpEq(m.nat.limbs, one))
+		return cmpEq(x, one)
+	}
+	// mu = floor(B^(2n) / m) holds exactly when 0 <= B^(2n) - mu * m < m,
+	// and the saturated value for m = B^(n - 1) gives B^(2n) - mu * m = m
+	cap := (2*size + 1) * _W
+	var mu, target Nat
+	mu.announced = (size + 1) * _W
+	mu.limbs = r.mu
+	target.announced = cap
+	target.limbs = make([]Word, 2*size+1)
+	target.limbs[2*size] = 1
+	product := new(Nat).Mul(&mu, &m.nat, cap)
+	_, _, lt := target.Cmp(product)
+	diff := new(Nat).WrappingSub(&target, product, cap)
+	_, eq, small := diff.Cmp(&m.nat)
+	saturated := Choice(1)
+	for i := 0; i < len(r.mu); i++ {
+		saturated &= ctEq(r.mu[i], ^Word(0))
+	}
+	return (1 ^ lt) & (small | (eq & saturated))
+}
+
 // Big returns the value of this Modulus as a big.Int
 func (m *Modulus) Big() *big.Int {
 	return m.nat.Big()
@@ -709,6 +1533,14 @@ func (m *Modulus) BitLen() int {
 	return m.nat.announced
 }
 
+// IsOdd returns 1 if this modulus is odd, and 0 otherwise.
+//
+// Moduli are allowed to leak this value, which decides which algorithms
+// can be used with this modulus.
+func (m *Modulus) IsOdd() Choice {
+	return Choice(m.nat.limbs[0] & 1)
+}
+
 // Cmp compares two moduli, returning results for (>, =, <).
 //
 // This will not leak information about the value of these relations, or the moduli.
@@ -855,16 +1687,163 @@ func shiftAddInGeneric(z, scratch []Word, x Word, m []Word) Word {
 	return shiftAddInCommon(z, scratch, m, hi, a2, a1, b1)
 }
 
+// barrettScratchSize returns the number of limbs of scratch space needed by barrettReduce.
+func barrettScratchSize(size int) int {
+	return 7*size + 5
+}
+
+// barrettReduce calculates out <- x mod m, using Barrett reduction
+//
+// This requires m to have a precomputed Barrett constant, and x to have at most
+// 2 * len(m.nat.limbs) limbs. out should have exactly len(m.nat.limbs) limbs,
+// and is allowed to alias x. scratch needs at least barrettScratchSize(len(m.nat.limbs))
+// limbs, and must not alias out or x.
+//
+// This follows Algorithm 14.42 of the Handbook of Applied Cryptography.
+func barrettReduce(out []Word, x []Word, scratch []Word, m *Modulus) {
+	size := len(m.nat.limbs)
+	scratch = scratch[:barrettScratchSize(size)]
+	for i := 0; i < len(scratch); i++ {
+		scratch[i] = 0
+	}
+	// Zero extend x, so that we can work with a fixed number of limbs.
+	xs := scratch[:2*size]
+	copy(xs, x)
+	// We need m with an extra zero limb, since addMulVVW expects its second
+	// argument to be at least as long as its first.
+	mExt := scratch[2*size : 3*size+1]
+	copy(mExt, m.nat.limbs)
+	// q1 = floor(x / B^(n - 1)), q2 = q1 * mu
+	q1 := xs[size-1:]
+	q2 := scratch[3*size+1 : 5*size+3]
+	mu := m.reduction().mu
+	for i := 0; i < len(q1); i++ {
+		q2[i+size+1] = addMulVVW(q2[i:i+size+1], mu, q1[i])
+	}
+	// q3 = floor(q2 / B^(n + 1)), and then r2 = q3 * m mod B^(n + 1)
+	q3 := q2[size+1:]
+	r := scratch[5*size+3 : 6*size+4]
+	for i := 0; i < len(q3); i++ {
+		addMulVVW(r[i:], mExt, q3[i])
+	}
+	// r = x - r2 mod B^(n + 1), which is guaranteed to be < 4m, with one more
+	// multiple of m than in the Handbook to account for a saturated mu.
+	subVV(r, xs[:size+1], r)
+	sub := scratch[6*size+4:]
+	for i := 0; i < 3; i++ {
+		c := subVV(sub, r, mExt)
+		ctCondCopy(1^Choice(c), r, sub)
+	}
+	copy(out, r[:size])
+}
+
+// pseudoMersenneScratchSize returns the number of limbs of scratch space needed by pseudoMersenneReduce.
+func pseudoMersenneScratchSize(size int) int {
+	return 6*size + 4
+}
+
+// pseudoMersenneFold calculates t <- (t mod 2^k) + (t >> k) * c, using hi as scratch space
+//
+// Since 2^k = c mod m, this doesn't change the value of t modulo m = 2^k - c.
+// hi should have the same length as t, and t must be large enough to hold the result.
+func pseudoMersenneFold(t []Word, hi []Word, k int, c []Word) {
+	shift := k >> _WShift
+	bitShift := uint(k & _WMask)
+	// Only these limbs of hi can be non-zero
+	hiLen := len(t) - shift
+	for i := 0; i < hiLen-1; i++ {
+		// When bitShift = 0, the second shift produces 0, as needed
+		hi[i] = (t[i+shift] >> bitShift) | (t[i+shift+1] << (_W - bitShift))
+	}
+	hi[hiLen-1] = t[len(t)-1] >> bitShift
+	n := limbCount(k)
+	for i := n; i < len(t); i++ {
+		t[i] = 0
+	}
+	t[n-1] &= limbMask(k)
+	for j := 0; j < len(c); j++ {
+		carry := addMulVVW(t[j:j+hiLen], hi[:hiLen], c[j])
+		addVW(t[j+hiLen:], t[j+hiLen:], carry)
+	}
+}
+
+// pseudoMersenneReduce calculates out <- x mod m, for m = 2^k - c
+//
+// This requires m to have been created with ModulusNearPowerOfTwo, and x < 2^(2k).
+// out should have exactly len(m.nat.limbs) limbs, and is allowed to alias x.
+// scratch needs at least pseudoMersenneScratchSize(len(m.nat.limbs)) limbs, and must
+// not alias out or x.
+func pseudoMersenneReduce(out []Word, x []Word, scratch []Word, m *Modulus) {
+	size := len(m.nat.limbs)
+	k := m.nat.announced
+	t := scratch[:2*size+1]
+	hi := scratch[2*size+1 : 4*size+2]
+	for i := 0; i < len(t); i++ {
+		t[i] = 0
+	}
+	copy(t, x)
+	// With x < 2^(2k), the first fold gives us t < 2^k (c + 1), and the second
+	// t < 2^k + c^2, which is < 2m, since c is small enough.
+	pseudoMersenneFold(t, hi, k, m.c)
+	// After the first fold, we only need to look at the limbs that can be non-zero
+	pseudoMersenneFold(t[:size+len(m.c)+1], hi, k, m.c)
+	mExt := scratch[4*size+2 : 5*size+3]
+	mExt[size] = 0
+	copy(mExt, m.nat.limbs)
+	sub := scratch[5*size+3 : 6*size+4]
+	c := subVV(sub, t[:size+1], mExt)
+	ctCondCopy(1^Choice(c), t[:size+1], sub)
+	copy(out, t[:size])
+}
+
 // Mod calculates z <- x mod m
 //
 // The capacity of the resulting number matches the capacity of the modulus.
 func (z *Nat) Mod(x *Nat, m *Modulus) *Nat {
+	return z.mod(x, m, nil)
+}
+
+// mod calculates z <- x mod m, like Mod.
+//
+// For even and pseudo-Mersenne moduli, scratch is used for reduction, and should
+// either be nil, or contain at least barrettScratchSize(len(m.nat.limbs)) limbs.
+func (z *Nat) mod(x *Nat, m *Modulus, scratch []Word) *Nat {
 	if x.reduced == m {
 		z.SetNat(x)
 		return z
 	}
 	size := len(m.nat.limbs)
 	xLimbs := x.unaliasedLimbs(z)
+	// LEAK: the length of x, and the form of m
+	// OK: both of these are public information
+	if m.c != nil && x.announced <= 2*m.nat.announced {
+		if scratch == nil {
+			scratch = make([]Word, pseudoMersenneScratchSize(size))
+		}
+		z.limbs = z.resizedLimbs(m.nat.announced)
+		pseudoMersenneReduce(z.limbs, xLimbs, scratch, m)
+		z.announced = m.nat.announced
+		z.reduced = m
+		if zeroizing() {
+			clearLimbs(scratch)
+		}
+		wipeTemporaries(z)
+		return z
+	}
+	if m.even && len(xLimbs) <= 2*size {
+		if scratch == nil {
+			scratch = make([]Word, barrettScratchSize(size))
+		}
+		z.limbs = z.resizedLimbs(m.nat.announced)
+		barrettReduce(z.limbs, xLimbs, scratch, m)
+		z.announced = m.nat.announced
+		z.reduced = m
+		if zeroizing() {
+			clearLimbs(scratch)
+		}
+		wipeTemporaries(z)
+		return z
+	}
 	z.limbs = z.resizedLimbs(2 * _W * size)
 	for i := 0; i < len(z.limbs); i++ {
 		z.limbs[i] = 0
@@ -891,6 +1870,7 @@ func (z *Nat) Mod(x *Nat, m *Modulus) *Nat {
 	z.limbs = z.resizedLimbs(m.nat.announced)
 	z.announced = m.nat.announced
 	z.reduced = m
+	wipeTemporaries(z)
 	return z
 }
 
@@ -905,6 +1885,12 @@ func (z *Nat) Mod(x *Nat, m *Modulus) *Nat {
 func (z *Nat) Div(x *Nat, m *Modulus, cap int) *Nat {
 	if cap < 0 {
 		cap = x.announced - m.nat.announced + 2
+		if cap < 0 {
+			cap = 0
+		}
+	}
+	if truncationChecks() && cap < x.announced {
+		checkTruncation("Div", new(Nat).Div(x, m, x.announced), cap)
 	}
 	if len(x.limbs) < len(m.nat.limbs) || x.reduced == m {
 		z.limbs = z.resizedLimbs(cap)
@@ -969,6 +1955,182 @@ func (z *Nat) Div(x *Nat, m *Modulus, cap int) *Nat {
 	return z
 }
 
+// DivRem calculates z <- x / y, returning z, and the remainder x mod y.
+//
+// Unlike Div, the divisor is an arbitrary Nat, whose value isn't leaked. This
+// uses bit by bit long division, which is slower than Div, but produces the quotient
+// and the remainder in a single pass. Only the announced lengths of x and y are leaked.
+//
+// cap determines the number of bits to keep in the quotient. If cap < 0, then
+// the quotient will have the same capacity as x. The capacity of the remainder
+// matches that of y.
+//
+// If y is 0, the quotient and remainder are unspecified.
+func (z *Nat) DivRem(x *Nat, y *Nat, cap int) (*Nat, *Nat) {
+	if cap < 0 {
+		cap = x.announced
+	}
+	if truncationChecks() && cap < x.announced {
+		q, _ := new(Nat).DivRem(x, y, x.announced)
+		checkTruncation("DivRem", q, cap)
+	}
+	size := limbCount(y.announced)
+	xLimbs := x.unaliasedLimbs(z)
+	// We work with an extra limb, to catch the bit shifted out of the remainder
+	yExt := make([]Word, size+1)
+	copy(yExt, y.limbs)
+	r := make([]Word, size+1)
+	scratch := make([]Word, size+1)
+
+	z.limbs = z.resizedLimbs(cap)
+	for i := 0; i < len(z.limbs); i++ {
+		z.limbs[i] = 0
+	}
+	// LEAK: the announced length of x
+	// OK: this is public
+	for i := x.announced - 1; i >= 0; i-- {
+		shlVU(r, r, 1)
+		r[0] |= (xLimbs[i/_W] >> (i % _W)) & 1
+		// If r >= y, we subtract y, and set the corresponding bit of the quotient
+		geq := 1 ^ Choice(subVV(scratch, r, yExt))
+		ctCondCopy(geq, r, scratch)
+		if i < cap {
+			z.limbs[i/_W] |= Word(geq) << (i % _W)
+		}
+	}
+	z.announced = cap
+	z.reduced = nil
+
+	rem := new(Nat)
+	rem.limbs = r[:size]
+	rem.announced = y.announced
+	if zeroizing() {
+		clearLimbs(yExt)
+		clearLimbs(scratch)
+	}
+	wipeTemporaries(z)
+	return z, rem
+}
+
+// divUint64 divides the limbs of x by d, returning the remainder.
+//
+// If q isn't nil, it should have the same length as x, and receives the quotient.
+// q is allowed to alias x. d must not be 0.
+func divUint64(q []Word, x []Word, d uint64) uint64 {
+	var dLimbs, r, scratch [uint64Limbs]Word
+	for i := range dLimbs {
+		dLimbs[i] = uint64Limb(d, i)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		// The remainder is always < d, so shifting in the next limb, and reducing,
+		// gives us the next limb of the quotient
+		qi := shiftAddInGeneric(r[:], scratch[:], x[i], dLimbs[:])
+		if q != nil {
+			q[i] = qi
+		}
+	}
+	var out uint64
+	for i := uint64Limbs - 1; i >= 0; i-- {
+		// See SetUint64 for why this shift is split in two
+		out = (out << (_W - 1) << 1) | uint64(r[i])
+	}
+	return out
+}
+
+// DivUint64 calculates z <- x / d, returning z, and the remainder x mod d.
+//
+// This is much faster than DivRem, since dividing by a single uint64 only takes
+// one pass over the limbs of x. Only the announced length of x is leaked, and
+// not the value of d, unless it's 0, in which case this panics.
+//
+// The capacity of the quotient matches that of x.
+func (z *Nat) DivUint64(x *Nat, d uint64) (*Nat, uint64) {
+	if d == 0 {
+		panic("DivUint64: division by zero")
+	}
+	xLimbs := x.extendedLimbs(x.announced)
+	z.limbs = z.resizedLimbs(x.announced)
+	rem := divUint64(z.limbs, xLimbs, d)
+	z.announced = x.announced
+	z.reduced = nil
+	return z, rem
+}
+
+// ModUint64 calculates x mod d.
+//
+// This is like DivUint64, but doesn't calculate the quotient, and never allocates.
+// This will panic if d is 0.
+func (x *Nat) ModUint64(d uint64) uint64 {
+	if d == 0 {
+		panic("ModUint64: division by zero")
+	}
+	return divUint64(nil, x.limbs, d)
+}
+
+// sqrtRem calculates floor(sqrt(x)), along with the remainder x - floor(sqrt(x))^2.
+//
+// This uses the binary digit by digit method, which doesn't require any multiplications,
+// or divisions. The results have one more limb than x, to avoid any overflow.
+//
+// LEAK: the announced length of x
+func sqrtRem(x *Nat) (root []Word, rem []Word) {
+	size := limbCount(x.announced) + 1
+	rem = make([]Word, size)
+	copy(rem, x.limbs)
+	root = make([]Word, size)
+	bit := make([]Word, size)
+	t := make([]Word, size)
+	scratch := make([]Word, size)
+	// We start with the largest power of 4 that fits in x's announced length
+	for pos := (x.announced - 1) &^ 1; pos >= 0; pos -= 2 {
+		// If rem >= root + 2^pos, we subtract it, and include that bit in the root
+		bit[pos/_W] = 1 << (pos % _W)
+		addVV(t, root, bit)
+		geq := 1 ^ Choice(subVV(scratch, rem, t))
+		ctCondCopy(geq, rem, scratch)
+		shrVU(root, root, 1)
+		bit[pos/_W] &= -Word(geq)
+		addVV(root, root, bit)
+		bit[pos/_W] = 0
+	}
+	if zeroizing() {
+		clearLimbs(t)
+		clearLimbs(scratch)
+	}
+	return root, rem
+}
+
+// Sqrt calculates z <- floor(sqrt(x))
+//
+// This only leaks the announced length of x. The capacity of the result is
+// half of that length, rounded up, which is enough to contain the root.
+func (z *Nat) Sqrt(x *Nat) *Nat {
+	root, rem := sqrtRem(x)
+	announced := (x.announced + 1) / 2
+	z.limbs = z.resizedLimbs(announced)
+	copy(z.limbs, root)
+	z.announced = announced
+	z.reduced = nil
+	if zeroizing() {
+		clearLimbs(root)
+		clearLimbs(rem)
+	}
+	return z
+}
+
+// IsPerfectSquare returns 1 if x = y^2 for some natural number y, and 0 otherwise
+//
+// Like Sqrt, this only leaks the announced length of x.
+func (x *Nat) IsPerfectSquare() Choice {
+	root, rem := sqrtRem(x)
+	res := cmpZero(rem)
+	if zeroizing() {
+		clearLimbs(root)
+		clearLimbs(rem)
+	}
+	return res
+}
+
 // ModAdd calculates z <- x + y mod m
 //
 // The capacity of the resulting number matches the capacity of the modulus.
@@ -1012,6 +2174,108 @@ func (z *Nat) ModAdd(x *Nat, y *Nat, m *Modulus) *Nat {
 	return z
 }
 
+// ModDouble calculates z <- 2x mod m
+//
+// This is cheaper than ModAdd(x, x, m), since x only needs to be reduced once,
+// and shifting by one bit replaces the addition. Formulas for elliptic curves, for
+// example, need this quite often.
+//
+// The capacity of the resulting number matches the capacity of the modulus.
+func (z *Nat) ModDouble(x *Nat, m *Modulus) *Nat {
+	var xModM Nat
+	xModM.Mod(x, m)
+
+	size := limbCount(m.nat.announced)
+	scratch := z.resizedLimbs(2 * _W * size)
+	z.limbs = scratch[:size]
+	subResult := scratch[size:]
+
+	// As in ModAdd, 2x < 2m, so a single conditional subtraction suffices
+	shiftCarry := shlVU(z.limbs, xModM.limbs, 1)
+	subCarry := subVV(subResult, z.limbs, m.nat.limbs)
+	ctCondCopy(ctEq(shiftCarry, subCarry), z.limbs, subResult)
+	z.reduced = m
+	z.announced = m.nat.announced
+	wipeTemporaries(z, &xModM)
+	return z
+}
+
+// ModTriple calculates z <- 3x mod m
+//
+// This doubles x, and then adds x, without going through multiplication.
+//
+// The capacity of the resulting number matches the capacity of the modulus.
+func (z *Nat) ModTriple(x *Nat, m *Modulus) *Nat {
+	var xModM Nat
+	xModM.Mod(x, m)
+	z.ModDouble(&xModM, m)
+	z.ModAdd(z, &xModM, m)
+	wipeTemporaries(z, &xModM)
+	return z
+}
+
+// uint64Limbs is the number of limbs needed to hold a uint64
+const uint64Limbs = (64 + _W - 1) / _W
+
+// ModAddUint64 calculates z <- x + y mod m
+//
+// Unlike ModAdd, this doesn't need a temporary Nat to hold y, using pooled
+// scratch space instead, if pooling is enabled. The value of y isn't leaked.
+//
+// The capacity of the resulting number matches the capacity of the modulus.
+func (z *Nat) ModAddUint64(x *Nat, y uint64, m *Modulus) *Nat {
+	size := len(m.nat.limbs)
+	s := getScratch(size)
+	scratch := s.get(3 * size)
+	yModM := scratch[:size]
+	subResult := scratch[size : 2*size]
+	for i := 0; i < size; i++ {
+		yModM[i] = 0
+	}
+	// y might be larger than m, so we reduce it, one limb at a time
+	for i := uint64Limbs - 1; i >= 0; i-- {
+		shiftAddIn(yModM, scratch[2*size:], uint64Limb(y, i), m)
+	}
+	z.Mod(x, m)
+
+	// Like ModAdd, with both values < m, a single conditional subtraction suffices
+	addCarry := addVV(z.limbs, z.limbs, yModM)
+	subCarry := subVV(subResult, z.limbs, m.nat.limbs)
+	ctCondCopy(ctEq(addCarry, subCarry), z.limbs, subResult)
+	putScratch(s, size)
+	return z
+}
+
+// ModMulUint64 calculates z <- x * y mod m
+//
+// Unlike ModMul, this doesn't need a temporary Nat to hold y, and multiplying
+// by a single word is much cheaper than a full modular multiplication. The value
+// of y isn't leaked.
+//
+// The capacity of the resulting number matches the capacity of the modulus.
+func (z *Nat) ModMulUint64(x *Nat, y uint64, m *Modulus) *Nat {
+	size := len(m.nat.limbs)
+	s := getScratch(size)
+	scratch := s.get(2*size + uint64Limbs)
+	product := scratch[:size+uint64Limbs]
+	for i := range product {
+		product[i] = 0
+	}
+	z.Mod(x, m)
+	for i := 0; i < uint64Limbs; i++ {
+		product[size+i] = addMulVVW(product[i:size+i], z.limbs, uint64Limb(y, i))
+	}
+	// Since x < m, and y < B^uint64Limbs, the top limbs of the product are < m,
+	// so we can shift the remaining limbs into them, reducing as we go.
+	remainder := product[uint64Limbs:]
+	for i := uint64Limbs - 1; i >= 0; i-- {
+		shiftAddIn(remainder, scratch[size+uint64Limbs:], product[i], m)
+	}
+	copy(z.limbs, remainder)
+	putScratch(s, size)
+	return z
+}
+
 func (z *Nat) ModSub(x *Nat, y *Nat, m *Modulus) *Nat {
 	var xModM, yModM Nat
 	// First reduce x and y mod m
@@ -1065,15 +2329,23 @@ func (z *Nat) Add(x *Nat, y *Nat, cap int) *Nat {
 	if cap < 0 {
 		cap = x.maxAnnounced(y) + 1
 	}
-	xLimbs := x.resizedLimbs(cap)
-	yLimbs := y.resizedLimbs(cap)
+	if truncationChecks() && cap <= x.maxAnnounced(y) {
+		checkTruncation("Add", new(Nat).Add(x, y, -1), cap)
+	}
+	z.wrappingAdd(x, y, cap)
+	return z
+}
+
+// wrappingAdd calculates z <- x + y, modulo 2^cap, without any truncation checks
+func (z *Nat) wrappingAdd(x *Nat, y *Nat, cap int) {
+	xLimbs := x.extendedLimbs(cap)
+	yLimbs := y.extendedLimbs(cap)
 	z.limbs = z.resizedLimbs(cap)
 	addVV(z.limbs, xLimbs, yLimbs)
 	// Mask off the final bits
 	z.limbs = z.resizedLimbs(cap)
 	z.announced = cap
 	z.reduced = nil
-	return z
 }
 
 // Sub calculates z <- x - y, modulo 2^cap
@@ -1085,17 +2357,232 @@ func (z *Nat) Sub(x *Nat, y *Nat, cap int) *Nat {
 	if cap < 0 {
 		cap = x.maxAnnounced(y)
 	}
-	xLimbs := x.resizedLimbs(cap)
-	yLimbs := y.resizedLimbs(cap)
+	if truncationChecks() {
+		if _, _, lt := x.Cmp(y); lt == 1 {
+			panic("saferith: Sub underflowed")
+		}
+		if cap < x.maxAnnounced(y) {
+			checkTruncation("Sub", new(Nat).WrappingSub(x, y, -1), cap)
+		}
+	}
+	z.wrappingSub(x, y, cap)
+	return z
+}
+
+// wrappingSub calculates z <- x - y, modulo 2^cap, without any truncation checks
+func (z *Nat) wrappingSub(x *Nat, y *Nat, cap int) {
+	xLimbs := x.extendedLimbs(cap)
+	yLimbs := y.extendedLimbs(cap)
 	z.limbs = z.resizedLimbs(cap)
 	subVV(z.limbs, xLimbs, yLimbs)
 	// Mask off the final bits
 	z.limbs = z.resizedLimbs(cap)
 	z.announced = cap
 	z.reduced = nil
+}
+
+// overflowsCap returns 1 if any bit at index cap or above is set in limbs, and 0 otherwise
+//
+// LEAK: the length of limbs, and cap
+func overflowsCap(limbs []Word, cap int) Choice {
+	i := cap / _W
+	if i >= len(limbs) {
+		return 0
+	}
+	acc := limbs[i] >> (cap % _W)
+	for j := i + 1; j < len(limbs); j++ {
+		acc |= limbs[j]
+	}
+	return 1 ^ ctEq(acc, 0)
+}
+
+// truncateChecked truncates z to cap bits, returning 1 if anything was lost
+func (z *Nat) truncateChecked(cap int) Choice {
+	overflow := overflowsCap(z.limbs, cap)
+	z.limbs = z.resizedLimbs(cap)
+	z.announced = cap
+	return overflow
+}
+
+// AddChecked calculates z <- x + y, modulo 2^cap, returning 1 if the sum overflowed
+//
+// Unlike Add, which silently wraps around, this lets callers detect, and reject,
+// results which don't fit in cap bits, without leaking whether that happened.
+//
+// If cap < 0, the capacity will be max(x.AnnouncedLen(), y.AnnouncedLen()) + 1,
+// which never overflows.
+func (z *Nat) AddChecked(x *Nat, y *Nat, cap int) (*Nat, Choice) {
+	full := x.maxAnnounced(y) + 1
+	if cap < 0 {
+		cap = full
+	}
+	if full < cap {
+		full = cap
+	}
+	z.Add(x, y, full)
+	return z, z.truncateChecked(cap)
+}
+
+// SubChecked calculates z <- x - y, modulo 2^cap, returning 1 if the difference overflowed
+//
+// This happens when x < y, or when x - y doesn't fit in cap bits. In both cases, z
+// contains the same result as Sub would.
+//
+// If cap < 0, the capacity will be max(x.AnnouncedLen(), y.AnnouncedLen()), and
+// only x < y counts as an overflow.
+func (z *Nat) SubChecked(x *Nat, y *Nat, cap int) (*Nat, Choice) {
+	full := x.maxAnnounced(y)
+	if cap < 0 {
+		cap = full
+	}
+	if full < cap {
+		full = cap
+	}
+	_, _, underflow := x.Cmp(y)
+	z.wrappingSub(x, y, full)
+	return z, underflow | z.truncateChecked(cap)
+}
+
+// saturate sets z to the largest value fitting in its announced length, if yes = 1
+func (z *Nat) saturate(yes Choice) {
+	for i := 0; i < len(z.limbs); i++ {
+		z.limbs[i] |= -Word(yes)
+	}
+	maskEnd(z.limbs, z.announced)
+}
+
+// SaturatingAdd calculates z <- min(x + y, 2^cap - 1)
+//
+// Instead of wrapping around, like Add, sums which don't fit in cap bits are
+// clamped to the largest value which does. This doesn't leak whether or not
+// that happened.
+//
+// If cap < 0, the capacity will be max(x.AnnouncedLen(), y.AnnouncedLen()) + 1
+func (z *Nat) SaturatingAdd(x *Nat, y *Nat, cap int) *Nat {
+	_, overflow := z.AddChecked(x, y, cap)
+	z.saturate(overflow)
 	return z
 }
 
+// SaturatingSub calculates z <- x - y, clamped to the range 0..2^cap - 1
+//
+// Differences which would be negative become 0, and those which don't fit
+// in cap bits become 2^cap - 1. This doesn't leak whether or not that happened.
+//
+// If cap < 0, the capacity will be max(x.AnnouncedLen(), y.AnnouncedLen())
+func (z *Nat) SaturatingSub(x *Nat, y *Nat, cap int) *Nat {
+	_, _, underflow := x.Cmp(y)
+	_, overflow := z.SubChecked(x, y, cap)
+	z.saturate(overflow)
+	for i := 0; i < len(z.limbs); i++ {
+		z.limbs[i] &= ^(-Word(underflow))
+	}
+	return z
+}
+
+// WrappingAdd calculates z <- x + y, modulo 2^cap
+//
+// This is the same as Add, but makes the choice of overflow semantics explicit,
+// as opposed to SaturatingAdd, or AddChecked.
+//
+// If cap < 0, the capacity will be max(x.AnnouncedLen(), y.AnnouncedLen()) + 1
+func (z *Nat) WrappingAdd(x *Nat, y *Nat, cap int) *Nat {
+	if cap < 0 {
+		cap = x.maxAnnounced(y) + 1
+	}
+	z.wrappingAdd(x, y, cap)
+	return z
+}
+
+// WrappingSub calculates z <- x - y, modulo 2^cap
+//
+// This is the same as Sub, but makes the choice of overflow semantics explicit,
+// as opposed to SaturatingSub, or SubChecked.
+//
+// If cap < 0, the capacity will be max(x.AnnouncedLen(), y.AnnouncedLen())
+func (z *Nat) WrappingSub(x *Nat, y *Nat, cap int) *Nat {
+	if cap < 0 {
+		cap = x.maxAnnounced(y)
+	}
+	z.wrappingSub(x, y, cap)
+	return z
+}
+
+// limbOrZero returns the ith limb of x, or 0, if x has fewer limbs.
+//
+// LEAK: the number of limbs of x
+func (x *Nat) limbOrZero(i int) Word {
+	if i < len(x.limbs) {
+		return x.limbs[i]
+	}
+	return 0
+}
+
+// bitwise calculates z <- op(x, y) modulo 2^cap, applying op to each limb
+//
+// If cap < 0, the capacity will be max(x.AnnouncedLen(), y.AnnouncedLen()).
+func (z *Nat) bitwise(x *Nat, y *Nat, cap int, op func(Word, Word) Word) *Nat {
+	if cap < 0 {
+		cap = x.maxAnnounced(y)
+	}
+	z.limbs = z.resizedLimbs(cap)
+	for i := 0; i < len(z.limbs); i++ {
+		z.limbs[i] = op(x.limbOrZero(i), y.limbOrZero(i))
+	}
+	maskEnd(z.limbs, cap)
+	z.announced = cap
+	z.reduced = nil
+	return z
+}
+
+// And calculates z <- x & y, modulo 2^cap
+//
+// The capacity is given in bits, and also controls the size of the result.
+//
+// If cap < 0, the capacity will be max(x.AnnouncedLen(), y.AnnouncedLen())
+func (z *Nat) And(x *Nat, y *Nat, cap int) *Nat {
+	return z.bitwise(x, y, cap, func(a, b Word) Word { return a & b })
+}
+
+// Or calculates z <- x | y, modulo 2^cap
+//
+// The capacity is given in bits, and also controls the size of the result.
+//
+// If cap < 0, the capacity will be max(x.AnnouncedLen(), y.AnnouncedLen())
+func (z *Nat) Or(x *Nat, y *Nat, cap int) *Nat {
+	return z.bitwise(x, y, cap, func(a, b Word) Word { return a | b })
+}
+
+// Xor calculates z <- x ^ y, modulo 2^cap
+//
+// The capacity is given in bits, and also controls the size of the result.
+//
+// If cap < 0, the capacity will be max(x.AnnouncedLen(), y.AnnouncedLen())
+func (z *Nat) Xor(x *Nat, y *Nat, cap int) *Nat {
+	return z.bitwise(x, y, cap, func(a, b Word) Word { return a ^ b })
+}
+
+// AndNot calculates z <- x &^ y, modulo 2^cap
+//
+// The capacity is given in bits, and also controls the size of the result.
+//
+// If cap < 0, the capacity will be max(x.AnnouncedLen(), y.AnnouncedLen())
+func (z *Nat) AndNot(x *Nat, y *Nat, cap int) *Nat {
+	return z.bitwise(x, y, cap, func(a, b Word) Word { return a &^ b })
+}
+
+// Not calculates z <- ^x, modulo 2^cap
+//
+// This flips every bit of x, up to the capacity, which is given in bits.
+//
+// If cap < 0, the capacity will be x.AnnouncedLen()
+func (z *Nat) Not(x *Nat, cap int) *Nat {
+	if cap < 0 {
+		cap = x.announced
+	}
+	return z.bitwise(x, x, cap, func(a, _ Word) Word { return ^a })
+}
+
 // montgomeryRepresentation calculates zR mod m
 func montgomeryRepresentation(z []Word, scratch []Word, m *Modulus) {
 	// Our strategy is to shift by W, n times, each time reducing modulo m
@@ -1143,9 +2630,18 @@ func tripleFromMul(a Word, b Word) triple {
 //
 // out, x, y must have the same length as the modulus, and be reduced already.
 //
-// out can alias x and y, but not scratch
+// out can alias x and y, but not scratch. scratch needs at least as many limbs
+// as the modulus. If scratch has twice as many limbs, then we compute the full
+// product before reducing it, which lets us use addMulVVW, and the MULX / ADX
+// instructions it can use on amd64.
 func montgomeryMul(x []Word, y []Word, out []Word, scratch []Word, m *Modulus) {
 	size := len(m.nat.limbs)
+	if len(scratch) >= 2*size {
+		mulVV(scratch[:2*size], x, y)
+		montgomeryReduce(out, scratch[:2*size], m)
+		return
+	}
+	scratch = scratch[:size]
 
 	for i := 0; i < size; i++ {
 		scratch[i] = 0
@@ -1174,15 +2670,262 @@ func montgomeryMul(x []Word, y []Word, out []Word, scratch []Word, m *Modulus) {
 	ctCondCopy(1^ctEq(dh, c), out, scratch)
 }
 
+// sqrVV calculates z <- x^2, with z having twice the length of x
+//
+// This computes each cross product x_i x_j only once, doubling their sum
+// afterwards, which saves almost half of the multiplications compared to Mul.
+func sqrVV(z []Word, x []Word) {
+	n := len(x)
+	for i := 0; i < len(z); i++ {
+		z[i] = 0
+	}
+	// First, the cross products x_i x_j, with i < j
+	for i := 0; i < n; i++ {
+		z[n+i] = addMulVVW(z[2*i+1:n+i], x[i+1:], x[i])
+	}
+	shlVU(z, z, 1)
+	// Then, the diagonal products x_i^2, which occupy disjoint pairs of limbs
+	var c Word
+	for i := 0; i < n; i++ {
+		hi, lo := mulWW(x[i], x[i])
+		z[2*i], c = add(z[2*i], lo, c)
+		z[2*i+1], c = add(z[2*i+1], hi, c)
+	}
+}
+
+// montgomeryReduce calculates out <- t / R mod m
+//
+// LEAK: the size of the modulus
+//
+// t must have twice the length of the modulus, and be < mR. t will be clobbered.
+// out must have the same length as the modulus.
+func montgomeryReduce(out []Word, t []Word, m *Modulus) {
+	size := len(m.nat.limbs)
+	carry := uint(0)
+	for i := 0; i < size; i++ {
+		c := addMulVVW(t[i:i+size], m.nat.limbs, t[i]*m.m0inv)
+		var ti uint
+		ti, carry = bits.Add(uint(t[i+size]), uint(c), carry)
+		t[i+size] = Word(ti)
+	}
+	c := subVV(out, t[size:], m.nat.limbs)
+	ctCondCopy(1^ctEq(Word(carry), c), out, t[size:])
+}
+
+// montgomerySqr calculates out <- x^2 / R mod m
+//
+// LEAK: the size of the modulus
+//
+// out and x must have the same length as the modulus, and x must be reduced.
+// scratch must have twice this length. out can alias x, but not scratch.
+func montgomerySqr(x []Word, out []Word, scratch []Word, m *Modulus) {
+	scratch = scratch[:2*len(x)]
+	sqrVV(scratch, x)
+	montgomeryReduce(out, scratch, m)
+}
+
+// ModSqr calculates z <- x^2 mod m
+//
+// The capacity of the resulting number matches the capacity of the modulus.
+//
+// This is faster than calling ModMul with the same argument twice, since
+// the symmetry of the partial products can be exploited.
+func (z *Nat) ModSqr(x *Nat, m *Modulus) *Nat {
+	size := len(m.nat.limbs)
+	s := getScratch(size)
+	z.ModSqrWithScratch(x, m, s)
+	putScratch(s, size)
+	return z
+}
+
+// ModSqrWithScratch calculates z <- x^2 mod m, like ModSqr.
+//
+// Temporary values are stored in s instead of being allocated.
+func (z *Nat) ModSqrWithScratch(x *Nat, m *Modulus, s *Scratch) *Nat {
+	size := len(m.nat.limbs)
+	scratch := s.get(2*size + barrettScratchSize(size))
+	xModM := s.x.mod(x, m, scratch[2*size:])
+	z.limbs = z.resizedLimbs(m.nat.announced)
+	if m.c != nil {
+		sqrVV(scratch[:2*size], xModM.limbs)
+		pseudoMersenneReduce(z.limbs, scratch[:2*size], scratch[2*size:], m)
+	} else if m.even {
+		sqrVV(scratch[:2*size], xModM.limbs)
+		barrettReduce(z.limbs, scratch[:2*size], scratch[2*size:], m)
+	} else {
+		montgomerySqr(xModM.limbs, z.limbs, scratch[:2*size], m)
+		// Multiplying by R^2 cancels out the division by R
+		montgomeryMul(z.limbs, m.reduction().rr, z.limbs, scratch, m)
+	}
+	z.reduced = m
+	z.announced = m.nat.announced
+	return z
+}
+
 // ModMul calculates z <- x * y mod m
 //
-// The capacity of the resulting number matches the capacity of the modulus
+// The capacity of the resulting number matches the capacity of the modulus.
+//
+// For odd moduli, this uses Montgomery multiplication, which avoids the
+// expensive division in reducing the full product. For moduli created with
+// ModulusNearPowerOfTwo, the faster pseudo-Mersenne reduction is used instead.
 func (z *Nat) ModMul(x *Nat, y *Nat, m *Modulus) *Nat {
 	xModM := new(Nat).Mod(x, m)
 	yModM := new(Nat).Mod(y, m)
-	bitLen := m.BitLen()
-	z.Mul(xModM, yModM, 2*bitLen)
-	return z.Mod(z, m)
+	if m.c != nil {
+		size := len(m.nat.limbs)
+		scratch := z.resizedLimbs(_W * (3*size + pseudoMersenneScratchSize(size)))
+		z.limbs = scratch[:size]
+		mulVV(scratch[size:3*size], xModM.limbs, yModM.limbs)
+		pseudoMersenneReduce(z.limbs, scratch[size:3*size], scratch[3*size:], m)
+		z.reduced = m
+		z.announced = m.nat.announced
+		wipeTemporaries(z, xModM, yModM)
+		return z
+	}
+	if m.even {
+		// Mod will use Barrett reduction, since the product fits in twice the size of m
+		bitLen := m.BitLen()
+		product := new(Nat).Mul(xModM, yModM, 2*bitLen)
+		z.Mod(product, m)
+		wipeTemporaries(z, xModM, yModM, product)
+		return z
+	}
+	size := len(m.nat.limbs)
+	scratch := z.resizedLimbs(_W * 2 * size)
+	z.limbs = scratch[:size]
+	// First, we calculate xy / R, and then multiply by R^2, to get (xy / R) R^2 / R = xy
+	montgomeryMul(xModM.limbs, yModM.limbs, z.limbs, scratch[size:], m)
+	montgomeryMul(z.limbs, m.reduction().rr, z.limbs, scratch[size:], m)
+	z.reduced = m
+	z.announced = m.nat.announced
+	wipeTemporaries(z, xModM, yModM)
+	return z
+}
+
+// ModMulWithScratch calculates z <- x * y mod m, like ModMul.
+//
+// Temporary values are stored in s instead of being allocated. Unlike ModMul,
+// this always uses schoolbook multiplication for even moduli, since Karatsuba
+// multiplication needs to allocate.
+func (z *Nat) ModMulWithScratch(x *Nat, y *Nat, m *Modulus, s *Scratch) *Nat {
+	size := len(m.nat.limbs)
+	scratch := s.get(2*size + barrettScratchSize(size))
+	xModM := s.x.mod(x, m, scratch[2*size:])
+	yModM := s.y.mod(y, m, scratch[2*size:])
+	z.limbs = z.resizedLimbs(m.nat.announced)
+	if m.c != nil {
+		mulVV(scratch[:2*size], xModM.limbs, yModM.limbs)
+		pseudoMersenneReduce(z.limbs, scratch[:2*size], scratch[2*size:], m)
+	} else if m.even {
+		mulVV(scratch[:2*size], xModM.limbs, yModM.limbs)
+		barrettReduce(z.limbs, scratch[:2*size], scratch[2*size:], m)
+	} else {
+		// xy / R, and then (xy / R) R^2 / R = xy
+		montgomeryMul(xModM.limbs, yModM.limbs, z.limbs, scratch, m)
+		montgomeryMul(z.limbs, m.reduction().rr, z.limbs, scratch, m)
+	}
+	z.reduced = m
+	z.announced = m.nat.announced
+	return z
+}
+
+// mulVV calculates z <- x * y, using schoolbook multiplication
+//
+// x and y must have the same length, and z must have twice this length.
+func mulVV(z []Word, x []Word, y []Word) {
+	n := len(x)
+	for i := 0; i < len(z); i++ {
+		z[i] = 0
+	}
+	for i := 0; i < n; i++ {
+		z[n+i] = addMulVVW(z[i:n+i], x, y[i])
+	}
+}
+
+// karatsubaThreshold is the number of limbs at which Mul switches to Karatsuba multiplication
+//
+// This was tuned on amd64, and is the point after which schoolbook multiplication
+// becomes noticeably slower.
+const karatsubaThreshold = 40
+
+// absDiff calculates out <- |a - b|, returning 1 if b > a
+//
+// b can be shorter than a, and out must have the same length as a.
+// scratch needs at least as many limbs as a.
+func absDiff(out []Word, a []Word, b []Word, scratch []Word) Choice {
+	bExt := scratch[:len(a)]
+	for i := 0; i < len(bExt); i++ {
+		bExt[i] = 0
+	}
+	copy(bExt, b)
+	c := Choice(subVV(out, a, bExt))
+	negateTwos(c, out)
+	return c
+}
+
+// karatsubaScratchSize returns the number of limbs of scratch space karatsuba needs.
+func karatsubaScratchSize(n int) int {
+	if n < karatsubaThreshold {
+		return 0
+	}
+	hh := n - n/2
+	// dx, dy, and p, followed by space for either the recursive call, or mid and minus
+	rest := karatsubaScratchSize(hh)
+	if rest < 4*hh+2 {
+		rest = 4*hh + 2
+	}
+	return 4*hh + rest
+}
+
+// karatsuba calculates z <- x * y, using Karatsuba's method
+//
+// x and y must have the same length, and z must have twice their length.
+// scratch needs at least karatsubaScratchSize(len(x)) limbs.
+//
+// This only leaks the length of the inputs, with the sign of the intermediate
+// differences being handled in constant-time.
+func karatsuba(z []Word, x []Word, y []Word, scratch []Word) {
+	n := len(x)
+	for i := 0; i < len(z); i++ {
+		z[i] = 0
+	}
+	if n < karatsubaThreshold {
+		for i := 0; i < n; i++ {
+			z[n+i] = addMulVVW(z[i:i+n], x, y[i])
+		}
+		return
+	}
+	h := n / 2
+	hh := n - h
+	x0, x1 := x[:h], x[h:]
+	y0, y1 := y[:h], y[h:]
+	// z = x0 y0 + x1 y1 B^(2h)
+	karatsuba(z[:2*h], x0, y0, scratch)
+	karatsuba(z[2*h:], x1, y1, scratch)
+
+	// The middle term x0 y1 + x1 y0 is x0 y0 + x1 y1 + (x1 - x0)(y0 - y1)
+	dx := scratch[:hh]
+	dy := scratch[hh : 2*hh]
+	p := scratch[2*hh : 4*hh]
+	rest := scratch[4*hh:]
+	// absDiff returns 1 when x1 - x0 is negative, and when y0 - y1 is positive
+	negative := absDiff(dx, x1, x0, rest) ^ absDiff(dy, y1, y0, rest) ^ 1
+	karatsuba(p, dx, dy, rest)
+
+	mid := rest[:2*hh+1]
+	for i := 0; i < len(mid); i++ {
+		mid[i] = 0
+	}
+	copy(mid, z[:2*h])
+	mid[2*hh] = addVV(mid[:2*hh], mid[:2*hh], z[2*h:])
+	minus := rest[2*hh+1 : 4*hh+2]
+	minus[2*hh] = mid[2*hh] - subVV(minus[:2*hh], mid[:2*hh], p)
+	mid[2*hh] += addVV(mid[:2*hh], mid[:2*hh], p)
+	ctCondCopy(negative, mid, minus)
+
+	c := addVV(z[h:h+len(mid)], z[h:h+len(mid)], mid)
+	addVW(z[h+len(mid):], z[h+len(mid):], c)
 }
 
 // Mul calculates z <- x * y, modulo 2^cap
@@ -1194,22 +2937,75 @@ func (z *Nat) Mul(x *Nat, y *Nat, cap int) *Nat {
 	if cap < 0 {
 		cap = x.announced + y.announced
 	}
+	if truncationChecks() && cap < x.announced+y.announced {
+		checkTruncation("Mul", new(Nat).Mul(x, y, -1), cap)
+	}
+	z.wrappingMul(x, y, cap)
+	return z
+}
+
+// WrappingMul calculates z <- x * y, modulo 2^cap
+//
+// This is the same as Mul, but makes the choice of overflow semantics explicit,
+// as opposed to MulChecked.
+//
+// If cap < 0, the capacity will be x.AnnouncedLen() + y.AnnouncedLen()
+func (z *Nat) WrappingMul(x *Nat, y *Nat, cap int) *Nat {
+	if cap < 0 {
+		cap = x.announced + y.announced
+	}
+	z.wrappingMul(x, y, cap)
+	return z
+}
+
+// wrappingMul calculates z <- x * y, modulo 2^cap, without any truncation checks
+func (z *Nat) wrappingMul(x *Nat, y *Nat, cap int) {
 	size := limbCount(cap)
 	// Since we neex to set z to zero, we have no choice to use a new buffer,
 	// because we allow z to alias either of the arguments
 	zLimbs := make([]Word, size)
-	xLimbs := x.resizedLimbs(cap)
-	yLimbs := y.resizedLimbs(cap)
-	// LEAK: limbCount
-	// OK: the capacity is public, or should be
-	for i := 0; i < size; i++ {
-		addMulVVW(zLimbs[i:], xLimbs, yLimbs[i])
+	// LEAK: the announced lengths of x and y, and limbCount
+	// OK: these are public, or should be
+	n := limbCount(x.announced)
+	if yn := limbCount(y.announced); yn > n {
+		n = yn
+	}
+	if n >= karatsubaThreshold {
+		s := getScratch(n)
+		scratch := s.get(2*n + karatsubaScratchSize(n))
+		karatsuba(scratch[:2*n], x.extendedLimbs(_W*n), y.extendedLimbs(_W*n), scratch[2*n:])
+		// Only the product itself is copied, which is then masked to cap, like the schoolbook path
+		copy(zLimbs, scratch[:2*n])
+		putScratch(s, n)
+	} else {
+		xLimbs := x.extendedLimbs(cap)
+		yLimbs := y.extendedLimbs(cap)
+		for i := 0; i < size; i++ {
+			addMulVVW(zLimbs[i:], xLimbs, yLimbs[i])
+		}
 	}
 	z.limbs = zLimbs
 	z.limbs = z.resizedLimbs(cap)
 	z.announced = cap
 	z.reduced = nil
-	return z
+}
+
+// MulChecked calculates z <- x * y, modulo 2^cap, returning 1 if the product overflowed
+//
+// Like AddChecked, this doesn't leak whether or not the product fits in cap bits.
+//
+// If cap < 0, the capacity will be x.AnnouncedLen() + y.AnnouncedLen(),
+// which never overflows.
+func (z *Nat) MulChecked(x *Nat, y *Nat, cap int) (*Nat, Choice) {
+	full := x.announced + y.announced
+	if cap < 0 {
+		cap = full
+	}
+	if full < cap {
+		full = cap
+	}
+	z.Mul(x, y, full)
+	return z, z.truncateChecked(cap)
 }
 
 // Rsh calculates z <- x >> shift, producing a certain number of bits
@@ -1226,7 +3022,7 @@ func (z *Nat) Rsh(x *Nat, shift uint, cap int) *Nat {
 	}
 
 	zLimbs := z.resizedLimbs(x.announced)
-	xLimbs := x.resizedLimbs(x.announced)
+	xLimbs := x.extendedLimbs(x.announced)
 	singleShift := shift % _W
 	shrVU(zLimbs, xLimbs, singleShift)
 
@@ -1257,8 +3053,11 @@ func (z *Nat) Lsh(x *Nat, shift uint, cap int) *Nat {
 	if cap < 0 {
 		cap = x.announced + int(shift)
 	}
+	if truncationChecks() && cap < x.announced+int(shift) {
+		checkTruncation("Lsh", new(Nat).Lsh(x, shift, -1), cap)
+	}
 	zLimbs := z.resizedLimbs(cap)
-	xLimbs := x.resizedLimbs(cap)
+	xLimbs := x.extendedLimbs(cap)
 	singleShift := shift % _W
 	shlVU(zLimbs, xLimbs, singleShift)
 
@@ -1272,6 +3071,8 @@ func (z *Nat) Lsh(x *Nat, shift uint, cap int) *Nat {
 			zLimbs[i] = 0
 		}
 	}
+	// Bits of x below cap can still be shifted past it
+	maskEnd(zLimbs, cap)
 
 	z.limbs = zLimbs
 	z.announced = cap
@@ -1279,26 +3080,61 @@ func (z *Nat) Lsh(x *Nat, shift uint, cap int) *Nat {
 	return z
 }
 
-func (z *Nat) expOdd(x *Nat, y *Nat, m *Modulus) *Nat {
+// SetBit sets z <- x, with the ith bit set to b, returning z.
+//
+// This will leak the value of i, but not of b, and panic if i is < 0.
+//
+// The announced size of the result is the largest between x.AnnouncedLen() and i + 1.
+func (z *Nat) SetBit(x *Nat, i int, b Choice) *Nat {
+	if i < 0 {
+		panic("negative bit")
+	}
+	announced := x.announced
+	if i >= announced {
+		announced = i + 1
+	}
+	xLimbs := x.extendedLimbs(announced)
+	z.limbs = z.resizedLimbs(announced)
+	copy(z.limbs, xLimbs)
+	limb := i >> _WShift
+	mask := Word(1) << (i & _WMask)
+	z.limbs[limb] = (z.limbs[limb] &^ mask) | (-Word(b) & mask)
+	z.announced = announced
+	z.reduced = nil
+	return z
+}
+
+// TruncateBits sets z <- x mod 2^k, returning z.
+//
+// In other words, this keeps only the low k bits of x. The announced size of
+// the result is k, even if x is smaller.
+func (z *Nat) TruncateBits(x *Nat, k int) *Nat {
+	z.SetNat(x)
+	z.Resize(k)
+	z.reduced = nil
+	return z
+}
+
+// expOdd calculates z <- x^y mod m, for odd m, as described in Exp.
+//
+// x should already be reduced modulo m, and y must not alias z.
+func (z *Nat) expOdd(x *Nat, y *Nat, m *Modulus, s *Scratch) *Nat {
 	size := len(m.nat.limbs)
 
-	xModM := new(Nat).Mod(x, m)
-	yLimbs := y.unaliasedLimbs(z)
-
-	scratch := z.resizedLimbs(_W * 18 * size)
+	scratch := s.get(19 * size)
 	scratch1 := scratch[16*size : 17*size]
 	scratch2 := scratch[17*size:]
 
-	z.limbs = scratch[:size]
+	z.limbs = z.resizedLimbs(m.nat.announced)
 	for i := 0; i < size; i++ {
 		z.limbs[i] = 0
 	}
 	z.limbs[0] = 1
-	montgomeryRepresentation(z.limbs, scratch1, m)
+	// Multiplying by R^2 puts us in Montgomery form, since the result is divided by R
+	montgomeryMul(z.limbs, m.reduction().rr, z.limbs, scratch1, m)
 
 	x1 := scratch[size : 2*size]
-	copy(x1, xModM.limbs)
-	montgomeryRepresentation(scratch[size:2*size], scratch1, m)
+	montgomeryMul(x.limbs, m.reduction().rr, x1, scratch1, m)
 	for i := 2; i < 16; i++ {
 		ximinus1 := scratch[(i-1)*size : i*size]
 		xi := scratch[i*size : (i+1)*size]
@@ -1307,13 +3143,14 @@ func (z *Nat) expOdd(x *Nat, y *Nat, m *Modulus) *Nat {
 
 	// LEAK: y's length
 	// OK: this should be public
-	for i := len(yLimbs) - 1; i >= 0; i-- {
-		yi := yLimbs[i]
+	for i := len(y.limbs) - 1; i >= 0; i-- {
+		yi := y.limbs[i]
 		for j := _W - 4; j >= 0; j -= 4 {
-			montgomeryMul(z.limbs, z.limbs, z.limbs, scratch1, m)
-			montgomeryMul(z.limbs, z.limbs, z.limbs, scratch1, m)
-			montgomeryMul(z.limbs, z.limbs, z.limbs, scratch1, m)
-			montgomeryMul(z.limbs, z.limbs, z.limbs, scratch1, m)
+			// scratch1 and scratch2 together give us the room needed for squaring
+			montgomerySqr(z.limbs, z.limbs, scratch[16*size:], m)
+			montgomerySqr(z.limbs, z.limbs, scratch[16*size:], m)
+			montgomerySqr(z.limbs, z.limbs, scratch[16*size:], m)
+			montgomerySqr(z.limbs, z.limbs, scratch[16*size:], m)
 
 			window := (yi >> j) & 0b1111
 			for i := 1; i < 16; i++ {
@@ -1334,36 +3171,300 @@ func (z *Nat) expOdd(x *Nat, y *Nat, m *Modulus) *Nat {
 	return z
 }
 
-func (z *Nat) expEven(x *Nat, y *Nat, m *Modulus) *Nat {
-	xModM := new(Nat).Mod(x, m)
-	yLimbs := y.unaliasedLimbs(z)
+// expEven calculates z <- x^y mod m, for even m, as described in Exp.
+//
+// x should already be reduced modulo m, and y must not alias z.
+func (z *Nat) expEven(x *Nat, y *Nat, m *Modulus, s *Scratch) *Nat {
+	size := len(m.nat.limbs)
 
-	scratch := new(Nat)
+	scratch := s.get(19*size + barrettScratchSize(size))
+	selected := scratch[16*size : 17*size]
+	product := scratch[17*size : 19*size]
+	reduceScratch := scratch[19*size:]
+
+	// table[i] = x^i mod m, with the first entry reduced in case m = 1
+	for i := 0; i < size; i++ {
+		scratch[i] = 0
+	}
+	scratch[0] = 1
+	barrettReduce(scratch[:size], scratch[:size], reduceScratch, m)
+	copy(scratch[size:2*size], x.limbs)
+	for i := 2; i < 16; i++ {
+		mulVV(product, scratch[(i-1)*size:i*size], x.limbs)
+		barrettReduce(scratch[i*size:(i+1)*size], product, reduceScratch, m)
+	}
+
+	z.limbs = z.resizedLimbs(m.nat.announced)
+	copy(z.limbs, scratch[:size])
 
 	// LEAK: y's length
 	// OK: this should be public
-	for i := len(yLimbs) - 1; i >= 0; i-- {
-		yi := yLimbs[i]
-		for j := _W; j >= 0; j-- {
-			z.ModMul(z, z, m)
-
-			sel := Choice((yi >> j) & 1)
-			scratch.ModMul(z, xModM, m)
-			ctCondCopy(sel, z.limbs, scratch.limbs)
+	for i := len(y.limbs) - 1; i >= 0; i-- {
+		yi := y.limbs[i]
+		for j := _W - 4; j >= 0; j -= 4 {
+			for k := 0; k < 4; k++ {
+				sqrVV(product, z.limbs)
+				barrettReduce(z.limbs, product, reduceScratch, m)
+			}
+
+			// We scan the entire table, and always multiply, even by x^0 = 1,
+			// to avoid leaking the window.
+			window := (yi >> j) & 0b1111
+			for k := 0; k < 16; k++ {
+				ctCondCopy(ctEq(window, Word(k)), selected, scratch[k*size:(k+1)*size])
+			}
+			mulVV(product, z.limbs, selected)
+			barrettReduce(z.limbs, product, reduceScratch, m)
 		}
 	}
+	z.reduced = m
+	z.announced = m.nat.announced
 	return z
 }
 
 // Exp calculates z <- x^y mod m
 //
-// The capacity of the resulting number matches the capacity of the modulus
+// The capacity of the resulting number matches the capacity of the modulus.
+//
+// This uses a fixed window of 4 bits, scanning over a table of precomputed
+// powers of x in constant time. Only the announced length of y is leaked.
 func (z *Nat) Exp(x *Nat, y *Nat, m *Modulus) *Nat {
+	size := len(m.nat.limbs)
+	s := getScratch(size)
+	z.ExpWithScratch(x, y, m, s)
+	putScratch(s, size)
+	return z
+}
+
+// ExpWithScratch calculates z <- x^y mod m, like Exp.
+//
+// The table of powers of x, and other temporary values, are stored in s
+// instead of being allocated.
+func (z *Nat) ExpWithScratch(x *Nat, y *Nat, m *Modulus, s *Scratch) *Nat {
+	// LEAK: whether or not y and z are the same Nat
+	// OK: this is a property of the caller, not the values
+	if y == z {
+		y = s.y.SetNat(y)
+	}
+	xModM := s.x.mod(x, m, s.get(barrettScratchSize(len(m.nat.limbs))))
+	if m.even {
+		return z.expEven(xModM, y, m, s)
+	} else {
+		return z.expOdd(xModM, y, m, s)
+	}
+}
+
+// expBlindingBits is the size of the random multiple added to exponents in ExpBlinded.
+const expBlindingBits = 64
+
+// ExpBlinded calculates z <- x^y mod m, randomizing the exponent with randomness from r.
+//
+// order should be a multiple of the order of x modulo m, such as phi(m), so that
+// x^order = 1. The exponent then gets replaced with y + k * order, for a random
+// 64 bit k, which doesn't change the result, but means that the exponent
+// used in each call is different. This mitigates side-channels which could slowly
+// learn bits of the exponent over many calls, e.g. on platforms where multiplication
+// isn't constant-time.
+//
+// If reading from r fails, an error is returned, and the value of z is undefined.
+//
+// The capacity of the resulting number matches the capacity of the modulus.
+func (z *Nat) ExpBlinded(x *Nat, y *Nat, m *Modulus, order *Nat, r io.Reader) (*Nat, error) {
+	k, err := new(Nat).RandBits(expBlindingBits, r)
+	if err != nil {
+		return nil, err
+	}
+	cap := order.announced + expBlindingBits
+	if y.announced > cap {
+		cap = y.announced
+	}
+	cap++
+	blinded := new(Nat).Mul(k, order, cap)
+	blinded.Add(blinded, y, cap)
+	z.Exp(x, blinded, m)
+	wipeTemporaries(z, k, blinded)
+	return z, nil
+}
+
+// expMul sets out <- a * b mod m, for use in MultiExp and ExpPublic.
+//
+// For odd moduli, the values are kept in Montgomery form, avoiding the conversion
+// cost on each multiplication.
+func expMul(out *Nat, a *Nat, b *Nat, scratch []Word, m *Modulus) {
+	if m.even {
+		out.ModMul(a, b, m)
+	} else {
+		montgomeryMul(a.limbs, b.limbs, out.limbs, scratch, m)
+	}
+}
+
+// expSqr sets out <- a^2 mod m, in the same representation as expMul.
+//
+// scratch needs to have twice the length of the modulus.
+func expSqr(out *Nat, a *Nat, scratch []Word, m *Modulus) {
 	if m.even {
-		return z.expEven(x, y, m)
+		out.ModSqr(a, m)
 	} else {
-		return z.expOdd(x, y, m)
+		montgomerySqr(a.limbs, out.limbs, scratch, m)
+	}
+}
+
+// MultiExp calculates z <- bases[0]^exps[0] * bases[1]^exps[1] * ... mod m
+//
+// This uses Straus' trick, sharing the squarings between all of the exponents,
+// which is considerably faster than calling Exp for each base. Each exponent is
+// processed in windows of 4 bits, scanning over a table of powers in constant time.
+//
+// The number of bases, and the announced length of each exponent, are leaked.
+//
+// This will panic if the number of bases and exponents don't match.
+func (z *Nat) MultiExp(bases []*Nat, exps []*Nat, m *Modulus) *Nat {
+	if len(bases) != len(exps) {
+		panic("MultiExp: mismatched number of bases and exponents")
 	}
+	size := len(m.nat.limbs)
+	newReduced := func() *Nat {
+		out := new(Nat)
+		out.limbs = make([]Word, size)
+		out.announced = m.nat.announced
+		out.reduced = m
+		return out
+	}
+	scratch := make([]Word, 2*size)
+
+	// LEAK: the length of each exponent
+	// OK: this should be public
+	expLimbs := make([][]Word, len(exps))
+	maxLen := 0
+	for i, e := range exps {
+		expLimbs[i] = e.unaliasedLimbs(z)
+		if len(expLimbs[i]) > maxLen {
+			maxLen = len(expLimbs[i])
+		}
+	}
+
+	// one will be 1 in the representation we're working with, reduced in case m = 1
+	one := new(Nat).SetUint64(1)
+	one.Mod(one, m)
+	if !m.even {
+		montgomeryMul(one.limbs, m.reduction().rr, one.limbs, scratch, m)
+	}
+
+	// tables[i][k] = bases[i]^k mod m
+	tables := make([][16]*Nat, len(bases))
+	for i, b := range bases {
+		tables[i][0] = one
+		tables[i][1] = new(Nat).Mod(b, m)
+		if !m.even {
+			montgomeryMul(tables[i][1].limbs, m.reduction().rr, tables[i][1].limbs, scratch, m)
+		}
+		for k := 2; k < 16; k++ {
+			tables[i][k] = newReduced()
+			expMul(tables[i][k], tables[i][k-1], tables[i][1], scratch, m)
+		}
+	}
+
+	acc := new(Nat).SetNat(one)
+	selected := newReduced()
+	for i := maxLen - 1; i >= 0; i-- {
+		for j := _W - 4; j >= 0; j -= 4 {
+			expSqr(acc, acc, scratch, m)
+			expSqr(acc, acc, scratch, m)
+			expSqr(acc, acc, scratch, m)
+			expSqr(acc, acc, scratch, m)
+
+			for b := range tables {
+				// Exponents shorter than this limb contribute nothing here
+				var yi Word
+				if i < len(expLimbs[b]) {
+					yi = expLimbs[b][i]
+				}
+				window := (yi >> j) & 0b1111
+				for k := 0; k < 16; k++ {
+					ctCondCopy(ctEq(window, Word(k)), selected.limbs, tables[b][k].limbs)
+				}
+				expMul(acc, acc, selected, scratch, m)
+			}
+		}
+	}
+	if !m.even {
+		// Multiplying by 1 takes us back out of Montgomery form
+		for i := 0; i < size; i++ {
+			scratch[i] = 0
+		}
+		scratch[0] = 1
+		montgomeryMul(acc.limbs, scratch, acc.limbs, selected.limbs, m)
+	}
+	return z.SetNat(acc)
+}
+
+// ExpPublic calculates z <- x^y mod m, for a public exponent y
+//
+// Unlike Exp, this is NOT constant-time in the exponent: it uses a sliding window,
+// skipping over zero bits, and leaks the value of y through timing. This is
+// only suitable for exponents that aren't secret, like an RSA public exponent.
+// The base x is still handled without leaking its value.
+//
+// The capacity of the resulting number matches the capacity of the modulus
+func (z *Nat) ExpPublic(x *Nat, y *Nat, m *Modulus) *Nat {
+	const window = 4
+	size := len(m.nat.limbs)
+	scratch := make([]Word, 2*size)
+	yLimbs := y.unaliasedLimbs(z)
+	bit := func(i int) Word {
+		return (yLimbs[i/_W] >> (i % _W)) & 1
+	}
+
+	acc := new(Nat).SetUint64(1)
+	acc.Mod(acc, m)
+	x1 := new(Nat).Mod(x, m)
+	if !m.even {
+		montgomeryMul(acc.limbs, m.reduction().rr, acc.limbs, scratch, m)
+		montgomeryMul(x1.limbs, m.reduction().rr, x1.limbs, scratch, m)
+	}
+	// table[k] = x^(2k + 1) mod m
+	var table [1 << (window - 1)]*Nat
+	table[0] = x1
+	x2 := new(Nat).SetNat(x1)
+	expMul(x2, x1, x1, scratch, m)
+	for k := 1; k < len(table); k++ {
+		table[k] = new(Nat).SetNat(x1)
+		expMul(table[k], table[k-1], x2, scratch, m)
+	}
+
+	// LEAK: the exponent
+	// OK: the caller has declared it to be public
+	for i := y.TrueLen() - 1; i >= 0; {
+		if bit(i) == 0 {
+			expSqr(acc, acc, scratch, m)
+			i--
+			continue
+		}
+		// Find the longest window ending in a 1 bit
+		l := i - window + 1
+		if l < 0 {
+			l = 0
+		}
+		for bit(l) == 0 {
+			l++
+		}
+		var w Word
+		for k := i; k >= l; k-- {
+			expSqr(acc, acc, scratch, m)
+			w = (w << 1) | bit(k)
+		}
+		expMul(acc, acc, table[w>>1], scratch, m)
+		i = l - 1
+	}
+	if !m.even {
+		// Multiplying by 1 takes us back out of Montgomery form
+		for i := 0; i < size; i++ {
+			x2.limbs[i] = 0
+		}
+		x2.limbs[0] = 1
+		montgomeryMul(acc.limbs, x2.limbs, acc.limbs, scratch, m)
+	}
+	return z.SetNat(acc)
 }
 
 // cmpEq compares two limbs (same size) returning 1 if x >= y, and 0 otherwise
@@ -1401,14 +3502,17 @@ func cmpZero(a []Word) Choice {
 // will be true.
 //
 // This function doesn't leak any information about the values involved, only
-// their announced lengths.
+// their announced lengths. The numbers are compared by value, so their announced
+// lengths can differ, with the shorter one being treated as if it were padded
+// with zeros. For example, 7 with a capacity of 3 bits is equal to 7 with a
+// capacity of 256 bits.
 func (z *Nat) Cmp(x *Nat) (Choice, Choice, Choice) {
 	// Rough Idea: Resize both slices to the maximum length, then compare
 	// using that length
 
 	maxBits := z.maxAnnounced(x)
-	zLimbs := z.resizedLimbs(maxBits)
-	xLimbs := x.resizedLimbs(maxBits)
+	zLimbs := z.extendedLimbs(maxBits)
+	xLimbs := x.extendedLimbs(maxBits)
 
 	eq := Choice(1)
 	geq := Choice(1)
@@ -1417,12 +3521,23 @@ func (z *Nat) Cmp(x *Nat) (Choice, Choice, Choice) {
 		eq &= eq_at_i
 		geq = (eq_at_i & geq) | ((1 ^ eq_at_i) & ctGt(zLimbs[i], xLimbs[i]))
 	}
-	if (eq & (1 ^ geq)) == 1 {
-		panic("eq but not geq")
-	}
+	// Since eq implies geq, exactly one of these results is true
 	return geq & (1 ^ eq), eq, 1 ^ geq
 }
 
+// CmpWord compares two natural numbers, returning the ordering as a single Word
+//
+// The result is 1 if z > x, 0 if z = x, and -1, i.e. all bits set, if z < x.
+// This is convenient to feed into further arithmetic, or masks, without needing
+// to combine the three choices returned by Cmp. For example, the result & 1 is
+// 1 exactly when z != x, and the top bit is set exactly when z < x.
+//
+// Like Cmp, this only leaks the announced lengths of z and x.
+func (z *Nat) CmpWord(x *Nat) Word {
+	gt, _, lt := z.Cmp(x)
+	return Word(gt) | -Word(lt)
+}
+
 // CmpMod compares this natural number with a modulus, returning results for (>, =, <)
 //
 // This doesn't leak anything about the values of the numbers, only their lengths.
@@ -1435,11 +3550,73 @@ func (z *Nat) CmpMod(m *Modulus) (Choice, Choice, Choice) {
 // This is equivalent to looking at the second choice returned by Cmp.
 // But, since looking at equality is so common, this function is provided
 // as an extra utility.
+//
+// Like Cmp, this compares values, so numbers with different announced lengths
+// can be equal. See EqTrimmed for a version making this explicit.
 func (z *Nat) Eq(y *Nat) Choice {
 	_, eq, _ := z.Cmp(y)
 	return eq
 }
 
+// EqTrimmed checks if z and y have the same value, ignoring their announced lengths
+//
+// This gives the same result as Eq, which already ignores capacity, but spells
+// this out at the call site, and never allocates: the limbs both numbers have
+// are compared directly, and the remaining limbs of the longer number must be 0.
+//
+// Only the announced lengths of z and y are leaked.
+func (z *Nat) EqTrimmed(y *Nat) Choice {
+	short, long := z.limbs, y.limbs
+	// LEAK: which of the two numbers is longer
+	// OK: the announced lengths are public
+	if len(short) > len(long) {
+		short, long = long, short
+	}
+	var diff Word
+	for i := 0; i < len(short); i++ {
+		diff |= short[i] ^ long[i]
+	}
+	return ctEq(diff, 0) & cmpZero(long[len(short):])
+}
+
+// uint64Limb returns the ith limb of x, when split into Words, or 0 past its end
+func uint64Limb(x uint64, i int) Word {
+	if i*_W >= 64 {
+		return 0
+	}
+	return Word(x >> (uint(i) * _W))
+}
+
+// CmpUint64 compares z with x, returning results for (>, =, <)
+//
+// Unlike Cmp, this doesn't need a temporary Nat to hold x, and won't allocate.
+// This only leaks the announced length of z.
+func (z *Nat) CmpUint64(x uint64) (Choice, Choice, Choice) {
+	size := len(z.limbs)
+	if size < 64/_W {
+		size = 64 / _W
+	}
+	eq := Choice(1)
+	geq := Choice(1)
+	for i := 0; i < size; i++ {
+		zi := z.limbOrZero(i)
+		xi := uint64Limb(x, i)
+		eqAtI := ctEq(zi, xi)
+		eq &= eqAtI
+		geq = (eqAtI & geq) | ((1 ^ eqAtI) & ctGt(zi, xi))
+	}
+	return geq & (1 ^ eq), eq, 1 ^ geq
+}
+
+// EqUint64 compares z with x, returning 1 if they're equal
+//
+// Like CmpUint64, this won't allocate, which makes it convenient for checks like
+// z == 1 in verification code.
+func (z *Nat) EqUint64(x uint64) Choice {
+	_, eq, _ := z.CmpUint64(x)
+	return eq
+}
+
 // EqZero compares z to 0.
 //
 // This is more efficient that calling Eq between this Nat and a zero Nat.
@@ -1534,7 +3711,10 @@ func topLimbs(a, b []Word) (Word, Word) {
 //
 // m0inv should be -invertModW(m[0]), which might have been precomputed in some
 // cases.
-func (z *Nat) invert(announced int, x []Word, m []Word, m0inv Word) Choice {
+//
+// If gcd is not nil, gcd(x, m) will be written into it. In general,
+// z will then satisfy zx = gcd(x, m) mod m.
+func (z *Nat) invert(announced int, x []Word, m []Word, m0inv Word, gcd []Word) Choice {
 	// This function follows Thomas Pornin's optimized GCD method:
 	//   https://eprint.iacr.org/2020/972
 	if len(x) != len(m) {
@@ -1687,6 +3867,9 @@ func (z *Nat) invert(announced int, x []Word, m []Word, m0inv Word) Choice {
 		shrVU(v, v, uint(remaining))
 	}
 
+	if gcd != nil {
+		copy(gcd, b[:size])
+	}
 	z.Resize(announced)
 	// Inversion succeeded if b, which contains gcd(x, m), is 1.
 	return cmpZero(b[1:]) & ctEq(1, b[0])
@@ -1715,7 +3898,7 @@ func (x *Nat) Coprime(y *Nat) Choice {
 	// We make b odd so that our calculations aren't messed up, but this doesn't affect
 	// our result
 	b[0] |= 1
-	invertible := scratch.invert(maxBits, a, b, -invertModW(b[0]))
+	invertible := scratch.invert(maxBits, a, b, -invertModW(b[0]), nil)
 
 	// If at least one of a or b is odd, then our GCD calculation will have been correct,
 	// otherwise, both are even, so we want to return false anyways.
@@ -1729,35 +3912,314 @@ func (x *Nat) IsUnit(m *Modulus) Choice {
 	return x.Coprime(&m.nat)
 }
 
-// modInverse calculates the inverse of a reduced x modulo m
+// trailingZeros returns the number of trailing zero bits in x, without leaking them
+//
+// If x is zero, this returns the number of bits in x.
+func trailingZeros(x []Word) Word {
+	var count Word
+	stillZero := Choice(1)
+	for i := 0; i < len(x); i++ {
+		for j := 0; j < _W; j++ {
+			stillZero &= ctEq((x[i]>>j)&1, 0)
+			count += Word(stillZero)
+		}
+	}
+	return count
+}
+
+// TrailingZeros returns the number of trailing zero bits in z, i.e. its 2-adic valuation
 //
-// This assumes that m is an odd number, but not that it's truncated
-// to its true size. This routine will only leak the announced sizes of
-// x and m.
+// This only leaks the announced length of z, with the result being returned as a Word,
+// so that it can be used in further constant-time operations, like RshSecret.
 //
-// We also assume that x is already reduced modulo m
-func (z *Nat) modInverse(x *Nat, m *Nat, m0inv Word) *Nat {
-	// Make sure that z doesn't alias either of m or x
-	xLimbs := x.unaliasedLimbs(z)
-	mLimbs := m.unaliasedLimbs(z)
-	z.invert(m.announced, xLimbs, mLimbs, m0inv)
+// If z is zero, this returns the announced length of z.
+func (z *Nat) TrailingZeros() Word {
+	count := trailingZeros(z.limbs)
+	announced := Word(z.announced)
+	return ctIfElse(ctGt(count, announced), announced, count)
+}
+
+// popCount returns the number of bits set in x
+//
+// We avoid bits.OnesCount, since its fallback implementation uses a lookup table.
+func popCount(x Word) Word {
+	y := uint64(x)
+	y -= (y >> 1) & 0x5555555555555555
+	y = (y & 0x3333333333333333) + ((y >> 2) & 0x3333333333333333)
+	y = (y + (y >> 4)) & 0x0F0F0F0F0F0F0F0F
+	return Word((y * 0x0101010101010101) >> 56)
+}
+
+// PopCount returns the number of bits set in z
+//
+// This only leaks the announced length of z, with the result being returned as a Word,
+// so that it can be used in further constant-time operations.
+func (z *Nat) PopCount() Word {
+	var count Word
+	for i := 0; i < len(z.limbs); i++ {
+		count += popCount(z.limbs[i])
+	}
+	return count
+}
+
+// ctShr calculates x <- x >> shift, without leaking the value of shift
+//
+// This works by conditionally applying each power of 2 shift, so only the length of x is leaked.
+// Shifts by at least the number of bits in x produce 0.
+func ctShr(x []Word, shift Word) {
+	scratch := make([]Word, len(x))
+	b := 0
+	for ; 1<<b <= len(x)*_W; b++ {
+		limbShift := (1 << b) / _W
+		for i := 0; i < len(x); i++ {
+			scratch[i] = 0
+			if i+limbShift < len(x) {
+				scratch[i] = x[i+limbShift]
+			}
+		}
+		if bitShift := uint(1<<b) % _W; bitShift != 0 {
+			shrVU(scratch, scratch, bitShift)
+		}
+		ctCondCopy(Choice((shift>>b)&1), x, scratch)
+	}
+	// Any remaining bits of shift shift out everything
+	for i := 0; i < len(x); i++ {
+		scratch[i] = 0
+	}
+	ctCondCopy(1^ctEq(shift>>b, 0), x, scratch)
+}
+
+// ctShl calculates x <- x << shift, without leaking the value of shift
+//
+// Like ctShr, only the length of x is leaked.
+func ctShl(x []Word, shift Word) {
+	scratch := make([]Word, len(x))
+	b := 0
+	for ; 1<<b <= len(x)*_W; b++ {
+		limbShift := (1 << b) / _W
+		for i := 0; i < len(x); i++ {
+			scratch[i] = 0
+			if i-limbShift >= 0 {
+				scratch[i] = x[i-limbShift]
+			}
+		}
+		if bitShift := uint(1<<b) % _W; bitShift != 0 {
+			shlVU(scratch, scratch, bitShift)
+		}
+		ctCondCopy(Choice((shift>>b)&1), x, scratch)
+	}
+	for i := 0; i < len(x); i++ {
+		scratch[i] = 0
+	}
+	ctCondCopy(1^ctEq(shift>>b, 0), x, scratch)
+}
+
+// RshSecret calculates z <- x >> shift, producing a certain number of bits
+//
+// Unlike Rsh, this doesn't leak the value of shift, only the announced length of x,
+// and cap. Shifting by at least the announced length of x produces 0.
+//
+// If cap < 0, the number of bits will be x.AnnouncedLen(), since the size of
+// the result can't depend on the secret shift.
+func (z *Nat) RshSecret(x *Nat, shift uint, cap int) *Nat {
+	if cap < 0 {
+		cap = x.announced
+	}
+	size := limbCount(x.announced)
+	if capSize := limbCount(cap); capSize > size {
+		size = capSize
+	}
+	xLimbs := x.extendedLimbs(x.announced)
+	zLimbs := make([]Word, size)
+	copy(zLimbs, xLimbs)
+	ctShr(zLimbs, Word(shift))
+
+	z.limbs = zLimbs
+	z.limbs = z.resizedLimbs(cap)
+	z.announced = cap
+	z.reduced = nil
+	return z
+}
+
+// LshSecret calculates z <- x << shift, producing a certain number of bits
+//
+// Unlike Lsh, this doesn't leak the value of shift, only the announced length of x,
+// and cap. Bits shifted past cap are discarded.
+//
+// If cap < 0, the number of bits will be x.AnnouncedLen(), since the size of
+// the result can't depend on the secret shift.
+func (z *Nat) LshSecret(x *Nat, shift uint, cap int) *Nat {
+	if cap < 0 {
+		cap = x.announced
+	}
+	xLimbs := x.extendedLimbs(x.announced)
+	zLimbs := make([]Word, limbCount(cap))
+	copy(zLimbs, xLimbs)
+	maskEnd(zLimbs, cap)
+	ctShl(zLimbs, Word(shift))
+
+	z.limbs = zLimbs
+	z.limbs = z.resizedLimbs(cap)
+	z.announced = cap
+	z.reduced = nil
+	return z
+}
+
+// GCD calculates z <- gcd(x, y)
+//
+// By convention, gcd(0, 0) = 0. This only leaks the announced sizes of x and y.
+//
+// The capacity of the resulting number is the maximum announced length of x and y.
+func (z *Nat) GCD(x *Nat, y *Nat) *Nat {
+	z.extendedGCD(x, y, false)
+	return z
+}
+
+// LCM calculates z <- lcm(x, y), the least common multiple of x and y
+//
+// By convention, lcm(x, 0) = 0. This only leaks the announced sizes of x and y.
+//
+// cap determines the number of bits to keep in the result. If cap < 0, then
+// the sum of the announced lengths of x and y is used, which is always enough.
+func (z *Nat) LCM(x *Nat, y *Nat, cap int) *Nat {
+	if cap < 0 {
+		cap = x.announced + y.announced
+	}
+	// lcm(x, y) = x * (y / gcd(x, y)), which avoids a larger division
+	gcd := new(Nat).GCD(x, y)
+	// When x = y = 0, this quotient is unspecified, but the product will still be 0
+	q, _ := new(Nat).DivRem(y, gcd, y.announced)
+	z.Mul(x, q, cap)
+	wipeTemporaries(z, gcd, q)
 	return z
 }
 
+// ExtendedGCD calculates z <- gcd(x, y), returning Bézout coefficients a, b
+//
+// These coefficients satisfy a * x + b * y = gcd(x, y). In absolute value,
+// they're bounded by y and x, respectively, and their capacity is the same as z.
+//
+// By convention, gcd(0, 0) = 0, with both coefficients being 0.
+// This only leaks the announced sizes of x and y.
+//
+// The capacity of the resulting number is the maximum announced length of x and y.
+func (z *Nat) ExtendedGCD(x *Nat, y *Nat) (*Nat, *Int, *Int) {
+	a, b := z.extendedGCD(x, y, true)
+	return z, a, b
+}
+
+// extendedGCD implements GCD and ExtendedGCD, only calculating the coefficients if asked to.
+func (z *Nat) extendedGCD(x *Nat, y *Nat, coefficients bool) (*Int, *Int) {
+	announced := x.maxAnnounced(y)
+	size := limbCount(announced)
+	if size == 0 {
+		z.Resize(0)
+		return new(Int), new(Int)
+	}
+	a := make([]Word, size)
+	copy(a, x.limbs)
+	b := make([]Word, size)
+	copy(b, y.limbs)
+
+	// Our GCD routine needs b to be odd, so we first remove the common powers
+	// of 2, which are the trailing zeros of a | b.
+	or := make([]Word, size)
+	for i := 0; i < size; i++ {
+		or[i] = a[i] | b[i]
+	}
+	k := trailingZeros(or)
+	ctShr(a, k)
+	ctShr(b, k)
+	// Now at least one of a or b is odd, unless both are 0.
+	swap := 1 ^ Choice(b[0]&1)
+	ctCondSwap(swap, a, b)
+	bothZero := cmpZero(b)
+	// gcd(0, 1) = 1 works fine as a stand in
+	b[0] |= Word(bothZero)
+
+	g := make([]Word, size)
+	v := new(Nat)
+	v.invert(announced, a, b, -invertModW(b[0]), g)
+	// This gives us v a = g mod b
+
+	var aCoeff, bCoeff *Int
+	if coefficients {
+		// The other coefficient is (g - va) / b. To avoid negative intermediate
+		// values, we calculate q = (va + b - g) / b, the coefficient being 1 - q.
+		t := new(Nat).Mul(v, &Nat{announced: announced, limbs: a}, 2*size*_W)
+		bExt := make([]Word, 2*size)
+		copy(bExt, b)
+		addVV(t.limbs, t.limbs, bExt)
+		copy(bExt, g)
+		subVV(t.limbs, t.limbs, bExt)
+		// divDouble needs an extra limb of room, even though q <= a
+		q := &Nat{announced: (size + 1) * _W, limbs: make([]Word, size+1)}
+		divDouble(t.limbs, b, q.limbs)
+
+		aCoeff = new(Int).SetNat(v)
+		bCoeff = new(Int).SetUint64(1)
+		bCoeff.Sub(bCoeff, new(Int).SetNat(q), announced)
+		aCoeff.Resize(announced)
+		// Undo the swap we may have done earlier
+		aCopy := aCoeff.Clone()
+		aCoeff.abs.CondAssign(swap, &bCoeff.abs)
+		aCoeff.sign = Choice(ctIfElse(swap, Word(bCoeff.sign), Word(aCoeff.sign)))
+		bCoeff.abs.CondAssign(swap, &aCopy.abs)
+		bCoeff.sign = Choice(ctIfElse(swap, Word(aCopy.sign), Word(bCoeff.sign)))
+		// When both are zero, so are the coefficients
+		zero := new(Nat).Resize(announced)
+		aCoeff.abs.CondAssign(bothZero, zero)
+		aCoeff.sign &= 1 ^ bothZero
+		bCoeff.abs.CondAssign(bothZero, zero)
+		bCoeff.sign &= 1 ^ bothZero
+	}
+
+	// Finally, we add back the common powers of 2, and handle gcd(0, 0) = 0
+	ctShl(g, k)
+	ctCondCopy(bothZero, g, make([]Word, size))
+	z.limbs = g
+	z.announced = announced
+	z.reduced = nil
+	return aCoeff, bCoeff
+}
+
 // ModInverse calculates z <- x^-1 mod m
 //
-// This will produce nonsense if the modulus is even.
+// This works for both odd and even moduli, but assumes that x is invertible.
+// If that isn't the case, z will contain an unspecified value. Use
+// ModInverseChecked to detect this situation.
 //
 // The capacity of the resulting number matches the capacity of the modulus
 func (z *Nat) ModInverse(x *Nat, m *Modulus) *Nat {
-	z.Mod(x, m)
+	z, _ = z.ModInverseChecked(x, m)
+	return z
+}
+
+// ModInverseChecked calculates z <- x^-1 mod m, returning 1 if x was invertible
+//
+// When gcd(x, m) != 1, no inverse exists, and 0 is returned, with z containing
+// an unspecified value. This is done in constant-time, using the divsteps
+// algorithm of Bernstein and Yang, only leaking the announced sizes of x and m,
+// as well as whether or not m is even.
+//
+// The capacity of the resulting number matches the capacity of the modulus
+func (z *Nat) ModInverseChecked(x *Nat, m *Modulus) (*Nat, Choice) {
+	xModM := new(Nat).Mod(x, m)
+	var ok Choice
 	if m.even {
-		z.modInverseEven(x, m)
+		z.modInverseEven(xModM, m)
+		// The even routine doesn't detect failure by itself, so we check the result
+		one := new(Nat).SetUint64(1)
+		one.Mod(one, m)
+		check := new(Nat).ModMul(xModM, z, m)
+		ok = check.Eq(one)
+		wipeTemporaries(z, check)
 	} else {
-		z.modInverse(z, &m.nat, m.m0inv)
+		ok = z.divstepsInverse(xModM, &m.nat)
 	}
 	z.reduced = m
-	return z
+	wipeTemporaries(z, xModM)
+	return z, ok
 }
 
 // divDouble divides x by d, outputtting the quotient in out, and a remainder
@@ -1797,6 +4259,9 @@ func divDouble(x []Word, d []Word, out []Word) []Word {
 			out[i] = oi
 		}
 	}
+	if zeroizing() {
+		clearLimbs(scratch)
+	}
 	return r
 }
 
@@ -1824,11 +4289,11 @@ func (z *Nat) modInverseEven(x *Nat, m *Modulus) *Nat {
 	// To find K, we can calculate (AM - 1) / X, and then subtract this from M, to get our inverse.
 	size := len(m.nat.limbs)
 	// We want to invert m modulo x, so we first calculate the reduced version, before inverting
-	var newZ Nat
-	newZ.limbs = divDouble(m.nat.limbs, x.limbs, nil)
-	newZ.modInverse(&newZ, x, -invertModW(x.limbs[0]))
-	inverseZero := cmpZero(newZ.limbs)
-	newZ.Mul(&newZ, &m.nat, 2*size*_W)
+	var mModX, inv, newZ Nat
+	mModX.limbs = divDouble(m.nat.limbs, x.limbs, nil)
+	inv.divstepsInverse(&mModX, x)
+	inverseZero := cmpZero(inv.limbs)
+	newZ.Mul(&inv, &m.nat, 2*size*_W)
 	newZ.limbs = newZ.resizedLimbs(_W * 2 * size)
 	subVW(newZ.limbs, newZ.limbs, 1)
 	divDouble(newZ.limbs, x.limbs, newZ.limbs)
@@ -1846,6 +4311,7 @@ func (z *Nat) modInverseEven(x *Nat, m *Modulus) *Nat {
 
 	z.limbs = newZ.limbs
 	z.Resize(m.nat.announced)
+	wipeTemporaries(z, &mModX, &inv)
 	return z
 }
 
@@ -1916,8 +4382,14 @@ func (z *Nat) tonelliShanks(x *Nat, p *Modulus) *Nat {
 
 // ModSqrt calculates the square root of x modulo p
 //
-// p must be an odd prime number, and x must actually have a square root
-// modulo p. The result is undefined if these conditions aren't satisfied
+// p must be a prime number, and x must actually have a square root
+// modulo p. The result is undefined if these conditions aren't satisfied.
+// ModSqrtChecked can be used when x might not have a square root.
+//
+// For p = 2, every number is its own square root. Other even moduli can't
+// be prime, and this function panics for them.
+//
+// For p = 3 mod 4, this uses a single exponentiation, and Tonelli-Shanks otherwise.
 //
 // This function will leak information about the value of p. This isn't intended
 // to be used in situations where the modulus isn't publicly known.
@@ -1926,10 +4398,66 @@ func (z *Nat) ModSqrt(x *Nat, p *Modulus) *Nat {
 		panic("Can't take square root mod 0")
 	}
 	if p.nat.limbs[0]&1 == 0 {
-		panic("Can't take square root mod an even number")
+		if p.nat.announced != 2 {
+			panic("Can't take square root mod an even number other than 2")
+		}
+		// x^2 = x mod 2
+		return z.Mod(x, p)
 	}
 	if p.nat.limbs[0]&0b11 == 0b11 {
 		return z.modSqrt3Mod4(x, p)
 	}
 	return z.tonelliShanks(x, p)
 }
+
+// ModSqrtChecked calculates the square root of x modulo p, returning 1 if it exists
+//
+// When x isn't a square modulo p, 0 is returned, and z contains an unspecified
+// value. Whether or not the root exists is determined in constant-time, by
+// squaring the result, rather than by branching on the value of x.
+//
+// Like ModSqrt, p must be a prime number, and its value may be leaked.
+func (z *Nat) ModSqrtChecked(x *Nat, p *Modulus) (*Nat, Choice) {
+	xModP := new(Nat).Mod(x, p)
+	z.ModSqrt(xModP, p)
+	ok := new(Nat).ModSqr(z, p).Eq(xModP)
+	return z, ok
+}
+
+// Jacobi returns the Jacobi symbol (x / m), which is one of -1, 0, or 1
+//
+// When m is prime, this is the Legendre symbol, indicating whether or not x is
+// a square modulo m. This doesn't leak anything beyond the announced size of x,
+// and the size of m.
+//
+// The modulus must be odd, and this function will panic otherwise.
+func (x *Nat) Jacobi(m *Modulus) int {
+	if m.even {
+		panic("Can't compute Jacobi symbol for an even modulus")
+	}
+	a := new(Nat).Mod(x, m).limbs
+	b := make([]Word, len(m.nat.limbs))
+	copy(b, m.nat.limbs)
+	scratch := make([]Word, len(b))
+
+	// We maintain (x / m) = (-1)^t (a / b), with b odd, using a binary GCD
+	// where each step removes at least one bit from a or b.
+	var t Word
+	for i := 0; i < 2*m.nat.announced; i++ {
+		aOdd := Choice(a[0] & 1)
+		// If a < b, then we use quadratic reciprocity to swap them,
+		// which flips the sign when a = b = 3 mod 4.
+		lt := aOdd & Choice(subVV(scratch, a, b))
+		t ^= Word(lt) & (a[0] & b[0] >> 1) & 1
+		ctCondSwap(lt, a, b)
+		// For odd a, (a / b) = (a - b / b)
+		subVV(scratch, a, b)
+		ctCondCopy(aOdd, a, scratch)
+		// a is now even, and (2 / b) = -1 exactly when b = 3, 5 mod 8
+		shrVU(a, a, 1)
+		t ^= ((b[0] >> 1) ^ (b[0] >> 2)) & 1
+	}
+	// a is now 0, and b = gcd(x, m), with the symbol being 0 unless this is 1
+	isOne := cmpZero(b[1:]) & ctEq(b[0], 1)
+	return int(isOne) * (1 - 2*int(t))
+}
@@ -1,12 +1,14 @@
 package saferith
 
 import (
+	crand "crypto/rand"
 	"math/big"
 	"testing"
 )
 
 var resultBig big.Int
 var resultNat Nat
+var resultInt int
 
 const _SIZE = 256
 
@@ -44,6 +46,15 @@ func modulus2048Even() []byte {
 	return bytes
 }
 
+// an even modulus of 3072 bits
+func modulus3072Even() []byte {
+	bytes := make([]byte, 384)
+	for i := 0; i < len(bytes); i++ {
+		bytes[i] = 0xFE
+	}
+	return bytes
+}
+
 // A 256 bit prime that's 3 mod 4
 func prime3Mod4() []byte {
 	bytes := make([]byte, 32)
@@ -353,6 +364,42 @@ func BenchmarkMulNat(b *testing.B) {
 	}
 }
 
+func _benchmarkMulNat(bits int, b *testing.B) {
+	b.StopTimer()
+
+	bytes := make([]byte, bits/8)
+	for i := 0; i < len(bytes); i++ {
+		bytes[i] = 0xAB
+	}
+	x := new(Nat).SetBytes(bytes)
+
+	b.StartTimer()
+	for n := 0; n < b.N; n++ {
+		var z Nat
+		z.Mul(x, x, 2*bits)
+		resultNat = z
+	}
+}
+
+func BenchmarkMul2048Nat(b *testing.B) {
+	_benchmarkMulNat(2048, b)
+}
+
+func BenchmarkMul4096Nat(b *testing.B) {
+	_benchmarkMulNat(4096, b)
+}
+
+func BenchmarkMul8192Nat(b *testing.B) {
+	_benchmarkMulNat(8192, b)
+}
+
+func BenchmarkMul4096NatPooled(b *testing.B) {
+	SetPooling(true)
+	defer SetPooling(false)
+	b.ReportAllocs()
+	_benchmarkMulNat(4096, b)
+}
+
 func _benchmarkModMulNat(m *Modulus, b *testing.B) {
 	b.StopTimer()
 
@@ -388,6 +435,56 @@ func BenchmarkLargeModMulNatEven(b *testing.B) {
 	_benchmarkModMulNat(m, b)
 }
 
+func Benchmark3072ModMulNatEven(b *testing.B) {
+	b.StopTimer()
+
+	m := ModulusFromBytes(modulus3072Even())
+	_benchmarkModMulNat(m, b)
+}
+
+func Benchmark3072ModMulBigEven(b *testing.B) {
+	b.StopTimer()
+
+	m := new(big.Int).SetBytes(modulus3072Even())
+	x := new(big.Int).SetBytes(ones())
+
+	b.StartTimer()
+	for n := 0; n < b.N; n++ {
+		var z big.Int
+		z.Mul(x, x)
+		z.Mod(&z, m)
+		resultBig = z
+	}
+}
+
+func _benchmarkModSqrNat(m *Modulus, b *testing.B) {
+	b.StopTimer()
+
+	x := new(Nat).SetBytes(ones())
+	x.Mod(x, m)
+
+	b.StartTimer()
+	for n := 0; n < b.N; n++ {
+		var z Nat
+		z.ModSqr(x, m)
+		resultNat = z
+	}
+}
+
+func BenchmarkLargeModSqrNat(b *testing.B) {
+	b.StopTimer()
+
+	m := ModulusFromBytes(modulus2048())
+	_benchmarkModSqrNat(m, b)
+}
+
+func BenchmarkLargeModSqrNatEven(b *testing.B) {
+	b.StopTimer()
+
+	m := ModulusFromBytes(modulus2048Even())
+	_benchmarkModSqrNat(m, b)
+}
+
 func _benchmarkModNat(m *Modulus, b *testing.B) {
 	b.StopTimer()
 
@@ -415,6 +512,21 @@ func BenchmarkLargeModNat(b *testing.B) {
 	_benchmarkModNat(m, b)
 }
 
+func Benchmark3072ModNatEven(b *testing.B) {
+	b.StopTimer()
+
+	m := ModulusFromBytes(modulus3072Even())
+	x := new(Nat).SetBytes(modulus3072Even())
+	x.Mul(x, x, -1)
+
+	b.StartTimer()
+	for n := 0; n < b.N; n++ {
+		var z Nat
+		z.Mod(x, m)
+		resultNat = z
+	}
+}
+
 func _benchmarkModInverseNat(m *Modulus, b *testing.B) {
 	b.StopTimer()
 
@@ -505,6 +617,118 @@ func BenchmarkLargeExpNatEven(b *testing.B) {
 	_benchmarkExpNat(m, b)
 }
 
+func BenchmarkLargeExpNatPooled(b *testing.B) {
+	b.StopTimer()
+	SetPooling(true)
+	defer SetPooling(false)
+	b.ReportAllocs()
+	m := ModulusFromBytes(modulus2048())
+	_benchmarkExpNat(m, b)
+}
+
+func BenchmarkLargeExpNatWithScratch(b *testing.B) {
+	b.StopTimer()
+
+	m := ModulusFromBytes(modulus2048())
+	x := new(Nat).SetBytes(ones())
+	y := new(Nat).SetBytes(ones())
+	x.Mod(x, m)
+	var z Nat
+	var s Scratch
+
+	b.ReportAllocs()
+	b.StartTimer()
+	for n := 0; n < b.N; n++ {
+		z.ExpWithScratch(x, y, m, &s)
+	}
+	resultNat = z
+}
+
+func BenchmarkLargeExpPublicNat(b *testing.B) {
+	b.StopTimer()
+	m := ModulusFromBytes(modulus2048())
+	x := new(Nat).SetBytes(ones())
+	e := new(Nat).SetUint64(65537)
+
+	b.StartTimer()
+	for n := 0; n < b.N; n++ {
+		var z Nat
+		z.ExpPublic(x, e, m)
+		resultNat = z
+	}
+}
+
+func _benchmarkMultiExpNat(m *Modulus, b *testing.B) {
+	b.StopTimer()
+
+	g := new(Nat).SetBytes(ones())
+	h := new(Nat).SetBytes(doubleOnes())
+	x := new(Nat).SetBytes(ones())
+	y := new(Nat).SetBytes(modulus2048())
+
+	b.StartTimer()
+	for n := 0; n < b.N; n++ {
+		var z Nat
+		z.MultiExp([]*Nat{g, h}, []*Nat{x, y}, m)
+		resultNat = z
+	}
+}
+
+func BenchmarkLargeMultiExpNat(b *testing.B) {
+	b.StopTimer()
+	m := ModulusFromBytes(modulus2048())
+	_benchmarkMultiExpNat(m, b)
+}
+
+func BenchmarkLargeMultiExpNatEven(b *testing.B) {
+	b.StopTimer()
+	m := ModulusFromBytes(modulus2048Even())
+	_benchmarkMultiExpNat(m, b)
+}
+
+func _benchmarkExpFixedBaseNat(m *Modulus, b *testing.B) {
+	b.StopTimer()
+
+	g := new(Nat).SetBytes(ones())
+	y := new(Nat).SetBytes(ones())
+	base := NewFixedBase(g, m, y.AnnouncedLen())
+
+	b.StartTimer()
+	for n := 0; n < b.N; n++ {
+		var z Nat
+		z.ExpFixedBase(base, y)
+		resultNat = z
+	}
+}
+
+func BenchmarkLargeExpFixedBaseNat(b *testing.B) {
+	b.StopTimer()
+	m := ModulusFromBytes(modulus2048())
+	_benchmarkExpFixedBaseNat(m, b)
+}
+
+func BenchmarkLargeExpFixedBaseNatEven(b *testing.B) {
+	b.StopTimer()
+	m := ModulusFromBytes(modulus2048Even())
+	_benchmarkExpFixedBaseNat(m, b)
+}
+
+func BenchmarkLargePedersenCommitNat(b *testing.B) {
+	b.StopTimer()
+
+	m := ModulusFromBytes(modulus2048())
+	g := new(Nat).SetBytes(ones())
+	h := new(Nat).SetBytes(doubleOnes())
+	x := new(Nat).SetBytes(ones())
+	r := new(Nat).SetBytes(modulus2048())
+	params := NewPedersenParams(m, g, h, r.AnnouncedLen())
+
+	b.StartTimer()
+	for n := 0; n < b.N; n++ {
+		resultNat = *params.Commit(x, r)
+	}
+}
+
 func BenchmarkSetBytesNat(b *testing.B) {
 	b.StopTimer()
 
@@ -563,6 +787,30 @@ func BenchmarkModSqrt1Mod4Nat(b *testing.B) {
 	}
 }
 
+func BenchmarkLargeJacobiNat(b *testing.B) {
+	b.StopTimer()
+
+	x := new(Nat).SetBytes(ones())
+	m := ModulusFromBytes(modulus2048())
+
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		resultInt = x.Jacobi(m)
+	}
+}
+
+func BenchmarkLargeJacobiBig(b *testing.B) {
+	b.StopTimer()
+
+	x := new(big.Int).SetBytes(ones())
+	m := new(big.Int).SetBytes(modulus2048())
+
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		resultInt = big.Jacobi(x, m)
+	}
+}
+
 func _benchmarkDivNat(m *Modulus, b *testing.B) {
 	b.StopTimer()
 
@@ -589,3 +837,108 @@ func BenchmarkLargeDivNat(b *testing.B) {
 	m := ModulusFromBytes(modulus2048())
 	_benchmarkDivNat(m, b)
 }
+
+func BenchmarkProbablyPrimeNat(b *testing.B) {
+	b.StopTimer()
+	p, _ := new(Nat).SetHex("FFFFFFFF00000001000000000000000000000000FFFFFFFFFFFFFFFFFFFFFFFF")
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		p.ProbablyPrime(20, crand.Reader)
+	}
+}
+
+func BenchmarkProbablyPrimeBPSWNat(b *testing.B) {
+	b.StopTimer()
+	p, _ := new(Nat).SetHex("FFFFFFFF00000001000000000000000000000000FFFFFFFFFFFFFFFFFFFFFFFF")
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		p.ProbablyPrimeBPSW()
+	}
+}
+
+func BenchmarkProbablyPrimeBig(b *testing.B) {
+	b.StopTimer()
+	p, _ := new(big.Int).SetString("FFFFFFFF00000001000000000000000000000000FFFFFFFFFFFFFFFFFFFFFFFF", 16)
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		p.ProbablyPrime(20)
+	}
+}
+
+func BenchmarkLargeTrialDivideNat(b *testing.B) {
+	b.StopTimer()
+	p := new(Nat).SetBytes(modulus2048())
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		p.TrialDivide()
+	}
+}
+
+func BenchmarkCRTExpNat(b *testing.B) {
+	b.StopTimer()
+	p, _ := crand.Prime(crand.Reader, 1024)
+	q, _ := crand.Prime(crand.Reader, 1024)
+	crt, _ := NewCRT(ModulusFromBig(p), ModulusFromBig(q))
+	pMinus1 := ModulusFromNat(new(Nat).Sub(crt.P().Nat(), new(Nat).SetUint64(1), -1))
+	qMinus1 := ModulusFromNat(new(Nat).Sub(crt.Q().Nat(), new(Nat).SetUint64(1), -1))
+	x := new(Nat).SetBytes(ones())
+	e := new(Nat).SetBytes(ones())
+	ep := new(Nat).Mod(e, pMinus1)
+	eq := new(Nat).Mod(e, qMinus1)
+
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		resultNat = *crt.ExpCRT(x, ep, eq)
+	}
+}
+
+func BenchmarkLargeBatchModMulNat(b *testing.B) {
+	b.StopTimer()
+	m := ModulusFromBytes(modulus2048())
+	x := make([]*Nat, 100)
+	out := make([]*Nat, len(x))
+	for i := range x {
+		x[i] = new(Nat).SetBytes(ones())
+		x[i].Mod(x[i], m)
+	}
+
+	b.StartTimer()
+	for n := 0; n < b.N; n++ {
+		BatchModMul(out, x, x, m)
+	}
+}
+
+func BenchmarkLargeLoopModMulNat(b *testing.B) {
+	b.StopTimer()
+	m := ModulusFromBytes(modulus2048())
+	x := make([]*Nat, 100)
+	out := make([]*Nat, len(x))
+	for i := range x {
+		x[i] = new(Nat).SetBytes(ones())
+		x[i].Mod(x[i], m)
+		out[i] = new(Nat)
+	}
+
+	b.StartTimer()
+	for n := 0; n < b.N; n++ {
+		for i := range x {
+			out[i].ModMul(x[i], x[i], m)
+		}
+	}
+}
+
+func BenchmarkModMul25519Nat(b *testing.B) {
+	b.StopTimer()
+	generic, _ := ModulusFromHex("7FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFED")
+	_benchmarkModMulNat(generic, b)
+}
+
+func BenchmarkModMul25519NatNearPowerOfTwo(b *testing.B) {
+	b.StopTimer()
+	_benchmarkModMulNat(ModulusNearPowerOfTwo(255, 19), b)
+}
+
+func BenchmarkLargeModMulNatNearPowerOfTwo(b *testing.B) {
+	b.StopTimer()
+	_benchmarkModMulNat(ModulusNearPowerOfTwo(2048, 159), b)
+}
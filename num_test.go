@@ -2,6 +2,9 @@ package saferith
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
 	"math/big"
 	"math/rand"
 	"reflect"
@@ -78,6 +81,77 @@ func TestSetBytesRoundTrip(t *testing.T) {
 	}
 }
 
+func reversed(data []byte) []byte {
+	out := make([]byte, len(data))
+	for i := 0; i < len(data); i++ {
+		out[i] = data[len(data)-1-i]
+	}
+	return out
+}
+
+func testSetBytesLEMatchesSetBytes(expected []byte) bool {
+	x := new(Nat).SetBytesLE(expected)
+	y := new(Nat).SetBytes(reversed(expected))
+	if !x.checkInvariants() || x.Eq(y) != 1 || x.AnnouncedLen() != y.AnnouncedLen() {
+		return false
+	}
+	return bytes.Equal(expected, x.BytesLE()) && bytes.Equal(reversed(expected), x.Bytes())
+}
+
+func TestSetBytesLEMatchesSetBytes(t *testing.T) {
+	err := quick.Check(testSetBytesLEMatchesSetBytes, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFillBytesLEExamples(t *testing.T) {
+	x := new(Nat).SetUint64(0xAABBCCDD)
+	expected := []byte{0xDD, 0xCC, 0xBB, 0xAA, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	actual := x.FillBytesLE(make([]byte, 10))
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	expected = []byte{0xDD, 0xCC}
+	actual = x.FillBytesLE(make([]byte, 2))
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+}
+
+func testSetBytesFromReaderMatchesSetBytes(expected []byte) bool {
+	x, err := new(Nat).SetBytesFromReader(bytes.NewReader(expected), len(expected))
+	if err != nil || !x.checkInvariants() {
+		return false
+	}
+	return x.Eq(new(Nat).SetBytes(expected)) == 1 && x.AnnouncedLen() == 8*len(expected)
+}
+
+func TestSetBytesFromReaderMatchesSetBytes(t *testing.T) {
+	err := quick.Check(testSetBytesFromReaderMatchesSetBytes, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSetBytesFromReaderExamples(t *testing.T) {
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	x, err := new(Nat).SetBytesFromReader(bytes.NewReader(data), len(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, x.Bytes()) {
+		t.Errorf("%+v != %+v", data, x.Bytes())
+	}
+	_, err = new(Nat).SetBytesFromReader(bytes.NewReader(data), len(data)+1)
+	if err == nil {
+		t.Errorf("expected error for short reader")
+	}
+}
+
 func testNatMarshalBinaryRoundTrip(x Nat) bool {
 	out, err := x.MarshalBinary()
 	if err != nil {
@@ -88,7 +162,7 @@ func testNatMarshalBinaryRoundTrip(x Nat) bool {
 	if err != nil {
 		return false
 	}
-	return x.Eq(y) == 1
+	return x.Eq(y) == 1 && x.AnnouncedLen() == y.AnnouncedLen()
 }
 
 func TestNatMarshalBinaryRoundTrip(t *testing.T) {
@@ -109,7 +183,15 @@ func testModulusMarshalBinaryRoundTrip(x Modulus) bool {
 		return false
 	}
 	_, eq, _ := x.Cmp(y)
-	return eq == 1
+	if eq != 1 || x.m0inv != y.m0inv || x.leading != y.leading || x.even != y.even {
+		return false
+	}
+	// The reduction constant should have been included, rather than calculated later
+	r, _ := y.precomputed.Load().(*modulusReduction)
+	if r == nil {
+		return false
+	}
+	return cmpEq(r.rr, x.reduction().rr) == 1 && cmpEq(r.mu, x.reduction().mu) == 1
 }
 
 func TestModulusMarshalBinaryRoundTrip(t *testing.T) {
@@ -119,6 +201,152 @@ func TestModulusMarshalBinaryRoundTrip(t *testing.T) {
 	}
 }
 
+func TestModulusUnmarshalBinaryInvalid(t *testing.T) {
+	m := new(Modulus)
+	invalid := [][]byte{
+		// unknown version
+		{0, 0, 0, 0, 8, 13, 0, 0, 0, 0, 0, 0, 0, 0},
+		// leading zeros
+		{binaryVersion, 0, 0, 0, 8, 1, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF},
+		// missing constant
+		{binaryVersion, 0, 0, 0, 4, 13},
+		// wrong constant
+		{binaryVersion, 0, 0, 0, 4, 13, 0, 0, 0, 0, 0, 0, 0, 1},
+	}
+	for _, data := range invalid {
+		if m.UnmarshalBinary(data) == nil {
+			t.Errorf("expected error unmarshalling %+v", data)
+		}
+	}
+}
+
+func TestModulusUnmarshalBinaryReductionConstant(t *testing.T) {
+	for _, m := range []*Modulus{ModulusFromUint64(13), ModulusFromUint64(14), ModulusFromUint64(1)} {
+		data, _ := m.MarshalBinary()
+		wordBytes := _W / 8
+		constantStart := len(data) - wordBytes*len(m.reduction().rr) - wordBytes*len(m.reduction().mu)
+		// Without the constant, it's calculated on first use instead
+		y := new(Modulus)
+		if err := y.UnmarshalBinary(data[:constantStart-1]); err != nil {
+			t.Fatal(err)
+		}
+		if r, _ := y.precomputed.Load().(*modulusReduction); r != nil {
+			t.Errorf("%+v should not be precomputed", y)
+		}
+		if !testModulusEq(m, y) {
+			t.Errorf("%+v != %+v", m, y)
+		}
+		// A constant for a different Word size is ignored
+		other := append([]byte{}, data[:constantStart-1]...)
+		other = append(other, byte(12-wordBytes))
+		otherLen := (m.BitLen() + 8*(12-wordBytes) - 1) / (8 * (12 - wordBytes))
+		if m.even {
+			otherLen++
+		}
+		other = append(other, make([]byte, (12-wordBytes)*otherLen)...)
+		if err := y.UnmarshalBinary(other); err != nil {
+			t.Fatal(err)
+		}
+		if !testModulusEq(m, y) {
+			t.Errorf("%+v != %+v", m, y)
+		}
+		// Any change to the constant should be detected
+		for i := constantStart; i < len(data); i++ {
+			tampered := append([]byte{}, data...)
+			tampered[i] ^= 1
+			if y.UnmarshalBinary(tampered) == nil {
+				t.Errorf("expected error unmarshalling %+v", tampered)
+			}
+		}
+		if y.UnmarshalBinary(data[:len(data)-1]) == nil {
+			t.Errorf("expected error unmarshalling %+v", data[:len(data)-1])
+		}
+	}
+}
+
+// testModulusEq checks that two moduli have the same value, and reduction constants
+func testModulusEq(m *Modulus, n *Modulus) bool {
+	_, eq, _ := m.Cmp(n)
+	if eq != 1 {
+		return false
+	}
+	return cmpEq(m.reduction().rr, n.reduction().rr) == 1 && cmpEq(m.reduction().mu, n.reduction().mu) == 1
+}
+
+func TestModulusPrecomputeIsLazy(t *testing.T) {
+	for _, hex := range []string{"FFFFFFFF00000001000000000000000000000000FFFFFFFFFFFFFFFFFFFFFFFF", "ABCDEF0123456789ABCDEF00"} {
+		m, _ := ModulusFromHex(hex)
+		if r, _ := m.precomputed.Load().(*modulusReduction); r != nil {
+			t.Errorf("%+v should not be precomputed", m)
+		}
+		m.Precompute()
+		r, _ := m.precomputed.Load().(*modulusReduction)
+		if r == nil {
+			t.Fatalf("%+v should be precomputed", m)
+		}
+		if r.check(m) != 1 {
+			t.Errorf("%+v has invalid constants", m)
+		}
+		// Later calls reuse the same constants
+		m.Precompute()
+		if m.reduction() != r {
+			t.Errorf("%+v was precomputed twice", m)
+		}
+	}
+}
+
+func TestModulusLazyPrecomputeConcurrent(t *testing.T) {
+	m, _ := ModulusFromHex("FFFFFFFF00000001000000000000000000000000FFFFFFFFFFFFFFFFFFFFFFFF")
+	x := new(Nat).SetUint64(0xDEADBEEF)
+	expected := new(Nat).Exp(x, x, ModulusFromNat(m.Nat()))
+	results := make(chan *Nat)
+	for i := 0; i < 8; i++ {
+		go func() {
+			results <- new(Nat).Exp(x, x, m)
+		}()
+	}
+	for i := 0; i < 8; i++ {
+		if actual := <-results; actual.Eq(expected) != 1 {
+			t.Errorf("%+v != %+v", expected, actual)
+		}
+	}
+}
+
+func testNatJSONRoundTrip(x Nat) bool {
+	out, err := json.Marshal(&x)
+	if err != nil {
+		return false
+	}
+	y := new(Nat)
+	err = json.Unmarshal(out, y)
+	if err != nil {
+		return false
+	}
+	return x.Eq(y) == 1 && x.AnnouncedLen() == y.AnnouncedLen()
+}
+
+func TestNatJSONRoundTrip(t *testing.T) {
+	err := quick.Check(testNatJSONRoundTrip, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNatJSONExamples(t *testing.T) {
+	x := new(Nat).SetUint64(0xABC).Resize(12)
+	expected := `"ABC"`
+	actual, _ := json.Marshal(x)
+	if expected != string(actual) {
+		t.Errorf("%+v != %+v", expected, string(actual))
+	}
+	if json.Unmarshal([]byte(`"ABG"`), x) == nil {
+		t.Errorf("expected error for invalid hex")
+	}
+	if json.Unmarshal([]byte(`123`), x) == nil {
+		t.Errorf("expected error for non string")
+	}
+}
+
 func testAddZeroIdentity(n Nat) bool {
 	if !n.checkInvariants() {
 		return false
@@ -152,8 +380,8 @@ func testAddCommutative(a Nat, b Nat) bool {
 	}
 	var aPlusB, bPlusA Nat
 	for _, x := range []int{256, 128, 64, 32, 8} {
-		aPlusB.Add(&a, &b, x)
-		bPlusA.Add(&b, &a, x)
+		aPlusB.WrappingAdd(&a, &b, x)
+		bPlusA.WrappingAdd(&b, &a, x)
 		if !(aPlusB.checkInvariants() && bPlusA.checkInvariants()) {
 			return false
 		}
@@ -192,16 +420,64 @@ func TestCondAssign(t *testing.T) {
 	}
 }
 
+func testCondSwap(a Nat, b Nat) bool {
+	for _, yes := range []Choice{0, 1} {
+		x := new(Nat).SetNat(&a)
+		y := new(Nat).SetNat(&b)
+		x.CondSwap(yes, y)
+		if !(x.checkInvariants() && y.checkInvariants()) {
+			return false
+		}
+		expectedX, expectedY := &a, &b
+		if yes == 1 {
+			expectedX, expectedY = &b, &a
+		}
+		if x.Eq(expectedX) != 1 || y.Eq(expectedY) != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCondSwap(t *testing.T) {
+	err := quick.Check(testCondSwap, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testSelect(a Nat, b Nat) bool {
+	shouldBeA := new(Nat).Select(1, &a, &b)
+	shouldBeB := new(Nat).Select(0, &a, &b)
+	if !(shouldBeA.checkInvariants() && shouldBeB.checkInvariants()) {
+		return false
+	}
+	if shouldBeA.Eq(&a) != 1 || shouldBeB.Eq(&b) != 1 {
+		return false
+	}
+	// Selecting into one of the arguments should work too
+	aliased := new(Nat).SetNat(&b)
+	aliased.Select(1, &a, aliased)
+	return aliased.Eq(&a) == 1
+}
+
+func TestSelect(t *testing.T) {
+	err := quick.Check(testSelect, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
 func testAddAssociative(a Nat, b Nat, c Nat) bool {
 	if !(a.checkInvariants() && b.checkInvariants() && c.checkInvariants()) {
 		return false
 	}
 	var order1, order2 Nat
 	for _, x := range []int{256, 128, 64, 32, 8} {
-		order1 = *order1.Add(&a, &b, x)
-		order1.Add(&order1, &c, x)
-		order2 = *order2.Add(&b, &c, x)
-		order2.Add(&a, &order2, x)
+		order1 = *order1.WrappingAdd(&a, &b, x)
+		order1.WrappingAdd(&order1, &c, x)
+		order2 = *order2.WrappingAdd(&b, &c, x)
+		order2.WrappingAdd(&a, &order2, x)
 		if !(order1.checkInvariants() && order2.checkInvariants()) {
 			return false
 		}
@@ -312,8 +588,8 @@ func testMulCommutative(a Nat, b Nat) bool {
 	}
 	var aTimesB, bTimesA Nat
 	for _, x := range []int{256, 128, 64, 32, 8} {
-		aTimesB.Mul(&a, &b, x)
-		bTimesA.Mul(&b, &a, x)
+		aTimesB.WrappingMul(&a, &b, x)
+		bTimesA.WrappingMul(&b, &a, x)
 		if !(aTimesB.checkInvariants() && bTimesA.checkInvariants()) {
 			return false
 		}
@@ -337,10 +613,10 @@ func testMulAssociative(a Nat, b Nat, c Nat) bool {
 	}
 	var order1, order2 Nat
 	for _, x := range []int{256, 128, 64, 32, 8} {
-		order1 = *order1.Mul(&a, &b, x)
-		order1.Mul(&order1, &c, x)
-		order2 = *order2.Mul(&b, &c, x)
-		order2.Mul(&a, &order2, x)
+		order1 = *order1.WrappingMul(&a, &b, x)
+		order1.WrappingMul(&order1, &c, x)
+		order2 = *order2.WrappingMul(&b, &c, x)
+		order2.WrappingMul(&a, &order2, x)
 		if !(order1.checkInvariants() && order2.checkInvariants()) {
 			return false
 		}
@@ -469,6 +745,138 @@ func TestModAddModSubInverse(t *testing.T) {
 	}
 }
 
+func testModDoubleTripleMatchModAdd(a Nat, m Modulus) bool {
+	double := new(Nat).ModDouble(&a, &m)
+	if !double.checkInvariants() || double.Eq(new(Nat).ModAdd(&a, &a, &m)) != 1 {
+		return false
+	}
+	triple := new(Nat).ModTriple(&a, &m)
+	if !triple.checkInvariants() || triple.Eq(new(Nat).ModAdd(double, &a, &m)) != 1 {
+		return false
+	}
+	// Aliasing the input should work too
+	aliased := new(Nat).SetNat(&a)
+	return aliased.ModTriple(aliased, &m).Eq(triple) == 1
+}
+
+func TestModDoubleTripleMatchModAdd(t *testing.T) {
+	err := quick.Check(testModDoubleTripleMatchModAdd, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestModDoubleExamples(t *testing.T) {
+	// With a modulus filling every bit of its limbs, doubling carries out of the top limb
+	m := ModulusFromBytes([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFB})
+	x := new(Nat).ModNeg(new(Nat).SetUint64(1), m)
+	// 2(m - 1) = m - 2, and 3(m - 1) = m - 3 mod m
+	expected := new(Nat).ModNeg(new(Nat).SetUint64(2), m)
+	actual := new(Nat).ModDouble(x, m)
+	if expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	expected = new(Nat).ModNeg(new(Nat).SetUint64(3), m)
+	actual = new(Nat).ModTriple(x, m)
+	if expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+}
+
+func testModUint64MatchesNat(a Nat, y uint64, m Modulus) bool {
+	yNat := new(Nat).SetUint64(y)
+	sum := new(Nat).ModAddUint64(&a, y, &m)
+	if !sum.checkInvariants() || sum.Eq(new(Nat).ModAdd(&a, yNat, &m)) != 1 {
+		return false
+	}
+	product := new(Nat).ModMulUint64(&a, y, &m)
+	if !product.checkInvariants() || product.Eq(new(Nat).ModMul(&a, yNat, &m)) != 1 {
+		return false
+	}
+	// Aliasing the input should work too
+	aliased := new(Nat).SetNat(&a)
+	return aliased.ModMulUint64(aliased, y, &m).Eq(product) == 1
+}
+
+func TestModUint64MatchesNat(t *testing.T) {
+	err := quick.Check(testModUint64MatchesNat, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestModUint64Examples(t *testing.T) {
+	m := ModulusFromUint64(13)
+	x := new(Nat).SetUint64(12)
+	// 2^64 - 1 = 2 mod 13, and 12 + 2 = 1 mod 13
+	expected := new(Nat).SetUint64(1)
+	actual := new(Nat).ModAddUint64(x, math.MaxUint64, m)
+	if expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	// 12 * 2 = 11 mod 13
+	actual = new(Nat).ModMulUint64(x, math.MaxUint64, m)
+	expected.SetUint64(11)
+	if expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	large := ModulusFromBytes(modulus2048())
+	x = new(Nat).ModNeg(new(Nat).SetUint64(1), large)
+	expected = new(Nat).ModNeg(new(Nat).SetUint64(math.MaxUint64), large)
+	actual = new(Nat).ModMulUint64(x, math.MaxUint64, large)
+	if expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+}
+
+func testDivUint64MatchesBig(a Nat, d uint64) bool {
+	if d == 0 {
+		return true
+	}
+	dBig := new(big.Int).SetUint64(d)
+	expectedQ, expectedR := new(big.Int).DivMod(a.Big(), dBig, new(big.Int))
+	q, r := new(Nat).DivUint64(&a, d)
+	if !q.checkInvariants() || q.AnnouncedLen() != a.AnnouncedLen() {
+		return false
+	}
+	if q.Big().Cmp(expectedQ) != 0 || r != expectedR.Uint64() {
+		return false
+	}
+	return a.ModUint64(d) == r
+}
+
+func TestDivUint64MatchesBig(t *testing.T) {
+	err := quick.Check(testDivUint64MatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDivUint64Examples(t *testing.T) {
+	x := new(Nat).SetUint64(math.MaxUint64)
+	q, r := new(Nat).DivUint64(x, math.MaxUint64)
+	expected := new(Nat).SetUint64(1)
+	if expected.Eq(q) != 1 || r != 0 {
+		t.Errorf("%+v != %+v", expected, q)
+	}
+	// 2^64 - 1 = 10 * 1844674407370955161 + 5
+	q, r = x.DivUint64(x, 10)
+	expected.SetUint64(1844674407370955161)
+	if expected.Eq(q) != 1 || r != 5 {
+		t.Errorf("%+v != %+v", expected, q)
+	}
+	// 2^128 - 1 = (2^64 + 1)(2^64 - 1)
+	x = new(Nat).SetBytes([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF})
+	q, r = new(Nat).DivUint64(x, math.MaxUint64)
+	expected.SetBytes([]byte{0x1, 0, 0, 0, 0, 0, 0, 0, 0x1})
+	if expected.Eq(q) != 1 || r != 0 {
+		t.Errorf("%+v != %+v", expected, q)
+	}
+	if r := x.ModUint64(1 << 63); r != 1<<63-1 {
+		t.Errorf("%+v != %+v", uint64(1<<63-1), r)
+	}
+}
+
 func testModMulCommutative(a Nat, b Nat, m Modulus) bool {
 	if !(a.checkInvariants() && b.checkInvariants()) {
 		return false
@@ -511,96 +919,347 @@ func TestModMulAssociative(t *testing.T) {
 	}
 }
 
-func testModInverseMultiplication(a Nat) bool {
-	if !a.checkInvariants() {
+func testModMulMatchesBig(a Nat, b Nat, m Modulus) bool {
+	actual := new(Nat).ModMul(&a, &b, &m)
+	if !actual.checkInvariants() {
 		return false
 	}
-	var scratch, one, zero Nat
-	zero.SetUint64(0)
-	one.SetUint64(1)
-	for _, x := range []uint64{3, 5, 7, 13, 19, 47, 97} {
-		m := ModulusFromUint64(x)
-		scratch.Mod(&a, m)
-		if scratch.Eq(&zero) == 1 {
-			continue
-		}
-		scratch.ModInverse(&a, m)
-		scratch.ModMul(&scratch, &a, m)
-		if !scratch.checkInvariants() {
-			return false
-		}
-		if scratch.Eq(&one) != 1 {
-			return false
-		}
-	}
-	return true
+	expected := new(big.Int).Mul(a.Big(), b.Big())
+	expected.Mod(expected, m.Big())
+	return actual.Big().Cmp(expected) == 0
 }
 
-func TestModInverseMultiplication(t *testing.T) {
-	err := quick.Check(testModInverseMultiplication, &quick.Config{})
+func TestModMulMatchesBig(t *testing.T) {
+	err := quick.Check(testModMulMatchesBig, &quick.Config{})
 	if err != nil {
 		t.Error(err)
 	}
 }
 
-func testModInverseMinusOne(a Nat) bool {
-	if !a.checkInvariants() {
-		return false
-	}
-	// Clear out the lowest bit
-	if len(a.limbs) > 0 {
-		a.limbs[0] &= ^Word(1)
-	}
-	if a.EqZero() == 1 {
-		return true
-	}
-	var one Nat
-	one.SetUint64(1)
-	z := new(Nat).Add(&a, &one, -1)
-	m := ModulusFromNat(z)
-	z.ModInverse(&a, m)
-	if !z.checkInvariants() {
+func testBarrettMatchesBig(a Nat, b Nat, m Modulus) bool {
+	// Force the modulus to be even, so that Barrett reduction gets used
+	even := ModulusFromNat(new(Nat).Lsh(&m.nat, 1, -1))
+	x := new(Nat).WrappingMul(&a, &b, 2*even.BitLen())
+	actual := new(Nat).Mod(x, even)
+	if !actual.checkInvariants() {
 		return false
 	}
-	return z.Eq(&a) == 1
+	expected := new(big.Int).Mod(x.Big(), even.Big())
+	return actual.Big().Cmp(expected) == 0
 }
 
-func TestModInverseMinusOne(t *testing.T) {
-	err := quick.Check(testModInverseMinusOne, &quick.Config{})
+func TestBarrettMatchesBig(t *testing.T) {
+	err := quick.Check(testBarrettMatchesBig, &quick.Config{})
 	if err != nil {
 		t.Error(err)
 	}
 }
 
-func testModInverseEvenMinusOne(a Nat) bool {
-	if !a.checkInvariants() {
-		return false
+func TestKaratsubaMatchesBig(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for _, limbs := range []int{karatsubaThreshold - 1, karatsubaThreshold, 2*karatsubaThreshold + 1, 5*karatsubaThreshold + 3} {
+		for trial := 0; trial < 8; trial++ {
+			xBytes := make([]byte, limbs*_S)
+			yBytes := make([]byte, limbs*_S-1-trial)
+			r.Read(xBytes)
+			r.Read(yBytes)
+			// Include the extreme values, which exercise all of the carries
+			if trial == 0 {
+				for i := range xBytes {
+					xBytes[i] = 0xFF
+				}
+				for i := range yBytes {
+					yBytes[i] = 0xFF
+				}
+			}
+			x := new(Nat).SetBytes(xBytes)
+			y := new(Nat).SetBytes(yBytes)
+			expected := new(big.Int).Mul(x.Big(), y.Big())
+			actual := new(Nat).Mul(x, y, -1)
+			if !actual.checkInvariants() {
+				t.Errorf("invariants failed for %d limbs", limbs)
+			}
+			if actual.Big().Cmp(expected) != 0 {
+				t.Errorf("%+v != %+v", expected, actual)
+			}
+			// Truncated products should also match
+			cap := x.AnnouncedLen()
+			actual.WrappingMul(x, y, cap)
+			expected.Mod(expected, new(big.Int).Lsh(big.NewInt(1), uint(cap)))
+			if actual.Big().Cmp(expected) != 0 {
+				t.Errorf("%+v != %+v", expected, actual)
+			}
+		}
 	}
-	// Set the lowest bit
-	if len(a.limbs) != 0 {
-		a.limbs[0] |= 1
+}
+
+func TestKaratsubaMatchesSchoolbookWithCap(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for _, limbs := range []int{karatsubaThreshold, 2*karatsubaThreshold + 1} {
+		xBytes := make([]byte, limbs*_S)
+		yBytes := make([]byte, limbs*_S)
+		r.Read(xBytes)
+		r.Read(yBytes)
+		x := new(Nat).SetBytes(xBytes)
+		y := new(Nat).SetBytes(yBytes)
+		for _, cap := range []int{0, 1, 7, _W, 3*_W + 5, 2*limbs*_W + 100} {
+			actual := new(Nat).WrappingMul(x, y, cap)
+			// Resizing the inputs to a small cap puts them below the threshold
+			schoolbook := new(Nat).WrappingMul(new(Nat).SetNat(x).Resize(cap), new(Nat).SetNat(y).Resize(cap), cap)
+			if !actual.checkInvariants() || actual.AnnouncedLen() != cap || len(actual.limbs) != len(schoolbook.limbs) {
+				t.Errorf("cap %d: invalid result %+v", cap, actual)
+			}
+			if actual.Eq(schoolbook) != 1 {
+				t.Errorf("%+v != %+v", schoolbook, actual)
+			}
+			expected := new(big.Int).Mul(x.Big(), y.Big())
+			expected.Mod(expected, new(big.Int).Lsh(big.NewInt(1), uint(cap)))
+			if actual.Big().Cmp(expected) != 0 {
+				t.Errorf("%+v != %+v", expected, actual)
+			}
+		}
 	}
-	var zero Nat
-	zero.SetUint64(0)
-	if a.Eq(&zero) == 1 {
-		return true
+}
+
+func TestOperationsDontModifyInputs(t *testing.T) {
+	skipIfCheckingCaps(t)
+	// A capacity smaller than the inputs used to truncate them in place
+	x := new(Nat).SetUint64(0x1FF)
+	y := new(Nat).SetUint64(0x181)
+	results := []struct {
+		name     string
+		actual   *Nat
+		expected uint64
+	}{
+		{"Add", new(Nat).Add(x, y, 8), 0x80},
+		{"Sub", new(Nat).Sub(x, y, 8), 0x7E},
+		{"Mul", new(Nat).Mul(x, y, 8), 0x7F},
+		{"Lsh", new(Nat).Lsh(x, 1, 8), 0xFE},
+	}
+	for _, r := range results {
+		if r.actual.EqUint64(r.expected) != 1 {
+			t.Errorf("%s: %#x != %+v", r.name, r.expected, r.actual)
+		}
+		if x.EqUint64(0x1FF) != 1 || y.EqUint64(0x181) != 1 {
+			t.Errorf("%s modified its inputs: %+v, %+v", r.name, x, y)
+		}
 	}
-	var one Nat
-	one.SetUint64(1)
-	var z Nat
-	z.Add(&a, &one, a.AnnouncedLen()+1)
-	if !z.checkInvariants() {
-		return false
+	if gt, _, _ := x.Cmp(y); gt != 1 || x.EqUint64(0x1FF) != 1 {
+		t.Errorf("Cmp modified its inputs: %+v, %+v", x, y)
 	}
-	z2 := new(Nat).ModInverse(&a, ModulusFromNat(&z))
-	if !z2.checkInvariants() {
+}
+
+func testModSqrMatchesModMul(a Nat, m Modulus) bool {
+	actual := new(Nat).ModSqr(&a, &m)
+	if !actual.checkInvariants() {
 		return false
 	}
-	return z2.Eq(&a) == 1
+	expected := new(Nat).ModMul(&a, &a, &m)
+	return actual.Eq(expected) == 1
 }
 
-func TestModInverseEvenMinusOne(t *testing.T) {
-	err := quick.Check(testModInverseEvenMinusOne, &quick.Config{})
+func TestModSqrMatchesModMul(t *testing.T) {
+	err := quick.Check(testModSqrMatchesModMul, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestModSqrExamples(t *testing.T) {
+	// Values just below the modulus exercise all of the carries
+	for _, bytes := range [][]byte{modulus2048(), modulus2048Even(), {13}, {1}} {
+		m := ModulusFromBytes(bytes)
+		x := new(Nat).Sub(m.Nat(), new(Nat).SetUint64(1), m.BitLen())
+		expected := new(big.Int).Mul(x.Big(), x.Big())
+		expected.Mod(expected, m.Big())
+		actual := new(Nat).ModSqr(x, m)
+		if actual.Big().Cmp(expected) != 0 {
+			t.Errorf("%+v != %+v", expected, actual)
+		}
+	}
+}
+
+func testModInverseCheckedMatchesBig(x Nat, m Modulus) bool {
+	actual, ok := new(Nat).ModInverseChecked(&x, &m)
+	if !actual.checkInvariants() {
+		return false
+	}
+	// Modulo 1, everything is technically invertible, but big disagrees
+	if m.Big().Cmp(big.NewInt(1)) == 0 {
+		return ok == 1
+	}
+	expected := new(big.Int).ModInverse(x.Big(), m.Big())
+	if expected == nil {
+		return ok == 0
+	}
+	return ok == 1 && actual.Big().Cmp(expected) == 0
+}
+
+func TestModInverseCheckedMatchesBig(t *testing.T) {
+	err := quick.Check(testModInverseCheckedMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestModInverseCheckedExamples(t *testing.T) {
+	cases := []struct {
+		x, m, inverse uint64
+		ok            Choice
+	}{
+		{3, 10, 7, 1},
+		{4, 10, 0, 0},
+		{0, 10, 0, 0},
+		{2, 7, 4, 1},
+		{6, 9, 0, 0},
+		{0, 7, 0, 0},
+		{13, 10, 7, 1},
+	}
+	for _, c := range cases {
+		m := ModulusFromUint64(c.m)
+		actual, ok := new(Nat).ModInverseChecked(new(Nat).SetUint64(c.x), m)
+		if ok != c.ok {
+			t.Errorf("%d^-1 mod %d: %+v != %+v", c.x, c.m, c.ok, ok)
+		}
+		if c.ok == 1 && actual.Eq(new(Nat).SetUint64(c.inverse)) != 1 {
+			t.Errorf("%+v != %+v", c.inverse, actual)
+		}
+	}
+}
+
+func testExtendedGCDMatchesBig(x Nat, y Nat) bool {
+	g, a, b := new(Nat).ExtendedGCD(&x, &y)
+	if !(g.checkInvariants() && a.abs.checkInvariants() && b.abs.checkInvariants()) {
+		return false
+	}
+	expected := new(big.Int).GCD(nil, nil, x.Big(), y.Big())
+	if g.Big().Cmp(expected) != 0 {
+		return false
+	}
+	if new(Nat).GCD(&x, &y).Eq(g) != 1 {
+		return false
+	}
+	// a * x + b * y = g
+	combination := new(big.Int).Mul(a.Big(), x.Big())
+	combination.Add(combination, new(big.Int).Mul(b.Big(), y.Big()))
+	return combination.Cmp(expected) == 0
+}
+
+func TestExtendedGCDMatchesBig(t *testing.T) {
+	err := quick.Check(testExtendedGCDMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExtendedGCDExamples(t *testing.T) {
+	cases := [][2]uint64{{0, 0}, {0, 6}, {6, 0}, {12, 18}, {18, 12}, {7, 1}, {1, 7}, {1 << 40, 1 << 20}, {96, 96}, {35, 64}, {64, 35}}
+	for _, c := range cases {
+		x := new(Nat).SetUint64(c[0])
+		y := new(Nat).SetUint64(c[1])
+		g, a, b := new(Nat).ExtendedGCD(x, y)
+		expected := new(big.Int).GCD(nil, nil, x.Big(), y.Big())
+		if g.Big().Cmp(expected) != 0 {
+			t.Errorf("gcd(%d, %d): %+v != %+v", c[0], c[1], expected, g)
+		}
+		combination := new(big.Int).Mul(a.Big(), x.Big())
+		combination.Add(combination, new(big.Int).Mul(b.Big(), y.Big()))
+		if combination.Cmp(expected) != 0 {
+			t.Errorf("gcd(%d, %d): %+v * x + %+v * y != %+v", c[0], c[1], a, b, expected)
+		}
+	}
+}
+
+func testModInverseMultiplication(a Nat) bool {
+	if !a.checkInvariants() {
+		return false
+	}
+	var scratch, one, zero Nat
+	zero.SetUint64(0)
+	one.SetUint64(1)
+	for _, x := range []uint64{3, 5, 7, 13, 19, 47, 97} {
+		m := ModulusFromUint64(x)
+		scratch.Mod(&a, m)
+		if scratch.Eq(&zero) == 1 {
+			continue
+		}
+		scratch.ModInverse(&a, m)
+		scratch.ModMul(&scratch, &a, m)
+		if !scratch.checkInvariants() {
+			return false
+		}
+		if scratch.Eq(&one) != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestModInverseMultiplication(t *testing.T) {
+	err := quick.Check(testModInverseMultiplication, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testModInverseMinusOne(a Nat) bool {
+	if !a.checkInvariants() {
+		return false
+	}
+	// Clear out the lowest bit
+	if len(a.limbs) > 0 {
+		a.limbs[0] &= ^Word(1)
+	}
+	if a.EqZero() == 1 {
+		return true
+	}
+	var one Nat
+	one.SetUint64(1)
+	z := new(Nat).Add(&a, &one, -1)
+	m := ModulusFromNat(z)
+	z.ModInverse(&a, m)
+	if !z.checkInvariants() {
+		return false
+	}
+	return z.Eq(&a) == 1
+}
+
+func TestModInverseMinusOne(t *testing.T) {
+	err := quick.Check(testModInverseMinusOne, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testModInverseEvenMinusOne(a Nat) bool {
+	if !a.checkInvariants() {
+		return false
+	}
+	// Set the lowest bit
+	if len(a.limbs) != 0 {
+		a.limbs[0] |= 1
+	}
+	var zero Nat
+	zero.SetUint64(0)
+	if a.Eq(&zero) == 1 {
+		return true
+	}
+	var one Nat
+	one.SetUint64(1)
+	var z Nat
+	z.Add(&a, &one, a.AnnouncedLen()+1)
+	if !z.checkInvariants() {
+		return false
+	}
+	z2 := new(Nat).ModInverse(&a, ModulusFromNat(&z))
+	if !z2.checkInvariants() {
+		return false
+	}
+	return z2.Eq(&a) == 1
+}
+
+func TestModInverseEvenMinusOne(t *testing.T) {
+	err := quick.Check(testModInverseEvenMinusOne, &quick.Config{})
 	if err != nil {
 		t.Error(err)
 	}
@@ -665,6 +1324,91 @@ func TestExpAddition(t *testing.T) {
 	}
 }
 
+func testExpMatchesBig(x Nat, y Nat, m Modulus) bool {
+	actual := new(Nat).Exp(&x, &y, &m)
+	if !actual.checkInvariants() {
+		return false
+	}
+	expected := new(big.Int).Exp(x.Big(), y.Big(), m.Big())
+	return actual.Big().Cmp(expected) == 0
+}
+
+func TestExpMatchesBig(t *testing.T) {
+	err := quick.Check(testExpMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testMultiExpMatchesBig(g Nat, h Nat, a Nat, b Nat, m Modulus) bool {
+	actual := new(Nat).MultiExp([]*Nat{&g, &h}, []*Nat{&a, &b}, &m)
+	if !actual.checkInvariants() {
+		return false
+	}
+	mBig := m.Big()
+	expected := new(big.Int).Exp(g.Big(), a.Big(), mBig)
+	expected.Mul(expected, new(big.Int).Exp(h.Big(), b.Big(), mBig))
+	expected.Mod(expected, mBig)
+	return actual.Big().Cmp(expected) == 0
+}
+
+func TestMultiExpMatchesBig(t *testing.T) {
+	err := quick.Check(testMultiExpMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMultiExpExamples(t *testing.T) {
+	for _, mod := range []uint64{13, 100} {
+		m := ModulusFromUint64(mod)
+		// 2^10 * 3^5 * 5^0
+		bases := []*Nat{new(Nat).SetUint64(2), new(Nat).SetUint64(3), new(Nat).SetUint64(5)}
+		exps := []*Nat{new(Nat).SetUint64(10), new(Nat).SetUint64(5), new(Nat).SetUint64(0)}
+		expected := new(Nat).SetUint64((1024 * 243) % mod)
+		actual := new(Nat).MultiExp(bases, exps, m)
+		if actual.Eq(expected) != 1 {
+			t.Errorf("%+v != %+v", expected, actual)
+		}
+		// An empty product is 1
+		expected.SetUint64(1)
+		actual.MultiExp(nil, nil, m)
+		if actual.Eq(expected) != 1 {
+			t.Errorf("%+v != %+v", expected, actual)
+		}
+	}
+}
+
+func testExpPublicMatchesExp(x Nat, y Nat, m Modulus) bool {
+	actual := new(Nat).ExpPublic(&x, &y, &m)
+	if !actual.checkInvariants() {
+		return false
+	}
+	expected := new(Nat).Exp(&x, &y, &m)
+	return actual.Eq(expected) == 1
+}
+
+func TestExpPublicMatchesExp(t *testing.T) {
+	err := quick.Check(testExpPublicMatchesExp, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExpPublicExamples(t *testing.T) {
+	for _, mod := range []uint64{13, 100} {
+		m := ModulusFromUint64(mod)
+		x := new(Nat).SetUint64(3)
+		for _, e := range []uint64{0, 1, 5, 65537} {
+			expected := new(Nat).Exp(x, new(Nat).SetUint64(e), m)
+			actual := new(Nat).ExpPublic(x, new(Nat).SetUint64(e), m)
+			if actual.Eq(expected) != 1 {
+				t.Errorf("%+v != %+v", expected, actual)
+			}
+		}
+	}
+}
+
 func testSqrtRoundTrip(x *Nat, p *Modulus) bool {
 	xSquared := x.ModMul(x, x, p)
 	xRoot := new(Nat).ModSqrt(xSquared, p)
@@ -777,7 +1521,7 @@ func TestAddExamples(t *testing.T) {
 		t.Errorf("%+v != %+v", x, z)
 	}
 	z.SetUint64(300 - 256)
-	x = *x.Add(&x, &y, 8)
+	x = *x.WrappingAdd(&x, &y, 8)
 	if x.Eq(&z) != 1 {
 		t.Errorf("%+v != %+v", x, z)
 	}
@@ -810,90 +1554,397 @@ func TestMulExamples(t *testing.T) {
 		t.Errorf("%+v != %+v", x, z)
 	}
 	z.SetUint64(232)
-	x = *x.Mul(&x, &y, 8)
+	x = *x.WrappingMul(&x, &y, 8)
 	if x.Eq(&z) != 1 {
 		t.Errorf("%+v != %+v", x, z)
 	}
 }
 
-func TestModAddExamples(t *testing.T) {
-	m := ModulusFromUint64(13)
-	var x, y, z Nat
-	x.SetUint64(40)
-	y.SetUint64(40)
-	x = *x.ModAdd(&x, &y, m)
-	z.SetUint64(2)
-	if x.Eq(&z) != 1 {
-		t.Errorf("%+v != %+v", x, z)
+// checkedMatchesBig checks the result of a checked operation against the full result
+func checkedMatchesBig(actual *Nat, overflow Choice, full *big.Int, cap int) bool {
+	if !actual.checkInvariants() || actual.AnnouncedLen() != cap {
+		return false
 	}
+	bound := new(big.Int).Lsh(big.NewInt(1), uint(cap))
+	expectedOverflow := Choice(0)
+	if full.Sign() < 0 || full.Cmp(bound) >= 0 {
+		expectedOverflow = 1
+	}
+	expected := new(big.Int).Mod(full, bound)
+	return overflow == expectedOverflow && actual.Big().Cmp(expected) == 0
 }
 
-func TestModMulExamples(t *testing.T) {
-	var x, y, z Nat
-	m := ModulusFromUint64(13)
-	x.SetUint64(40)
-	y.SetUint64(40)
-	x = *x.ModMul(&x, &y, m)
-	z.SetUint64(1)
-	if x.Eq(&z) != 1 {
-		t.Errorf("%+v != %+v", x, z)
-	}
-	m = ModulusFromBytes([]byte{1, 0, 0, 0, 0, 0, 0, 0, 1})
-	x.SetUint64(1)
-	x = *x.ModMul(&x, &x, m)
-	z.SetUint64(1)
-	if x.Eq(&z) != 1 {
-		t.Errorf("%+v != %+v", x, z)
-	}
-	m = ModulusFromBytes([]byte{1, 0, 0, 0, 0, 0, 0, 0, 1})
-	x.SetUint64(16390320477281102916)
-	y.SetUint64(13641051446569424315)
-	x = *x.ModMul(&x, &y, m)
-	z.SetUint64(12559215458690093993)
-	if x.Eq(&z) != 1 {
-		t.Errorf("%+v != %+v", x, z)
+func testAddCheckedMatchesBig(x Nat, y Nat, cap uint8) bool {
+	full := new(big.Int).Add(x.Big(), y.Big())
+	actual, overflow := new(Nat).AddChecked(&x, &y, int(cap))
+	return checkedMatchesBig(actual, overflow, full, int(cap))
+}
+
+func TestAddCheckedMatchesBig(t *testing.T) {
+	err := quick.Check(testAddCheckedMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
 	}
 }
 
-func TestModExamples(t *testing.T) {
-	var x, test Nat
-	x.SetUint64(40)
-	m := ModulusFromUint64(13)
-	x.Mod(&x, m)
-	test.SetUint64(1)
-	if x.Eq(&test) != 1 {
-		t.Errorf("%+v != %+v", x, test)
+func testSubCheckedMatchesBig(x Nat, y Nat, cap uint8) bool {
+	full := new(big.Int).Sub(x.Big(), y.Big())
+	actual, overflow := new(Nat).SubChecked(&x, &y, int(cap))
+	return checkedMatchesBig(actual, overflow, full, int(cap))
+}
+
+func TestSubCheckedMatchesBig(t *testing.T) {
+	err := quick.Check(testSubCheckedMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
 	}
-	m = ModulusFromBytes([]byte{13, 0, 0, 0, 0, 0, 0, 0, 1})
-	x.SetBytes([]byte{41, 0, 0, 0, 0, 0, 0, 0, 0})
-	x.Mod(&x, m)
-	test.SetBytes([]byte{1, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFD})
-	if x.Eq(&test) != 1 {
-		t.Errorf("%+v != %+v", x, test)
+}
+
+func testMulCheckedMatchesBig(x Nat, y Nat, cap uint16) bool {
+	full := new(big.Int).Mul(x.Big(), y.Big())
+	actual, overflow := new(Nat).MulChecked(&x, &y, int(cap%1024))
+	return checkedMatchesBig(actual, overflow, full, int(cap%1024))
+}
+
+func TestMulCheckedMatchesBig(t *testing.T) {
+	err := quick.Check(testMulCheckedMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
 	}
 }
 
-func TestModInverseExamples(t *testing.T) {
-	x, z := new(Nat), new(Nat)
-	x.SetUint64(2)
-	m := ModulusFromUint64(13)
-	x = x.ModInverse(x, m)
-	z.SetUint64(7)
-	if x.Eq(z) != 1 {
-		t.Errorf("%+v != %+v", x, z)
+func TestCheckedExamples(t *testing.T) {
+	x := new(Nat).SetUint64(200)
+	y := new(Nat).SetUint64(100)
+	actual, overflow := new(Nat).AddChecked(x, y, 8)
+	if overflow != 1 || actual.Eq(new(Nat).SetUint64(44)) != 1 {
+		t.Errorf("44, 1 != %+v, %+v", actual, overflow)
 	}
-	x.SetUint64(16359684999990746055)
-	m = ModulusFromUint64(7)
-	x = x.ModInverse(x, m)
-	z.SetUint64(3)
-	if x.Eq(z) != 1 {
-		t.Errorf("%+v != %+v", x, z)
+	actual, overflow = new(Nat).AddChecked(x, y, 9)
+	if overflow != 0 || actual.Eq(new(Nat).SetUint64(300)) != 1 {
+		t.Errorf("300, 0 != %+v, %+v", actual, overflow)
 	}
-	x.SetUint64(461423694560)
-	m = ModulusFromUint64(461423694561)
-	z.ModInverse(x, m)
-	if x.Eq(z) != 1 {
-		t.Errorf("%+v != %+v", x, z)
+	actual, overflow = new(Nat).SubChecked(y, x, 8)
+	if overflow != 1 || actual.Eq(new(Nat).SetUint64(156)) != 1 {
+		t.Errorf("156, 1 != %+v, %+v", actual, overflow)
+	}
+	actual, overflow = x.SubChecked(x, y, -1)
+	if overflow != 0 || actual.Eq(new(Nat).SetUint64(100)) != 1 {
+		t.Errorf("100, 0 != %+v, %+v", actual, overflow)
+	}
+	actual, overflow = new(Nat).MulChecked(y, y, 13)
+	if overflow != 1 || actual.Eq(new(Nat).SetUint64(10000%(1<<13))) != 1 {
+		t.Errorf("%+v, 1 != %+v, %+v", 10000%(1<<13), actual, overflow)
+	}
+	actual, overflow = new(Nat).MulChecked(y, y, 14)
+	if overflow != 0 || actual.Eq(new(Nat).SetUint64(10000)) != 1 {
+		t.Errorf("10000, 0 != %+v, %+v", actual, overflow)
+	}
+}
+
+func testSaturatingMatchesBig(x Nat, y Nat, cap uint8) bool {
+	bound := new(big.Int).Lsh(big.NewInt(1), uint(cap))
+	max := new(big.Int).Sub(bound, big.NewInt(1))
+	clamp := func(v *big.Int) *big.Int {
+		if v.Sign() < 0 {
+			return new(big.Int)
+		}
+		if v.Cmp(max) > 0 {
+			return max
+		}
+		return v
+	}
+	sum := new(Nat).SaturatingAdd(&x, &y, int(cap))
+	if !sum.checkInvariants() || sum.AnnouncedLen() != int(cap) {
+		return false
+	}
+	if sum.Big().Cmp(clamp(new(big.Int).Add(x.Big(), y.Big()))) != 0 {
+		return false
+	}
+	diff := new(Nat).SaturatingSub(&x, &y, int(cap))
+	if !diff.checkInvariants() || diff.AnnouncedLen() != int(cap) {
+		return false
+	}
+	return diff.Big().Cmp(clamp(new(big.Int).Sub(x.Big(), y.Big()))) == 0
+}
+
+func TestSaturatingMatchesBig(t *testing.T) {
+	err := quick.Check(testSaturatingMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testWrappingMatchesAdd(x Nat, y Nat, cap uint8) bool {
+	if new(Nat).WrappingAdd(&x, &y, int(cap)).Eq(new(Nat).Add(&x, &y, int(cap))) != 1 {
+		return false
+	}
+	if new(Nat).WrappingSub(&x, &y, int(cap)).Eq(new(Nat).Sub(&x, &y, int(cap))) != 1 {
+		return false
+	}
+	return new(Nat).WrappingMul(&x, &y, int(cap)).Eq(new(Nat).Mul(&x, &y, int(cap))) == 1
+}
+
+func TestWrappingMatchesAdd(t *testing.T) {
+	skipIfCheckingCaps(t)
+	err := quick.Check(testWrappingMatchesAdd, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSaturatingExamples(t *testing.T) {
+	x := new(Nat).SetUint64(200)
+	y := new(Nat).SetUint64(100)
+	expected := new(Nat).SetUint64(255)
+	actual := new(Nat).SaturatingAdd(x, y, 8)
+	if expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	expected.SetUint64(0)
+	actual = new(Nat).SaturatingSub(y, x, 8)
+	if expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	expected.SetUint64(100)
+	actual = x.SaturatingSub(x, y, 8)
+	if expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+}
+
+func testEqTrimmedMatchesEq(a Nat, b Nat) bool {
+	if a.EqTrimmed(&b) != a.Eq(&b) || b.EqTrimmed(&a) != a.Eq(&b) {
+		return false
+	}
+	// Growing, or shrinking, a number to fit its value shouldn't change equality
+	grown := new(Nat).SetNat(&a).Resize(a.AnnouncedLen() + 200)
+	trimmed := new(Nat).SetNat(&a).Resize(a.TrueLen())
+	return a.EqTrimmed(grown) == 1 && grown.EqTrimmed(trimmed) == 1 && grown.Eq(trimmed) == 1
+}
+
+func TestEqTrimmedMatchesEq(t *testing.T) {
+	err := quick.Check(testEqTrimmedMatchesEq, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEqTrimmedExamples(t *testing.T) {
+	// 2^200 + 7 only differs from 7 past the limbs of the latter
+	high := new(Nat).Lsh(new(Nat).SetUint64(1), 200, -1)
+	high.Add(high, new(Nat).SetUint64(7), -1)
+	cases := []struct {
+		a, b     *Nat
+		expected Choice
+	}{
+		{new(Nat), new(Nat).Resize(256), 1},
+		{new(Nat).SetUint64(7).Resize(3), new(Nat).SetUint64(7).Resize(256), 1},
+		{new(Nat).SetUint64(7).Resize(3), new(Nat).SetUint64(8).Resize(256), 0},
+		{new(Nat).SetUint64(7), high, 0},
+	}
+	for _, c := range cases {
+		if actual := c.a.EqTrimmed(c.b); actual != c.expected {
+			t.Errorf("%+v != %+v", c.expected, actual)
+		}
+		if actual := c.b.EqTrimmed(c.a); actual != c.expected {
+			t.Errorf("%+v != %+v", c.expected, actual)
+		}
+	}
+}
+
+func testCmpWordMatchesBig(z Nat, x Nat) bool {
+	expected := z.Big().Cmp(x.Big())
+	actual := z.CmpWord(&x)
+	return Word(expected) == actual && z.CmpWord(&z) == 0
+}
+
+func TestCmpWordMatchesBig(t *testing.T) {
+	err := quick.Check(testCmpWordMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCmpWordExamples(t *testing.T) {
+	small := new(Nat).SetUint64(7)
+	large := new(Nat).SetUint64(7).Resize(256)
+	large.Lsh(large, 100, -1)
+	if actual := small.CmpWord(large); actual != ^Word(0) {
+		t.Errorf("%+v != %+v", ^Word(0), actual)
+	}
+	if actual := large.CmpWord(small); actual != 1 {
+		t.Errorf("%+v != %+v", 1, actual)
+	}
+	if actual := small.CmpWord(new(Nat).SetUint64(7).Resize(256)); actual != 0 {
+		t.Errorf("%+v != %+v", 0, actual)
+	}
+}
+
+func testCmpUint64MatchesCmp(z Nat, x uint64) bool {
+	gt, eq, lt := z.CmpUint64(x)
+	expectedGt, expectedEq, expectedLt := z.Cmp(new(Nat).SetUint64(x))
+	if gt != expectedGt || eq != expectedEq || lt != expectedLt {
+		return false
+	}
+	// Also compare against the low bits of z, which are more likely to be equal
+	low := z.Uint64()
+	gt, eq, lt = new(Nat).SetUint64(low).CmpUint64(x)
+	return gt == ChoiceFromBool(low > x) && eq == ChoiceFromBool(low == x) && lt == ChoiceFromBool(low < x)
+}
+
+func TestCmpUint64MatchesCmp(t *testing.T) {
+	err := quick.Check(testCmpUint64MatchesCmp, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEqUint64Examples(t *testing.T) {
+	cases := []struct {
+		z        *Nat
+		x        uint64
+		expected Choice
+	}{
+		{new(Nat), 0, 1},
+		{new(Nat), 1, 0},
+		{new(Nat).SetUint64(1), 1, 1},
+		{new(Nat).SetUint64(7).Resize(3), 7, 1},
+		{new(Nat).SetUint64(7).Resize(3), 1<<40 + 7, 0},
+		{new(Nat).SetUint64(0xFFFFFFFFFFFFFFFF), 0xFFFFFFFFFFFFFFFF, 1},
+		{new(Nat).SetUint64(1).Resize(256), 1, 1},
+		{new(Nat).Lsh(new(Nat).SetUint64(1), 64, -1), 0, 0},
+	}
+	for _, c := range cases {
+		actual := c.z.EqUint64(c.x)
+		if actual != c.expected {
+			t.Errorf("%+v == %d: %+v != %+v", c.z, c.x, c.expected, actual)
+		}
+	}
+}
+
+func TestEqUint64DoesNotAllocate(t *testing.T) {
+	z := new(Nat).SetUint64(1).Resize(2048)
+	allocs := testing.AllocsPerRun(10, func() {
+		z.EqUint64(1)
+		z.CmpUint64(1)
+	})
+	if allocs != 0 {
+		t.Errorf("%+v allocations != 0", allocs)
+	}
+}
+
+func TestModAddExamples(t *testing.T) {
+	m := ModulusFromUint64(13)
+	var x, y, z Nat
+	x.SetUint64(40)
+	y.SetUint64(40)
+	x = *x.ModAdd(&x, &y, m)
+	z.SetUint64(2)
+	if x.Eq(&z) != 1 {
+		t.Errorf("%+v != %+v", x, z)
+	}
+}
+
+func TestModMulExamples(t *testing.T) {
+	var x, y, z Nat
+	m := ModulusFromUint64(13)
+	x.SetUint64(40)
+	y.SetUint64(40)
+	x = *x.ModMul(&x, &y, m)
+	z.SetUint64(1)
+	if x.Eq(&z) != 1 {
+		t.Errorf("%+v != %+v", x, z)
+	}
+	m = ModulusFromBytes([]byte{1, 0, 0, 0, 0, 0, 0, 0, 1})
+	x.SetUint64(1)
+	x = *x.ModMul(&x, &x, m)
+	z.SetUint64(1)
+	if x.Eq(&z) != 1 {
+		t.Errorf("%+v != %+v", x, z)
+	}
+	m = ModulusFromBytes([]byte{1, 0, 0, 0, 0, 0, 0, 0, 1})
+	x.SetUint64(16390320477281102916)
+	y.SetUint64(13641051446569424315)
+	x = *x.ModMul(&x, &y, m)
+	z.SetUint64(12559215458690093993)
+	if x.Eq(&z) != 1 {
+		t.Errorf("%+v != %+v", x, z)
+	}
+}
+
+func TestModulusRRExamples(t *testing.T) {
+	// With a single limb, R = 2^_W
+	m := ModulusFromUint64(13)
+	expected := new(big.Int).Lsh(big.NewInt(1), 2*_W)
+	expected.Mod(expected, big.NewInt(13))
+	if uint64(m.reduction().rr[0]) != expected.Uint64() {
+		t.Errorf("%+v != %+v", m.reduction().rr[0], expected)
+	}
+	m = ModulusFromUint64(1)
+	x := new(Nat).SetUint64(7)
+	x.ModMul(x, x, m)
+	if x.EqZero() != 1 {
+		t.Errorf("%+v != 0", x)
+	}
+}
+
+func TestBarrettExamples(t *testing.T) {
+	// The largest possible input, (B^n - 1)^2, needs two final subtractions
+	m := ModulusFromBytes([]byte{0x80, 0, 0, 0, 0, 0, 0, 0, 2})
+	x := new(Nat).SetBytes([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF})
+	expected := new(big.Int).Mod(x.Big(), m.Big())
+	actual := new(Nat).Mod(x, m).Big()
+	if actual.Cmp(expected) != 0 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	m = ModulusFromUint64(2)
+	x.SetUint64(7)
+	x.ModMul(x, x, m)
+	if x.Eq(new(Nat).SetUint64(1)) != 1 {
+		t.Errorf("%+v != 1", x)
+	}
+}
+
+func TestModExamples(t *testing.T) {
+	var x, test Nat
+	x.SetUint64(40)
+	m := ModulusFromUint64(13)
+	x.Mod(&x, m)
+	test.SetUint64(1)
+	if x.Eq(&test) != 1 {
+		t.Errorf("%+v != %+v", x, test)
+	}
+	m = ModulusFromBytes([]byte{13, 0, 0, 0, 0, 0, 0, 0, 1})
+	x.SetBytes([]byte{41, 0, 0, 0, 0, 0, 0, 0, 0})
+	x.Mod(&x, m)
+	test.SetBytes([]byte{1, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFD})
+	if x.Eq(&test) != 1 {
+		t.Errorf("%+v != %+v", x, test)
+	}
+}
+
+func TestModInverseExamples(t *testing.T) {
+	x, z := new(Nat), new(Nat)
+	x.SetUint64(2)
+	m := ModulusFromUint64(13)
+	x = x.ModInverse(x, m)
+	z.SetUint64(7)
+	if x.Eq(z) != 1 {
+		t.Errorf("%+v != %+v", x, z)
+	}
+	x.SetUint64(16359684999990746055)
+	m = ModulusFromUint64(7)
+	x = x.ModInverse(x, m)
+	z.SetUint64(3)
+	if x.Eq(z) != 1 {
+		t.Errorf("%+v != %+v", x, z)
+	}
+	x.SetUint64(461423694560)
+	m = ModulusFromUint64(461423694561)
+	z.ModInverse(x, m)
+	if x.Eq(z) != 1 {
+		t.Errorf("%+v != %+v", x, z)
 	}
 	x.SetHex("2AFAE74A613B0764098D86")
 	m, _ = ModulusFromHex("2AFAE74A613B0764098D87")
@@ -945,6 +1996,21 @@ func TestExpExamples(t *testing.T) {
 	if x.Eq(&z) != 1 {
 		t.Errorf("%+v != %+v", x, z)
 	}
+	m = ModulusFromUint64(100)
+	x.SetUint64(3)
+	y.SetUint64(5)
+	x = *x.Exp(&x, &y, m)
+	z.SetUint64(43)
+	if x.Eq(&z) != 1 {
+		t.Errorf("%+v != %+v", x, z)
+	}
+	x.SetUint64(3)
+	y.SetUint64(0)
+	x = *x.Exp(&x, &y, m)
+	z.SetUint64(1)
+	if x.Eq(&z) != 1 {
+		t.Errorf("%+v != %+v", x, z)
+	}
 }
 
 func TestSetBytesExamples(t *testing.T) {
@@ -1098,6 +2164,25 @@ func TestBigExamples(t *testing.T) {
 	}
 }
 
+func TestSetBigClearsReduction(t *testing.T) {
+	m := ModulusFromUint64(13)
+	x := new(Nat).Mod(new(Nat).SetUint64(20), m)
+	x.SetBig(big.NewInt(100), 8)
+	x.Mod(x, m)
+	expected := new(Nat).SetUint64(9)
+	if expected.Eq(x) != 1 {
+		t.Errorf("%+v != %+v", expected, x)
+	}
+}
+
+func TestModulusFromBigExamples(t *testing.T) {
+	expected := ModulusFromUint64(0xAABBCCDD)
+	actual := ModulusFromBig(big.NewInt(0xAABBCCDD))
+	if _, eq, _ := expected.Cmp(actual); eq != 1 || expected.BitLen() != actual.BitLen() {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+}
+
 func TestDivExamples(t *testing.T) {
 	x := &Nat{announced: 3 * _W, limbs: []Word{0, 64, 64}}
 	n := &Nat{announced: 2 * _W, limbs: []Word{1, 1}}
@@ -1116,6 +2201,64 @@ func TestDivExamples(t *testing.T) {
 	}
 }
 
+func testCoprimeMatchesBig(x Nat, y Nat) bool {
+	gcd := new(big.Int).GCD(nil, nil, x.Big(), y.Big())
+	expected := Choice(0)
+	if gcd.Cmp(big.NewInt(1)) == 0 {
+		expected = 1
+	}
+	return x.Coprime(&y) == expected
+}
+
+func TestCoprimeMatchesBig(t *testing.T) {
+	err := quick.Check(testCoprimeMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testLCMMatchesBig(x Nat, y Nat) bool {
+	actual := new(Nat).LCM(&x, &y, -1)
+	if !actual.checkInvariants() || actual.AnnouncedLen() != x.AnnouncedLen()+y.AnnouncedLen() {
+		return false
+	}
+	expected := new(big.Int)
+	gcd := new(big.Int).GCD(nil, nil, x.Big(), y.Big())
+	if gcd.Sign() != 0 {
+		expected.Mul(x.Big(), y.Big())
+		expected.Quo(expected, gcd)
+	}
+	return actual.Big().Cmp(expected) == 0
+}
+
+func TestLCMMatchesBig(t *testing.T) {
+	err := quick.Check(testLCMMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLCMExamples(t *testing.T) {
+	cases := []struct {
+		x, y, lcm uint64
+	}{
+		{0, 0, 0},
+		{0, 5, 0},
+		{5, 0, 0},
+		{1, 7, 7},
+		{4, 6, 12},
+		{1008, 1012, 255024},
+		{2 * 3 * 5, 3 * 5 * 7, 2 * 3 * 5 * 7},
+	}
+	for _, c := range cases {
+		x := new(Nat).SetUint64(c.x)
+		actual := x.LCM(x, new(Nat).SetUint64(c.y), -1)
+		if actual.Eq(new(Nat).SetUint64(c.lcm)) != 1 {
+			t.Errorf("lcm(%d, %d): %+v != %+v", c.x, c.y, c.lcm, actual)
+		}
+	}
+}
+
 func TestCoprimeExamples(t *testing.T) {
 	x := new(Nat).SetUint64(5 * 7 * 13)
 	y := new(Nat).SetUint64(3 * 7 * 11)
@@ -1214,6 +2357,63 @@ func TestHexExamples(t *testing.T) {
 	}
 }
 
+func testSetDecimalMatchesBig(x Nat) bool {
+	dec := x.Big().String()
+	y, err := new(Nat).SetDecimal(dec)
+	if err != nil || !y.checkInvariants() {
+		return false
+	}
+	return x.Eq(y) == 1
+}
+
+func TestSetDecimalMatchesBig(t *testing.T) {
+	err := quick.Check(testSetDecimalMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSetDecimalExamples(t *testing.T) {
+	x, err := new(Nat).SetDecimal("18446744073709551616")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := new(Nat).SetBytes([]byte{1, 0, 0, 0, 0, 0, 0, 0, 0})
+	if expected.Eq(x) != 1 {
+		t.Errorf("%+v != %+v", expected, x)
+	}
+	for _, invalid := range []string{"", "12a", "-1", " 1"} {
+		if _, err := new(Nat).SetDecimal(invalid); err == nil {
+			t.Errorf("expected error parsing %q", invalid)
+		}
+	}
+}
+
+func TestFormatExamples(t *testing.T) {
+	x := new(Nat).SetUint64(0xAB)
+	cases := []struct {
+		format   string
+		expected string
+	}{
+		{"%x", "ab"},
+		{"%X", "AB"},
+		{"%#x", "0xab"},
+		{"%d", "171"},
+		{"%b", "10101011"},
+		{"%06d", "000171"},
+		{"%-5d|", "171  |"},
+		{"%v", x.String()},
+		{"%s", x.String()},
+		{"%30s", fmt.Sprintf("%30s", x.String())},
+	}
+	for _, c := range cases {
+		actual := fmt.Sprintf(c.format, x)
+		if c.expected != actual {
+			t.Errorf("%s: %+v != %+v", c.format, c.expected, actual)
+		}
+	}
+}
+
 func TestDivEdgeCase(t *testing.T) {
 	x, _ := new(Nat).SetHex("B857C2BFBB8F9C8529B37228BE59017114876E17623A605308BFF084CBA97565BC97F9A2ED65895572B157AF6CADE2D7DD018772149E3216DA6D5B57EA703AF1598E23F3A79637C3072053427732C9E336AF983AB8FFD4F0AD08F042C8D3709FC6CC7247AE6C5D1181183FDBC4A1252D6B8C124FF50D6C72579AC2EC75F79FFD040F61F771D8E4116B40E595DB898A702DC99A882A37F091CDC897171921D744E5F2ACA5F466E4D9087B8D04E90CA99DBB259329C30CD925E046FFCB0CDB17FF2EB9C7475D4280C14711B1538F1282A2259348EAB246296D03051774D34D968329C336997EA4EEEBE9D8EE2EBAEBEF4B97076DF9431556F219DFEEFB58D9828E6AB9944C6717AD201331C8A12A11544389251E9A80388378F5B5596D129DDB5BC80F4D1AC993F0E6EF65AD7F832189DA2BDA0E642B6F1CDC539F07913FCFD65BCDE7D7CD2B7223D37B3666D58879B8EE61D61CE3683B6168F392B61A7C99F162C12138CD598770CC7604577E67B8A28C96AF7BDCB24CBD9B0E2801A2F122EFF7A21249C65BA49BD39B9F6B62BD4B0B16EBA1B8FC4AA2EFD03AD4D08AE17371D4B0A88020B77BCD072063DE9EB3F1FCC54FD2D35E587A424C7F62090E6A82B4839ED376BC572882E415F0A3277AF19E9A8BD4F19C69BA445ADAEAB178CE6952BE8140B0FACF0E7E045B9B8A54986481F8279D78048959FAB13B41AC11EB12AA4C")
 	nNat, _ := new(Nat).SetHex("D93C94E373D1B82924130A345FA7B8664AAFF9F335C0E6E79DCFEF49C88DC444885CA953F12BAA4A67B7B21C2FF6B4EECF6A750C76A456B2C800AFCBD0660CA03CB256A594C0D46B00118D6179F845D91EE0D4AFB2168E0FBFAB9958FE3A831950C8D8F402E4CD72C90128F1AE3BE986CE5FFD2EABC3363DE1EEB71BBC7245F4C78899301031803F0AE5B09C803E5E02E18FFA540202E65C29D1692058C34F34B9C9F42482E31436511B23A80F4642DB06BCE8E7C1B0A54E537418B411E4856277B9EC30C0103E1C7881E85F29AD6F7C27109DEEEC1676EE6A74E9641440A9E1095076CFBDD23FFF84A2C683EB19EBEE82811A8B6771CC7AF01DF85BA8A66FCD")
@@ -1232,20 +2432,1015 @@ func TestDivEdgeCase(t *testing.T) {
 	}
 }
 
-func TestLshExamples(t *testing.T) {
-	x := new(Nat).SetUint64(1).Resize(1)
-	expected := new(Nat).SetUint64(32)
-	actual := x.Lsh(x, 5, -1)
-	if expected.Eq(actual) != 1 {
-		t.Errorf("%+v != %+v", expected, actual)
+func testDivRemMatchesBig(x Nat, y Nat) bool {
+	if y.EqZero() == 1 {
+		return true
+	}
+	q, r := new(Nat).DivRem(&x, &y, -1)
+	if !q.checkInvariants() || !r.checkInvariants() {
+		return false
 	}
+	if q.AnnouncedLen() != x.AnnouncedLen() || r.AnnouncedLen() != y.AnnouncedLen() {
+		return false
+	}
+	expectedQ, expectedR := new(big.Int).QuoRem(x.Big(), y.Big(), new(big.Int))
+	return q.Big().Cmp(expectedQ) == 0 && r.Big().Cmp(expectedR) == 0
 }
 
-func TestRshExamples(t *testing.T) {
-	x := new(Nat).SetUint64(32)
+func TestDivRemMatchesBig(t *testing.T) {
+	err := quick.Check(testDivRemMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testDivRemMatchesDiv(x Nat, m Modulus) bool {
+	expectedQ := new(Nat).Div(&x, &m, -1)
+	expectedR := new(Nat).Mod(&x, &m)
+	q, r := new(Nat).DivRem(&x, m.Nat(), expectedQ.AnnouncedLen())
+	return q.Eq(expectedQ) == 1 && r.Eq(expectedR) == 1
+}
+
+func TestDivRemMatchesDiv(t *testing.T) {
+	err := quick.Check(testDivRemMatchesDiv, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDivRemAliasing(t *testing.T) {
+	x := new(Nat).SetUint64(1000003)
+	y := new(Nat).SetUint64(1009)
+	q, r := x.DivRem(x, y, -1)
+	if q.Eq(new(Nat).SetUint64(991)) != 1 || r.Eq(new(Nat).SetUint64(84)) != 1 {
+		t.Errorf("991, 84 != %+v, %+v", q, r)
+	}
+	x = new(Nat).SetUint64(1000003)
+	q, r = y.DivRem(x, y, -1)
+	if q.Eq(new(Nat).SetUint64(991)) != 1 || r.Eq(new(Nat).SetUint64(84)) != 1 {
+		t.Errorf("991, 84 != %+v, %+v", q, r)
+	}
+}
+
+func TestDivRemExamples(t *testing.T) {
+	cases := []struct {
+		x, y, q, r uint64
+		cap        int
+	}{
+		{100, 7, 14, 2, -1},
+		{6, 7, 0, 6, -1},
+		{7, 7, 1, 0, -1},
+		{0xFFFFFFFFFFFFFFFF, 1, 0xFFFFFFFFFFFFFFFF, 0, -1},
+		// The quotient gets truncated to cap bits
+		{100, 7, 6, 2, 3},
+	}
+	for _, c := range cases {
+		// safenum_checkcap doesn't allow truncating the quotient
+		if c.cap >= 0 && checkCapBuild {
+			continue
+		}
+		q, r := new(Nat).DivRem(new(Nat).SetUint64(c.x), new(Nat).SetUint64(c.y), c.cap)
+		if q.Eq(new(Nat).SetUint64(c.q)) != 1 || r.Eq(new(Nat).SetUint64(c.r)) != 1 {
+			t.Errorf("%d, %d != %+v, %+v", c.q, c.r, q, r)
+		}
+	}
+}
+
+func testSqrtMatchesBig(x Nat) bool {
+	actual := new(Nat).Sqrt(&x)
+	if !actual.checkInvariants() || actual.AnnouncedLen() != (x.AnnouncedLen()+1)/2 {
+		return false
+	}
+	return actual.Big().Cmp(new(big.Int).Sqrt(x.Big())) == 0
+}
+
+func TestSqrtMatchesBig(t *testing.T) {
+	err := quick.Check(testSqrtMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testIsPerfectSquare(x Nat) bool {
+	square := new(Nat).Mul(&x, &x, -1)
+	if square.IsPerfectSquare() != 1 || new(Nat).Sqrt(square).Eq(&x) != 1 {
+		return false
+	}
+	// x^2 + 1 is only a square when x = 0
+	next := new(Nat).Add(square, new(Nat).SetUint64(1), -1)
+	return next.IsPerfectSquare() == x.EqZero()
+}
+
+func TestIsPerfectSquare(t *testing.T) {
+	err := quick.Check(testIsPerfectSquare, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSqrtExamples(t *testing.T) {
+	cases := []struct {
+		x, root uint64
+		square  Choice
+	}{
+		{0, 0, 1},
+		{1, 1, 1},
+		{2, 1, 0},
+		{3, 1, 0},
+		{4, 2, 1},
+		{99, 9, 0},
+		{100, 10, 1},
+		{0xFFFFFFFFFFFFFFFF, 0xFFFFFFFF, 0},
+		{0xFFFFFFFE00000001, 0xFFFFFFFF, 1},
+	}
+	for _, c := range cases {
+		x := new(Nat).SetUint64(c.x)
+		actual := new(Nat).Sqrt(x)
+		if actual.Eq(new(Nat).SetUint64(c.root)) != 1 {
+			t.Errorf("%+v != %+v", c.root, actual)
+		}
+		if x.IsPerfectSquare() != c.square {
+			t.Errorf("%d: %+v != %+v", c.x, c.square, x.IsPerfectSquare())
+		}
+	}
+	// Aliasing should also work
+	x := new(Nat).SetUint64(1 << 40)
+	x.Sqrt(x)
+	if x.Eq(new(Nat).SetUint64(1<<20)) != 1 {
+		t.Errorf("%+v != %+v", 1<<20, x)
+	}
+}
+
+func TestLshExamples(t *testing.T) {
+	x := new(Nat).SetUint64(1).Resize(1)
+	expected := new(Nat).SetUint64(32)
+	actual := x.Lsh(x, 5, -1)
+	if expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+}
+
+func TestRshExamples(t *testing.T) {
+	x := new(Nat).SetUint64(32)
 	expected := new(Nat).SetUint64(1)
 	actual := x.Rsh(x, 5, -1)
 	if expected.Eq(actual) != 1 {
 		t.Errorf("%+v != %+v", expected, actual)
 	}
 }
+
+func testJacobiMatchesBig(x Nat, m Modulus) bool {
+	// Jacobi requires odd moduli
+	if m.even {
+		return true
+	}
+	return x.Jacobi(&m) == big.Jacobi(x.Big(), m.Big())
+}
+
+func TestJacobiMatchesBig(t *testing.T) {
+	err := quick.Check(testJacobiMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestJacobiExamples(t *testing.T) {
+	cases := []struct {
+		x, m     uint64
+		expected int
+	}{
+		{0, 1, 1},
+		{5, 1, 1},
+		{0, 7, 0},
+		{2, 7, 1},
+		{3, 7, -1},
+		{7, 7, 0},
+		{6, 9, 0},
+		{2, 15, 1},
+		{7, 15, -1},
+		{1001, 9907, -1},
+		{19, 45, 1},
+	}
+	for _, c := range cases {
+		actual := new(Nat).SetUint64(c.x).Jacobi(ModulusFromUint64(c.m))
+		if actual != c.expected {
+			t.Errorf("(%d / %d): %+v != %+v", c.x, c.m, c.expected, actual)
+		}
+	}
+	// Legendre symbol modulo the P-256 prime
+	p, _ := ModulusFromHex("FFFFFFFF00000001000000000000000000000000FFFFFFFFFFFFFFFFFFFFFFFF")
+	x := new(Nat).SetUint64(12345)
+	square := new(Nat).ModMul(x, x, p)
+	if square.Jacobi(p) != 1 {
+		t.Errorf("%+v is not a square mod p", square)
+	}
+	// Since p = 3 mod 4, -1 isn't a square
+	minusSquare := new(Nat).ModNeg(square, p)
+	if minusSquare.Jacobi(p) != -1 {
+		t.Errorf("%+v is a square mod p", minusSquare)
+	}
+}
+
+func TestModSqrtCheckedExamples(t *testing.T) {
+	for _, p := range []*Modulus{ModulusFromUint64(7), ModulusFromUint64(13), ModulusFromUint64(17)} {
+		pBig := p.Big()
+		for i := uint64(0); i < p.Big().Uint64(); i++ {
+			x := new(Nat).SetUint64(i)
+			root, ok := new(Nat).ModSqrtChecked(x, p)
+			expected := big.NewInt(0).ModSqrt(x.Big(), pBig) != nil
+			if (ok == 1) != expected {
+				t.Errorf("sqrt(%d) mod %+v: %+v != %+v", i, pBig, expected, ok)
+			}
+			if ok == 1 && new(Nat).ModMul(root, root, p).Eq(x) != 1 {
+				t.Errorf("%+v^2 != %d", root, i)
+			}
+		}
+	}
+	// Large primes, both 3 mod 4, and 1 mod 4, being the P-256 and Curve25519 primes
+	for _, hex := range []string{
+		"FFFFFFFF00000001000000000000000000000000FFFFFFFFFFFFFFFFFFFFFFFF",
+		"7FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFED",
+	} {
+		p, _ := ModulusFromHex(hex)
+		x := new(Nat).SetUint64(0xABCDEF)
+		square := new(Nat).ModMul(x, x, p)
+		if _, ok := new(Nat).ModSqrtChecked(square, p); ok != 1 {
+			t.Errorf("%+v should have a square root", square)
+		}
+		nonSquare := new(Nat).SetUint64(2)
+		for nonSquare.Jacobi(p) != -1 {
+			nonSquare.Add(nonSquare, new(Nat).SetUint64(1), 64)
+		}
+		if _, ok := new(Nat).ModSqrtChecked(nonSquare, p); ok != 0 {
+			t.Errorf("%+v shouldn't have a square root", nonSquare)
+		}
+	}
+}
+
+func TestRandExamples(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for _, mod := range []uint64{1, 2, 3, 7, 100, 257} {
+		m := ModulusFromUint64(mod)
+		seen := make(map[uint64]bool)
+		for i := 0; i < 2000; i++ {
+			x, err := new(Nat).Rand(m, r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !x.checkInvariants() || x.AnnouncedLen() != m.BitLen() {
+				t.Errorf("invalid random value %+v", x)
+			}
+			if _, _, lt := x.CmpMod(m); lt != 1 {
+				t.Errorf("%+v >= %+v", x, mod)
+			}
+			seen[x.Uint64()] = true
+		}
+		// With this many samples, every value should show up
+		if uint64(len(seen)) != mod {
+			t.Errorf("only %d out of %d values sampled", len(seen), mod)
+		}
+	}
+	m := ModulusFromBytes(modulus2048())
+	x, err := new(Nat).Rand(m, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, lt := x.CmpMod(m); lt != 1 {
+		t.Errorf("%+v >= %+v", x, m)
+	}
+	if _, err := new(Nat).Rand(m, bytes.NewReader(make([]byte, 10))); err == nil {
+		t.Errorf("expected error on short reader")
+	}
+}
+
+func TestRandBitsExamples(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for _, bits := range []int{0, 1, 7, 64, 65, 256} {
+		x, err := new(Nat).RandBits(bits, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !x.checkInvariants() || x.AnnouncedLen() != bits || x.TrueLen() > bits {
+			t.Errorf("invalid %d bit random value %+v", bits, x)
+		}
+	}
+	if _, err := new(Nat).RandBits(-1, r); err == nil {
+		t.Errorf("expected error for negative size")
+	}
+}
+
+func TestRandRangeExamples(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	lo := new(Nat).SetUint64(2)
+	hi := new(Nat).SetUint64(11)
+	seen := make(map[uint64]bool)
+	for i := 0; i < 1000; i++ {
+		x, err := new(Nat).RandRange(lo, hi, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !x.checkInvariants() || x.AnnouncedLen() != hi.AnnouncedLen() {
+			t.Errorf("invalid random value %+v", x)
+		}
+		seen[x.Uint64()] = true
+	}
+	for i := uint64(2); i <= 11; i++ {
+		if !seen[i] {
+			t.Errorf("%d was never sampled", i)
+		}
+	}
+	if len(seen) != 10 {
+		t.Errorf("sampled values outside of [2, 11]: %+v", seen)
+	}
+	// A range with a single value
+	x, err := new(Nat).RandRange(hi, hi, r)
+	if err != nil || x.Eq(hi) != 1 {
+		t.Errorf("%+v != %+v", hi, x)
+	}
+	if _, err := new(Nat).RandRange(hi, lo, r); err == nil {
+		t.Errorf("expected error for empty range")
+	}
+}
+
+func TestExpBlindedExamples(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	// 1009 * 1013, with phi = 1008 * 1012
+	m := ModulusFromUint64(1009 * 1013)
+	order := new(Nat).SetUint64(1008 * 1012)
+	for _, base := range []uint64{2, 3, 12345, 1000000} {
+		x := new(Nat).SetUint64(base)
+		for _, exp := range []uint64{0, 1, 65537, 1 << 40} {
+			y := new(Nat).SetUint64(exp)
+			expected := new(Nat).Exp(x, y, m)
+			actual, err := new(Nat).ExpBlinded(x, y, m, order, r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !actual.checkInvariants() || actual.Eq(expected) != 1 {
+				t.Errorf("%d^%d: %+v != %+v", base, exp, expected, actual)
+			}
+		}
+	}
+	if _, err := new(Nat).ExpBlinded(new(Nat).SetUint64(2), order, m, order, bytes.NewReader(nil)); err == nil {
+		t.Errorf("expected error on empty reader")
+	}
+}
+
+func testModulusSquare(m Modulus) bool {
+	actual := m.Square()
+	expected := ModulusFromNat(new(Nat).Mul(&m.nat, &m.nat, -1))
+	if actual.nat.Eq(&expected.nat) != 1 {
+		return false
+	}
+	if actual.nat.announced != expected.nat.announced || actual.leading != expected.leading {
+		return false
+	}
+	if actual.even != expected.even || actual.m0inv != expected.m0inv {
+		return false
+	}
+	return cmpEq(actual.reduction().rr, expected.reduction().rr) == 1 && cmpEq(actual.reduction().mu, expected.reduction().mu) == 1
+}
+
+func TestModulusSquare(t *testing.T) {
+	err := quick.Check(testModulusSquare, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPaillierLExamples(t *testing.T) {
+	// n = 1009 * 1013, with lambda = lcm(1008, 1012)
+	n := ModulusFromUint64(1009 * 1013)
+	nSquared := n.Square()
+	lambda := new(Nat).SetUint64(1008 * 1012 / 4)
+	// With g = n + 1, g^lambda = 1 + lambda * n mod n^2, so L gives us back lambda
+	g := new(Nat).Add(n.Nat(), new(Nat).SetUint64(1), -1)
+	x := new(Nat).Exp(g, lambda, nSquared)
+	actual := new(Nat).PaillierL(x, n)
+	expected := new(Nat).Mod(lambda, n)
+	if !actual.checkInvariants() || actual.Eq(expected) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	// A full round of encryption and decryption, with randomness r
+	m := new(Nat).SetUint64(424242)
+	r := new(Nat).SetUint64(777)
+	c := new(Nat).Exp(g, m, nSquared)
+	c.ModMul(c, new(Nat).Exp(r, n.Nat(), nSquared), nSquared)
+	mu := new(Nat).ModInverse(actual, n)
+	decrypted := new(Nat).PaillierL(new(Nat).Exp(c, lambda, nSquared), n)
+	decrypted.ModMul(decrypted, mu, n)
+	if decrypted.Eq(new(Nat).Mod(m, n)) != 1 {
+		t.Errorf("%+v != %+v", m, decrypted)
+	}
+}
+
+func TestNatClear(t *testing.T) {
+	x := new(Nat).SetBytes([]byte{0xAA, 0xBB, 0xCC, 0xDD, 0xEE, 0xFF, 0x00, 0x11, 0x22})
+	x.Resize(64)
+	backing := x.limbs[:cap(x.limbs)]
+	x.Clear()
+	if x.EqZero() != 1 || x.AnnouncedLen() != 0 || !x.checkInvariants() {
+		t.Errorf("%+v is not cleared", x)
+	}
+	for _, limb := range backing {
+		if limb != 0 {
+			t.Errorf("limb %+v was not cleared", limb)
+		}
+	}
+	x.SetUint64(7)
+	if x.Eq(new(Nat).SetUint64(7)) != 1 {
+		t.Errorf("%+v != 7", x)
+	}
+}
+
+func TestModulusClear(t *testing.T) {
+	for _, m := range []*Modulus{ModulusFromUint64(13), ModulusFromUint64(14)} {
+		limbs := m.nat.limbs
+		rr := m.reduction().rr
+		mu := m.reduction().mu
+		m.Clear()
+		r, _ := m.precomputed.Load().(*modulusReduction)
+		if m.BitLen() != 0 || r != nil || m.m0inv != 0 {
+			t.Errorf("%+v is not cleared", m)
+		}
+		for _, backing := range [][]Word{limbs, rr, mu} {
+			for _, limb := range backing[:cap(backing)] {
+				if limb != 0 {
+					t.Errorf("limb %+v was not cleared", limb)
+				}
+			}
+		}
+	}
+}
+
+func TestBitExamples(t *testing.T) {
+	x := new(Nat).SetUint64(0b1011)
+	for i, expected := range []Choice{1, 1, 0, 1, 0} {
+		actual := x.Bit(i)
+		if actual != expected {
+			t.Errorf("%+v != %+v", expected, actual)
+		}
+	}
+	if x.Bit(1000) != 0 {
+		t.Errorf("bit past the end should be 0")
+	}
+}
+
+func testSetBitMatchesBig(x Nat, i uint8, b bool) bool {
+	var bit Choice
+	if b {
+		bit = 1
+	}
+	actual := new(Nat).SetBit(&x, int(i), bit)
+	if !actual.checkInvariants() {
+		return false
+	}
+	expected := new(big.Int).SetBit(x.Big(), int(i), uint(bit))
+	return actual.Big().Cmp(expected) == 0 && actual.Bit(int(i)) == bit
+}
+
+func TestSetBitMatchesBig(t *testing.T) {
+	err := quick.Check(testSetBitMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testTruncateBitsMatchesBig(x Nat, k uint8) bool {
+	actual := new(Nat).TruncateBits(&x, int(k))
+	if !actual.checkInvariants() || actual.AnnouncedLen() != int(k) {
+		return false
+	}
+	mask := new(big.Int).Lsh(big.NewInt(1), uint(k))
+	mask.Sub(mask, big.NewInt(1))
+	expected := new(big.Int).And(x.Big(), mask)
+	return actual.Big().Cmp(expected) == 0
+}
+
+func TestTruncateBitsMatchesBig(t *testing.T) {
+	err := quick.Check(testTruncateBitsMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testRshSecretMatchesRsh(x Nat, shift uint16) bool {
+	s := uint(shift % 512)
+	actual := new(Nat).RshSecret(&x, s, -1)
+	if !actual.checkInvariants() || actual.AnnouncedLen() != x.AnnouncedLen() {
+		return false
+	}
+	expected := new(Nat).Rsh(&x, s, x.AnnouncedLen())
+	return actual.Eq(expected) == 1
+}
+
+func TestRshSecretMatchesRsh(t *testing.T) {
+	err := quick.Check(testRshSecretMatchesRsh, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testLshSecretMatchesLsh(x Nat, shift uint16) bool {
+	s := uint(shift % 512)
+	actual := new(Nat).LshSecret(&x, s, -1)
+	if !actual.checkInvariants() || actual.AnnouncedLen() != x.AnnouncedLen() {
+		return false
+	}
+	expected := new(Nat).Lsh(&x, s, x.AnnouncedLen())
+	return actual.Eq(expected) == 1
+}
+
+func TestLshSecretMatchesLsh(t *testing.T) {
+	skipIfCheckingCaps(t)
+	err := quick.Check(testLshSecretMatchesLsh, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestShiftSecretExamples(t *testing.T) {
+	x := new(Nat).SetUint64(0xF0)
+	actual := new(Nat).RshSecret(x, 4, -1)
+	expected := new(Nat).SetUint64(0xF)
+	if actual.Eq(expected) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	actual = new(Nat).LshSecret(x, 4, 12)
+	expected = new(Nat).SetUint64(0xF00)
+	if actual.Eq(expected) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	// Shifting everything out gives 0
+	actual = new(Nat).RshSecret(x, 1000, -1)
+	if actual.EqZero() != 1 {
+		t.Errorf("%+v != 0", actual)
+	}
+	actual = new(Nat).LshSecret(x, 1000, 128)
+	if actual.EqZero() != 1 {
+		t.Errorf("%+v != 0", actual)
+	}
+}
+
+func testBitwiseMatchesBig(x Nat, y Nat) bool {
+	bigX, bigY := x.Big(), y.Big()
+	for _, c := range []struct {
+		actual   *Nat
+		expected *big.Int
+	}{
+		{new(Nat).And(&x, &y, -1), new(big.Int).And(bigX, bigY)},
+		{new(Nat).Or(&x, &y, -1), new(big.Int).Or(bigX, bigY)},
+		{new(Nat).Xor(&x, &y, -1), new(big.Int).Xor(bigX, bigY)},
+		{new(Nat).AndNot(&x, &y, -1), new(big.Int).AndNot(bigX, bigY)},
+	} {
+		if !c.actual.checkInvariants() || c.actual.AnnouncedLen() != x.maxAnnounced(&y) {
+			return false
+		}
+		if c.actual.Big().Cmp(c.expected) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBitwiseMatchesBig(t *testing.T) {
+	err := quick.Check(testBitwiseMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testNotIsSubtractionFromAllOnes(x Nat) bool {
+	actual := new(Nat).Not(&x, -1)
+	if !actual.checkInvariants() {
+		return false
+	}
+	allOnes := new(big.Int).Lsh(big.NewInt(1), uint(x.AnnouncedLen()))
+	allOnes.Sub(allOnes, big.NewInt(1))
+	return actual.Big().Cmp(allOnes.Sub(allOnes, x.Big())) == 0
+}
+
+func TestNotIsSubtractionFromAllOnes(t *testing.T) {
+	err := quick.Check(testNotIsSubtractionFromAllOnes, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBitwiseExamples(t *testing.T) {
+	x := new(Nat).SetUint64(0b1100)
+	y := new(Nat).SetUint64(0b1010)
+	// Truncating the capacity keeps only the low bits
+	actual := new(Nat).Or(x, y, 3)
+	expected := new(Nat).SetUint64(0b110)
+	if actual.Eq(expected) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	x.Xor(x, y, -1)
+	expected = new(Nat).SetUint64(0b0110)
+	if x.Eq(expected) != 1 {
+		t.Errorf("%+v != %+v", expected, x)
+	}
+}
+
+func testTrailingZerosMatchesBig(x Nat) bool {
+	expected := uint(x.AnnouncedLen())
+	if bigX := x.Big(); bigX.Sign() != 0 {
+		expected = bigX.TrailingZeroBits()
+	}
+	return uint(x.TrailingZeros()) == expected
+}
+
+func TestTrailingZerosMatchesBig(t *testing.T) {
+	err := quick.Check(testTrailingZerosMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testPopCountMatchesBig(x Nat) bool {
+	bigX := x.Big()
+	expected := 0
+	for i := 0; i < bigX.BitLen(); i++ {
+		expected += int(bigX.Bit(i))
+	}
+	return int(x.PopCount()) == expected
+}
+
+func TestPopCountMatchesBig(t *testing.T) {
+	err := quick.Check(testPopCountMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTrailingZerosExamples(t *testing.T) {
+	x := new(Nat).SetUint64(0b1011000)
+	if x.TrailingZeros() != 3 {
+		t.Errorf("%+v != %+v", 3, x.TrailingZeros())
+	}
+	if x.PopCount() != 3 {
+		t.Errorf("%+v != %+v", 3, x.PopCount())
+	}
+	zero := new(Nat).Resize(100)
+	if zero.TrailingZeros() != 100 {
+		t.Errorf("%+v != %+v", 100, zero.TrailingZeros())
+	}
+}
+
+func testNormalizePreservesValue(x Nat) bool {
+	normalized := x.Clone().Normalize()
+	if !normalized.checkInvariants() || normalized.AnnouncedLen() != x.TrueLen() {
+		return false
+	}
+	return normalized.Big().Cmp(x.Big()) == 0
+}
+
+func TestNormalizePreservesValue(t *testing.T) {
+	err := quick.Check(testNormalizePreservesValue, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNormalizeReducedExamples(t *testing.T) {
+	m := ModulusFromUint64(0xFFFF)
+	x := new(Nat).SetUint64(3)
+	x.Mod(x, m)
+	x.Normalize()
+	if !x.checkInvariants() || x.AnnouncedLen() != 2 {
+		t.Errorf("%+v != %+v", 2, x.AnnouncedLen())
+	}
+}
+
+func testModulusNearPowerOfTwoMatchesBig(k uint16, c uint64, x Nat, y Nat) bool {
+	bits := 8 + int(k%600)
+	c &= (1 << (bits/2 - 1)) - 1
+	if c == 0 {
+		c = 1
+	}
+	m := ModulusNearPowerOfTwo(bits, c)
+	bigM := new(big.Int).Lsh(big.NewInt(1), uint(bits))
+	bigM.Sub(bigM, new(big.Int).SetUint64(c))
+	if m.Big().Cmp(bigM) != 0 || m.BitLen() != bits {
+		return false
+	}
+	product := new(Nat).ModMul(&x, &y, m)
+	expected := new(big.Int).Mul(x.Big(), y.Big())
+	expected.Mod(expected, bigM)
+	if !product.checkInvariants() || product.Big().Cmp(expected) != 0 {
+		return false
+	}
+	square := new(Nat).ModSqr(&x, m)
+	expected.Mul(x.Big(), x.Big())
+	expected.Mod(expected, bigM)
+	if !square.checkInvariants() || square.Big().Cmp(expected) != 0 {
+		return false
+	}
+	reduced := new(Nat).Mod(&x, m)
+	expected.Mod(x.Big(), bigM)
+	return reduced.checkInvariants() && reduced.Big().Cmp(expected) == 0
+}
+
+func TestModulusNearPowerOfTwoMatchesBig(t *testing.T) {
+	err := quick.Check(testModulusNearPowerOfTwoMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestModulusNearPowerOfTwoExamples(t *testing.T) {
+	m := ModulusNearPowerOfTwo(255, 19)
+	expected, _ := ModulusFromHex("7FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFED")
+	if _, eq, _ := m.Cmp(expected); eq != 1 {
+		t.Errorf("%+v != %+v", expected, m)
+	}
+	// The largest possible product should still be reduced correctly
+	x := new(Nat).Sub(m.Nat(), new(Nat).SetUint64(1), -1)
+	actual := new(Nat).ModMul(x, x, m)
+	one := new(Nat).SetUint64(1)
+	if actual.Eq(one) != 1 {
+		t.Errorf("%+v != %+v", one, actual)
+	}
+	for _, c := range []uint64{0, 1 << 20} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic for c = %+v", c)
+				}
+			}()
+			ModulusNearPowerOfTwo(40, c)
+		}()
+	}
+}
+
+func TestModulusIsOdd(t *testing.T) {
+	for _, x := range []uint64{1, 2, 3, 14, 15, 1 << 63} {
+		m := ModulusFromUint64(x)
+		expected := Choice(x & 1)
+		if m.IsOdd() != expected {
+			t.Errorf("%d: %+v != %+v", x, expected, m.IsOdd())
+		}
+	}
+}
+
+// skipIfCheckingCaps skips tests relying on operations like Add or Mul truncating their results
+//
+// With the safenum_checkcap tag, these operations panic instead.
+func skipIfCheckingCaps(t testing.TB) {
+	if checkCapBuild {
+		t.Skip("safenum_checkcap forbids truncating results")
+	}
+}
+
+func expectPanic(t *testing.T, name string, f func()) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("%s should have panicked", name)
+		}
+	}()
+	f()
+}
+
+func TestEvenModulusSupport(t *testing.T) {
+	moduli := []*Modulus{
+		ModulusFromUint64(2),
+		ModulusFromUint64(4),
+		ModulusFromUint64(1000),
+		ModulusFromBytes(modulus2048Even()),
+	}
+	x := new(Nat).SetUint64(0xABCDEF0123456789)
+	for _, m := range moduli {
+		if m.IsOdd() != 0 {
+			t.Errorf("%+v should be even", m)
+		}
+		mBig := m.Big()
+		xBig := x.Big()
+		// Exponentiation works
+		expected := new(big.Int).Exp(xBig, xBig, mBig)
+		actual := new(Nat).Exp(x, x, m)
+		if actual.Big().Cmp(expected) != 0 {
+			t.Errorf("%+v != %+v", expected, actual)
+		}
+		// Inversion works, and detects non invertible elements
+		for _, y := range []*Nat{x, new(Nat).Add(x, new(Nat).SetUint64(1), -1)} {
+			inv, ok := new(Nat).ModInverseChecked(y, m)
+			expectedInv := new(big.Int).ModInverse(y.Big(), mBig)
+			if (ok == 1) != (expectedInv != nil) {
+				t.Errorf("%+v mod %+v: unexpected ok %+v", y, m, ok)
+			}
+			if ok == 1 && inv.Big().Cmp(expectedInv) != 0 {
+				t.Errorf("%+v != %+v", expectedInv, inv)
+			}
+		}
+		// Square roots only exist for the prime 2
+		if m.BitLen() == 2 {
+			root, ok := new(Nat).ModSqrtChecked(x, m)
+			expectedRoot := new(big.Int).Mod(xBig, mBig)
+			if ok != 1 || root.Big().Cmp(expectedRoot) != 0 {
+				t.Errorf("%+v != %+v", expectedRoot, root)
+			}
+		} else {
+			expectPanic(t, "ModSqrt", func() { new(Nat).ModSqrt(x, m) })
+			expectPanic(t, "ModSqrtChecked", func() { new(Nat).ModSqrtChecked(x, m) })
+		}
+		expectPanic(t, "Jacobi", func() { x.Jacobi(m) })
+		if _, err := NewField(m); err == nil {
+			t.Errorf("NewField(%+v) should fail", m)
+		}
+	}
+}
+
+func TestChoiceCombinators(t *testing.T) {
+	for _, b := range []bool{false, true} {
+		c := ChoiceFromBool(b)
+		if c.Bool() != b {
+			t.Errorf("%+v != %+v", b, c.Bool())
+		}
+		if c.Not().Bool() != !b {
+			t.Errorf("%+v != %+v", !b, c.Not().Bool())
+		}
+		expected := Word(7)
+		if !b {
+			expected = 9
+		}
+		if actual := c.Select(7, 9); actual != expected {
+			t.Errorf("%+v != %+v", expected, actual)
+		}
+		for _, e := range []bool{false, true} {
+			d := ChoiceFromBool(e)
+			if c.And(d).Bool() != (b && e) {
+				t.Errorf("%v && %v: %+v", b, e, c.And(d))
+			}
+			if c.Or(d).Bool() != (b || e) {
+				t.Errorf("%v || %v: %+v", b, e, c.Or(d))
+			}
+			if c.Xor(d).Bool() != (b != e) {
+				t.Errorf("%v != %v: %+v", b, e, c.Xor(d))
+			}
+		}
+	}
+}
+
+func testCondCopyVVMatchesGeneric(v bool, x []Word, y []Word) bool {
+	if len(y) > len(x) {
+		y = y[:len(x)]
+	}
+	x = x[:len(y)]
+	expected := append([]Word{}, x...)
+	condCopyVV_g(Word(ChoiceFromBool(v)), expected, y)
+	actual := append([]Word{}, x...)
+	condCopyVV(Word(ChoiceFromBool(v)), actual, y)
+	return cmpEq(expected, actual) == 1 && len(expected) == len(actual)
+}
+
+func TestCondCopyVVMatchesGeneric(t *testing.T) {
+	err := quick.Check(testCondCopyVVMatchesGeneric, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testAddVVMatchesGeneric(x []Word, y []Word) bool {
+	if len(y) > len(x) {
+		y = y[:len(x)]
+	}
+	x = x[:len(y)]
+	expected := make([]Word, len(x))
+	expectedC := addVV_g(expected, x, y)
+	actual := make([]Word, len(x))
+	actualC := addVV(actual, x, y)
+	return expectedC == actualC && cmpEq(expected, actual) == 1
+}
+
+func testSubVVMatchesGeneric(x []Word, y []Word) bool {
+	if len(y) > len(x) {
+		y = y[:len(x)]
+	}
+	x = x[:len(y)]
+	expected := make([]Word, len(x))
+	expectedC := subVV_g(expected, x, y)
+	actual := make([]Word, len(x))
+	actualC := subVV(actual, x, y)
+	return expectedC == actualC && cmpEq(expected, actual) == 1
+}
+
+func testMulAddVWWMatchesGeneric(x []Word, y Word, r Word) bool {
+	expected := make([]Word, len(x))
+	expectedC := mulAddVWW_g(expected, x, y, r)
+	actual := make([]Word, len(x))
+	actualC := mulAddVWW(actual, x, y, r)
+	return expectedC == actualC && cmpEq(expected, actual) == 1
+}
+
+func testAddMulVVWMatchesGeneric(z []Word, x []Word, y Word) bool {
+	if len(x) < len(z) {
+		z = z[:len(x)]
+	}
+	expected := append([]Word{}, z...)
+	expectedC := addMulVVW_g(expected, x, y)
+	actual := append([]Word{}, z...)
+	actualC := addMulVVW(actual, x, y)
+	return expectedC == actualC && cmpEq(expected, actual) == 1
+}
+
+func testMulWWMatchesGeneric(x Word, y Word) bool {
+	expectedHi, expectedLo := mulWW_g(x, y)
+	actualHi, actualLo := mulWW(x, y)
+	return expectedHi == actualHi && expectedLo == actualLo
+}
+
+// These make sure that the assembly routines for the current architecture
+// agree with their generic counterparts.
+func TestArithMatchesGeneric(t *testing.T) {
+	config := &quick.Config{MaxCount: 1000}
+	if err := quick.Check(testAddVVMatchesGeneric, config); err != nil {
+		t.Errorf("addVV: %v", err)
+	}
+	if err := quick.Check(testSubVVMatchesGeneric, config); err != nil {
+		t.Errorf("subVV: %v", err)
+	}
+	if err := quick.Check(testMulAddVWWMatchesGeneric, config); err != nil {
+		t.Errorf("mulAddVWW: %v", err)
+	}
+	if err := quick.Check(testAddMulVVWMatchesGeneric, config); err != nil {
+		t.Errorf("addMulVVW: %v", err)
+	}
+	if err := quick.Check(testMulWWMatchesGeneric, config); err != nil {
+		t.Errorf("mulWW: %v", err)
+	}
+	// Saturated limbs stress every carry
+	for n := 0; n < 20; n++ {
+		x := make([]Word, n)
+		for i := range x {
+			x[i] = ^Word(0)
+		}
+		if !testAddVVMatchesGeneric(x, x) || !testSubVVMatchesGeneric(make([]Word, n), x) {
+			t.Errorf("carry mismatch with %d limbs", n)
+		}
+		if !testMulAddVWWMatchesGeneric(x, ^Word(0), ^Word(0)) || !testAddMulVVWMatchesGeneric(x, x, ^Word(0)) {
+			t.Errorf("multiplication mismatch with %d limbs", n)
+		}
+	}
+}
+
+// Values straddling limb boundaries on both 32 and 64 bit platforms,
+// where carries and borrows have to propagate across limbs.
+func limbBoundaryValues() []*big.Int {
+	var out []*big.Int
+	for _, shift := range []uint{31, 32, 63, 64, 96, 128} {
+		p := new(big.Int).Lsh(big.NewInt(1), shift)
+		out = append(out, new(big.Int).Sub(p, big.NewInt(1)), p, new(big.Int).Add(p, big.NewInt(1)))
+	}
+	return out
+}
+
+func TestLimbBoundariesMatchBig(t *testing.T) {
+	values := limbBoundaryValues()
+	m := ModulusFromBytes(new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 127), big.NewInt(1)).Bytes())
+	for _, xBig := range values {
+		for _, yBig := range values {
+			x := new(Nat).SetBig(xBig, xBig.BitLen())
+			y := new(Nat).SetBig(yBig, yBig.BitLen())
+			sum := new(big.Int).Add(xBig, yBig)
+			if actual := new(Nat).Add(x, y, -1).Big(); actual.Cmp(sum) != 0 {
+				t.Errorf("%v + %v: %v != %v", xBig, yBig, sum, actual)
+			}
+			product := new(big.Int).Mul(xBig, yBig)
+			if actual := new(Nat).Mul(x, y, -1).Big(); actual.Cmp(product) != 0 {
+				t.Errorf("%v * %v: %v != %v", xBig, yBig, product, actual)
+			}
+			if xBig.Cmp(yBig) >= 0 {
+				diff := new(big.Int).Sub(xBig, yBig)
+				if actual := new(Nat).Sub(x, y, -1).Big(); actual.Cmp(diff) != 0 {
+					t.Errorf("%v - %v: %v != %v", xBig, yBig, diff, actual)
+				}
+			}
+			expected := new(big.Int).Mod(product, m.Big())
+			if actual := new(Nat).ModMul(x, y, m).Big(); actual.Cmp(expected) != 0 {
+				t.Errorf("%v * %v mod m: %v != %v", xBig, yBig, expected, actual)
+			}
+			expected = new(big.Int).Exp(xBig, yBig, m.Big())
+			if actual := new(Nat).Exp(x, y, m).Big(); actual.Cmp(expected) != 0 {
+				t.Errorf("%v ^ %v mod m: %v != %v", xBig, yBig, expected, actual)
+			}
+		}
+	}
+}
+
+// Moduli which are exact powers of the limb base have the largest Barrett constant
+func TestModPowerOfLimbBase(t *testing.T) {
+	for _, limbs := range []uint{1, 2, 5} {
+		mBig := new(big.Int).Lsh(big.NewInt(1), limbs*_W)
+		m := ModulusFromBig(mBig)
+		x := new(Nat).SetBig(new(big.Int).Sub(new(big.Int).Mul(mBig, mBig), big.NewInt(1)), 2*int(limbs)*_W+2)
+		expected := new(big.Int).Mod(x.Big(), mBig)
+		actual := new(Nat).Mod(x, m)
+		if actual.Big().Cmp(expected) != 0 {
+			t.Errorf("%v != %+v", expected, actual)
+		}
+		if m.reduction().check(m) != 1 {
+			t.Errorf("invalid Barrett constant for 2^%d", limbs*_W)
+		}
+	}
+}
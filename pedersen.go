@@ -0,0 +1,73 @@
+package saferith
+
+// PedersenParams holds precomputed tables for Pedersen commitments modulo n.
+//
+// A commitment to x, using randomness r, is g^x h^r mod n. In protocols like
+// CMP or CGGMP, for threshold ECDSA, n is an RSA modulus, and computing these
+// commitments dominates the running time, which is why it's worth precomputing
+// powers of g and h, as with FixedBase.
+//
+// The parameters g, h, and n, are all considered public.
+type PedersenParams struct {
+	g *FixedBase
+	h *FixedBase
+}
+
+// NewPedersenParams precomputes the tables for committing modulo n, with generators g and h.
+//
+// The precomputed tables cover exponents of up to bits bits. Larger exponents can
+// still be used, but don't benefit from the precomputation.
+func NewPedersenParams(n *Modulus, g *Nat, h *Nat, bits int) *PedersenParams {
+	return &PedersenParams{g: NewFixedBase(g, n, bits), h: NewFixedBase(h, n, bits)}
+}
+
+// N returns the modulus commitments are computed with.
+func (p *PedersenParams) N() *Modulus {
+	return p.g.m
+}
+
+// G returns the generator the committed value is the exponent of.
+func (p *PedersenParams) G() *Nat {
+	return p.g.g.Clone()
+}
+
+// H returns the generator the randomness is the exponent of.
+func (p *PedersenParams) H() *Nat {
+	return p.h.g.Clone()
+}
+
+// Commit calculates g^x h^r mod n.
+//
+// Both exponents are processed together, window by window, multiplying a single
+// accumulator by entries selected in constant time from each table. Like Exp,
+// only the announced lengths of x and r are leaked.
+//
+// The capacity of the result matches the capacity of n.
+func (p *PedersenParams) Commit(x *Nat, r *Nat) *Nat {
+	m := p.N()
+	// LEAK: the lengths of x and r
+	// OK: these should be public
+	if x.announced > p.g.bits || r.announced > p.h.bits {
+		return new(Nat).MultiExp([]*Nat{p.g.g, p.h.g}, []*Nat{x, r}, m)
+	}
+	size := len(m.nat.limbs)
+	scratch := make([]Word, 2*size)
+	acc := new(Nat).SetNat(p.g.one)
+	selected := new(Nat).SetNat(p.g.one)
+
+	xLimbs := x.extendedLimbs(x.announced)
+	rLimbs := r.extendedLimbs(r.announced)
+	xWindows := (x.announced + fixedBaseWindow - 1) / fixedBaseWindow
+	rWindows := (r.announced + fixedBaseWindow - 1) / fixedBaseWindow
+	for i := 0; i < xWindows || i < rWindows; i++ {
+		if i < xWindows {
+			p.g.mulWindow(acc, xLimbs, i, selected, scratch)
+		}
+		if i < rWindows {
+			p.h.mulWindow(acc, rLimbs, i, selected, scratch)
+		}
+	}
+	z := p.g.finish(new(Nat), acc, scratch)
+	p.g.wipe(z, acc, selected, scratch)
+	return z
+}
@@ -0,0 +1,49 @@
+package saferith
+
+import (
+	"testing"
+	"testing/quick"
+)
+
+func testPedersenCommitMatchesMultiExp(g Nat, h Nat, x Nat, r Nat, m Modulus) bool {
+	bits := x.AnnouncedLen()
+	if r.AnnouncedLen() > bits {
+		bits = r.AnnouncedLen()
+	}
+	params := NewPedersenParams(&m, &g, &h, bits)
+	expected := new(Nat).MultiExp([]*Nat{&g, &h}, []*Nat{&x, &r}, &m)
+	actual := params.Commit(&x, &r)
+	return actual.checkInvariants() && actual.AnnouncedLen() == expected.AnnouncedLen() && actual.Eq(expected) == 1
+}
+
+func TestPedersenCommitMatchesMultiExp(t *testing.T) {
+	err := quick.Check(testPedersenCommitMatchesMultiExp, &quick.Config{MaxCount: 30})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testPedersenCommitLargeExponents(g Nat, h Nat, x Nat, r Nat, m Modulus) bool {
+	params := NewPedersenParams(&m, &g, &h, x.AnnouncedLen()/2)
+	expected := new(Nat).MultiExp([]*Nat{&g, &h}, []*Nat{&x, &r}, &m)
+	return params.Commit(&x, &r).Eq(expected) == 1
+}
+
+func TestPedersenCommitLargeExponents(t *testing.T) {
+	err := quick.Check(testPedersenCommitLargeExponents, &quick.Config{MaxCount: 20})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPedersenCommitHomomorphic(t *testing.T) {
+	m := ModulusFromUint64(1000003 * 999983)
+	params := NewPedersenParams(m, new(Nat).SetUint64(2), new(Nat).SetUint64(3), 64)
+	x0, r0 := new(Nat).SetUint64(1234), new(Nat).SetUint64(5678)
+	x1, r1 := new(Nat).SetUint64(4321), new(Nat).SetUint64(8765)
+	expected := params.Commit(new(Nat).Add(x0, x1, 64), new(Nat).Add(r0, r1, 64))
+	actual := new(Nat).ModMul(params.Commit(x0, r0), params.Commit(x1, r1), m)
+	if actual.Eq(expected) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+}
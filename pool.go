@@ -0,0 +1,62 @@
+package saferith
+
+import (
+	"math/bits"
+	"sync"
+	"sync/atomic"
+)
+
+// poolingEnabled is 1 if temporary buffers should be reused through scratchPools
+var poolingEnabled uint32
+
+// scratchPools holds Scratch values for reuse, keyed by size class.
+//
+// The Scratch values in scratchPools[i] were used with sizes in [2^(i-1), 2^i),
+// which makes sure that the space they've grown to is reasonable for other
+// sizes in the same class.
+var scratchPools [bits.UintSize + 1]sync.Pool
+
+// SetPooling controls whether or not temporary buffers are reused across operations.
+//
+// When enabled, operations like Exp and Mul take their scratch space from an internal
+// pool, rather than allocating it each time, which reduces pressure on the garbage collector.
+// Buffers are cleared before being returned to the pool, so no secret values are
+// retained in pooled memory. Pooling is disabled by default.
+//
+// This can be called concurrently with other operations.
+func SetPooling(enabled bool) {
+	var v uint32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreUint32(&poolingEnabled, v)
+}
+
+// getScratch returns a Scratch for temporary values used with a given number of limbs.
+//
+// If pooling is enabled, this Scratch is taken from the pool, and should be
+// returned to it using putScratch once it's no longer used.
+func getScratch(size int) *Scratch {
+	if atomic.LoadUint32(&poolingEnabled) == 1 {
+		if s, ok := scratchPools[bits.Len(uint(size))].Get().(*Scratch); ok {
+			return s
+		}
+	}
+	return new(Scratch)
+}
+
+// putScratch clears a Scratch, and returns it to the pool, if pooling is enabled.
+//
+// Without pooling, the Scratch is still cleared if zeroization is enabled.
+//
+// size should be the same value that was passed to getScratch.
+func putScratch(s *Scratch, size int) {
+	if atomic.LoadUint32(&poolingEnabled) == 0 {
+		if zeroizing() {
+			s.Clear()
+		}
+		return
+	}
+	s.Clear()
+	scratchPools[bits.Len(uint(size))].Put(s)
+}
@@ -0,0 +1,60 @@
+package saferith
+
+import (
+	"math/big"
+	"testing"
+	"testing/quick"
+)
+
+func testPooledExpMatchesBig(x Nat, y Nat, m Modulus) bool {
+	actual := new(Nat).Exp(&x, &y, &m)
+	if !actual.checkInvariants() {
+		return false
+	}
+	expected := new(big.Int).Exp(x.Big(), y.Big(), m.Big())
+	return actual.Big().Cmp(expected) == 0
+}
+
+func TestPooledExpMatchesBig(t *testing.T) {
+	SetPooling(true)
+	defer SetPooling(false)
+	err := quick.Check(testPooledExpMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPooledMulMatchesBig(t *testing.T) {
+	SetPooling(true)
+	defer SetPooling(false)
+	// Repeating a size makes sure that a pooled buffer gets reused
+	for _, limbs := range []int{karatsubaThreshold, 2*karatsubaThreshold + 1, karatsubaThreshold} {
+		bytes := make([]byte, limbs*_S)
+		for i := 0; i < len(bytes); i++ {
+			bytes[i] = byte(3*i + limbs)
+		}
+		x := new(Nat).SetBytes(bytes)
+		actual := new(Nat).Mul(x, x, -1)
+		expected := new(big.Int).Mul(x.Big(), x.Big())
+		if actual.Big().Cmp(expected) != 0 {
+			t.Errorf("%+v != %+v", expected, actual.Big())
+		}
+	}
+}
+
+func TestPoolingReducesAllocations(t *testing.T) {
+	m := ModulusFromBytes(modulus2048())
+	x := new(Nat).SetBytes(ones())
+	z := new(Nat)
+	exp := func() { z.Exp(x, x, m) }
+
+	unpooled := testing.AllocsPerRun(10, exp)
+	SetPooling(true)
+	defer SetPooling(false)
+	// Make sure the pool has been filled
+	exp()
+	pooled := testing.AllocsPerRun(10, exp)
+	if pooled >= unpooled {
+		t.Errorf("pooled allocations %+v >= unpooled allocations %+v", pooled, unpooled)
+	}
+}
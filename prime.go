@@ -0,0 +1,384 @@
+package saferith
+
+import (
+	"errors"
+	"io"
+	"math/bits"
+)
+
+// trialDivisionLimit bounds the small primes checked by TrialDivide.
+const trialDivisionLimit = 1024
+
+// smallPrimeGroup is a set of small primes, whose product fits in a uint64.
+type smallPrimeGroup struct {
+	product uint64
+	primes  []uint64
+}
+
+// smallPrimeGroups contains all the primes below trialDivisionLimit.
+//
+// Grouping them lets us reduce a large number once per group, rather than once
+// per prime, since the residue modulo each prime can be recovered from the
+// residue modulo their product.
+var smallPrimeGroups = makeSmallPrimeGroups(trialDivisionLimit)
+
+// makeSmallPrimeGroups sieves the primes below limit, grouping them together.
+func makeSmallPrimeGroups(limit int) []smallPrimeGroup {
+	composite := make([]bool, limit)
+	var groups []smallPrimeGroup
+	current := smallPrimeGroup{product: 1}
+	for p := 2; p < limit; p++ {
+		if composite[p] {
+			continue
+		}
+		// Checking p <= limit / p avoids overflowing p * p
+		for k := p * p; p <= limit/p && k < limit; k += p {
+			composite[k] = true
+		}
+		hi, product := bits.Mul64(current.product, uint64(p))
+		if hi != 0 {
+			groups = append(groups, current)
+			current = smallPrimeGroup{product: 1}
+			product = uint64(p)
+		}
+		current.product = product
+		current.primes = append(current.primes, uint64(p))
+	}
+	return append(groups, current)
+}
+
+// TrialDivide checks that n has no small prime factors, other than itself
+//
+// This returns 1 if none of the primes below 1024 divide n, or if n is one of
+// these primes. This is much cheaper than a round of Miller-Rabin, and rejects
+// most composite numbers, which makes it a useful filter to apply before
+// ProbablyPrime, when generating random primes. Note that 0 is rejected, but
+// 1 isn't, since it has no prime factors at all.
+//
+// Only the announced length of n is leaked, and not which primes divide it.
+func (n *Nat) TrialDivide() Choice {
+	return n.trialDivide(smallPrimeGroups)
+}
+
+// trialDivide returns 1 if none of the primes in groups divide n, other than n itself.
+func (n *Nat) trialDivide(groups []smallPrimeGroup) Choice {
+	pass := Choice(1)
+	for _, group := range groups {
+		var residue [uint64Limbs]Word
+		r := n.ModUint64(group.product)
+		for i := range residue {
+			residue[i] = uint64Limb(r, i)
+		}
+		for _, p := range group.primes {
+			// p fits in an int, so the remainder fits in a single Word
+			divisible := ctEq(Word(divUint64(nil, residue[:], p)), 0)
+			pass &= 1 ^ (divisible & (1 ^ n.EqUint64(p)))
+		}
+	}
+	return pass
+}
+
+// millerRabin checks whether n passes the Miller-Rabin test for the witness a
+//
+// n - 1 = d * 2^s, with d odd, and a should be reduced modulo n. Neither the
+// witness, nor s, are leaked, since we always do the maximum number of squarings.
+func millerRabin(a *Nat, d *Nat, s Word, n *Modulus) Choice {
+	one := new(Nat).SetUint64(1)
+	one.Mod(one, n)
+	minusOne := new(Nat).ModNeg(one, n)
+
+	y := new(Nat).Exp(a, d, n)
+	pass := y.Eq(one) | y.Eq(minusOne)
+	// LEAK: the size of n
+	// OK: this is public
+	for i := 1; i < n.BitLen(); i++ {
+		y.ModSqr(y, n)
+		pass |= ctGt(s, Word(i)) & y.Eq(minusOne)
+	}
+	return pass
+}
+
+// checkRounds panics if the number of rounds for a primality test isn't positive
+//
+// With no rounds at all, a test would accept any number that passes the initial
+// checks, including composite numbers, so this is always a mistake.
+func checkRounds(rounds int) {
+	if rounds < 1 {
+		panic("saferith: rounds must be positive")
+	}
+}
+
+// ProbablyPrime checks whether or not n is prime, using Miller-Rabin
+//
+// This does the given number of rounds, with each round using a random witness,
+// sampled using rand. The probability that a composite number passes is at most
+// 4^-rounds. If reading from rand fails, this returns false. At least one
+// round is needed, and this will panic if rounds < 1.
+//
+// Each round is done in constant-time, with the exponent being hidden by always doing
+// the maximum number of squarings, so only the number of rounds, the true size of n,
+// and the result are leaked. Small values, even numbers, and numbers with small prime
+// factors, are rejected early, since the result already reveals this information.
+func (n *Nat) ProbablyPrime(rounds int, rand io.Reader) bool {
+	checkRounds(rounds)
+	if prime, done := n.checkSmallFactors(); done {
+		return prime
+	}
+	m := ModulusFromNat(n)
+	d, s := n.millerRabinSplit()
+
+	// Witnesses are sampled uniformly in [2, n - 2]
+	two := new(Nat).SetUint64(2)
+	nMinus2 := new(Nat).Sub(n, two, n.announced)
+	a := new(Nat)
+	for i := 0; i < rounds; i++ {
+		if _, err := a.RandRange(two, nMinus2, rand); err != nil {
+			return false
+		}
+		if millerRabin(a, d, s, m) != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// checkSmallFactors handles the cases where n's primality is obvious
+//
+// If n is < 5, even, has a small prime factor, or is small enough for the
+// absence of small factors to prove that it's prime, then done will be true,
+// and prime indicates whether or not n is prime. Otherwise, n is odd, at least
+// 1024^2, and has no prime factors below 1024.
+func (n *Nat) checkSmallFactors() (prime bool, done bool) {
+	// LEAK: whether n < 5, or n is even
+	// OK: these cases are determined by the result, except for 2 and 3
+	if _, _, lt := n.Cmp(new(Nat).SetUint64(5)); lt == 1 {
+		small := n.Uint64()
+		return small == 2 || small == 3, true
+	}
+	if n.limbs[0]&1 == 0 {
+		return false, true
+	}
+	// LEAK: whether n has a small factor
+	// OK: this is determined by the result, since n is neither 2 nor 3
+	if n.TrialDivide() != 1 {
+		return false, true
+	}
+	// LEAK: whether n < 1024^2
+	// OK: the true size of n is already leaked by the other tests
+	if _, _, lt := n.CmpUint64(trialDivisionLimit * trialDivisionLimit); lt == 1 {
+		// Any composite number below this has a prime factor below 1024
+		return true, true
+	}
+	return false, false
+}
+
+// millerRabinSplit returns d, s, with n - 1 = d * 2^s, and d odd
+func (n *Nat) millerRabinSplit() (*Nat, Word) {
+	d := new(Nat).Sub(n, new(Nat).SetUint64(1), n.announced)
+	s := trailingZeros(d.limbs)
+	ctShr(d.limbs, s)
+	return d, s
+}
+
+// lucasParameters finds the parameters for the strong Lucas test, using Selfridge's method
+//
+// This returns the first D in 5, -7, 9, -11, ... with (D / n) = -1, along with
+// Q = (1 - D) / 4, reduced modulo n. The parameter P is always 1. If some D shares
+// a factor with n, then n is composite, and ok will be false.
+//
+// n must be odd, larger than any D we try, and not a perfect square.
+func lucasParameters(m *Modulus) (d int64, q *Nat, ok bool) {
+	for d = 5; ; {
+		abs := d
+		if abs < 0 {
+			abs = -abs
+		}
+		dNat := new(Nat).SetUint64(uint64(abs))
+		if d < 0 {
+			dNat.ModNeg(dNat, m)
+		}
+		// LEAK: the Jacobi symbol of each D we try
+		// OK: this is a public function of n, which only reveals its residues
+		// modulo a few small numbers, and the search stops after a handful of steps
+		// on average.
+		switch dNat.Jacobi(m) {
+		case 0:
+			return d, nil, false
+		case -1:
+			q = new(Nat).SetUint64(uint64(1-d) / 4)
+			if d > 0 {
+				// (1 - D) / 4 is negative when D is positive
+				q.SetUint64(uint64(d-1) / 4)
+				q.ModNeg(q.Mod(q, m), m)
+			}
+			return d, q.Mod(q, m), true
+		}
+		if d > 0 {
+			d = -(d + 2)
+		} else {
+			d = -d + 2
+		}
+	}
+}
+
+// strongLucas checks whether n passes the strong Lucas test, with P = 1, and Q given
+//
+// With n + 1 = d * 2^s, d odd, n passes if U_d = 0, or V_(d * 2^r) = 0 mod n,
+// for some 0 <= r < s. We only compute the V sequence, along with Q^k, using a
+// ladder over the bits of d, since U_d = 0 exactly when 2 V_(d + 1) = P V_d,
+// as long as D is invertible modulo n.
+//
+// Like millerRabin, neither d, nor s are leaked, since we go over every bit of
+// n + 1, and always do the maximum number of doublings.
+func strongLucas(n *Nat, q *Nat, m *Modulus) Choice {
+	// n + 1 = d * 2^s
+	d := new(Nat).Add(n, new(Nat).SetUint64(1), n.announced+1)
+	s := trailingZeros(d.limbs)
+	ctShr(d.limbs, s)
+	dLimbs := d.extendedLimbs(d.announced)
+
+	two := new(Nat).Mod(new(Nat).SetUint64(2), m)
+	// V_0 = 2, V_1 = P = 1, and Q^0 = 1
+	vk := new(Nat).SetNat(two)
+	vk1 := new(Nat).Mod(new(Nat).SetUint64(1), m)
+	qk := new(Nat).Mod(new(Nat).SetUint64(1), m)
+
+	cross := new(Nat)
+	qSelected := new(Nat)
+	vSelected := new(Nat)
+	for i := d.announced - 1; i >= 0; i-- {
+		bit := Choice((dLimbs[i/_W] >> (uint(i) % _W)) & 1)
+		// We go from k to 2k + bit, using:
+		//   V_(2k) = V_k^2 - 2 Q^k
+		//   V_(2k + 1) = V_k V_(k + 1) - P Q^k
+		// with V_(2k + 2) following from the first, using k + 1 in place of k.
+		cross.ModMul(vk, vk1, m)
+		cross.ModSub(cross, qk, m)
+		qSelected.ModMul(qk, q, m)
+		ctCondCopy(1^bit, qSelected.limbs, qk.limbs)
+		vSelected.SetNat(vk)
+		ctCondCopy(bit, vSelected.limbs, vk1.limbs)
+		vSelected.ModSqr(vSelected, m)
+		vSelected.ModSub(vSelected, qSelected, m)
+		vSelected.ModSub(vSelected, qSelected, m)
+		// Q^(2k + bit) = Q^k Q^(k + bit)
+		qk.ModMul(qk, qSelected, m)
+		vk.SetNat(vSelected)
+		vk1.SetNat(cross)
+		ctCondSwap(bit, vk.limbs, vk1.limbs)
+	}
+
+	// U_d = 0 when 2 V_(d + 1) - V_d = 0
+	u := new(Nat).ModAdd(vk1, vk1, m)
+	u.ModSub(u, vk, m)
+	pass := u.EqZero() | vk.EqZero()
+	// LEAK: the size of n
+	// OK: this is public
+	for i := 1; i <= m.BitLen(); i++ {
+		// V_(2k) = V_k^2 - 2 Q^k, and Q^(2k) = (Q^k)^2
+		vk.ModSqr(vk, m)
+		vk.ModSub(vk, qk, m)
+		vk.ModSub(vk, qk, m)
+		qk.ModSqr(qk, m)
+		pass |= ctGt(s, Word(i)) & vk.EqZero()
+	}
+	return pass
+}
+
+// lucasCheck runs the strong Lucas test on n, after checkSmallFactors
+//
+// The choice of parameters is leaked, as is whether n is a perfect square,
+// which would make it composite.
+func (n *Nat) lucasCheck(m *Modulus) bool {
+	// LEAK: whether n is a perfect square
+	// OK: this is determined by the result, since squares are composite
+	if n.IsPerfectSquare() == 1 {
+		return false
+	}
+	_, q, ok := lucasParameters(m)
+	if !ok {
+		return false
+	}
+	return strongLucas(n, q, m) == 1
+}
+
+// ProbablyPrimeLucas checks whether or not n is a strong Lucas probable prime
+//
+// This uses the parameters chosen by Selfridge's method: D is the first number
+// in 5, -7, 9, -11, ..., which isn't a square modulo n, with P = 1, and
+// Q = (1 - D) / 4. No randomness is needed, but some composite numbers do pass.
+// This test is mostly useful as part of ProbablyPrimeBPSW.
+//
+// Beyond what ProbablyPrime leaks, the Jacobi symbols (D / n) used to find the
+// parameters are leaked. The test itself hides the factorization of n + 1, like
+// Miller-Rabin does for n - 1.
+func (n *Nat) ProbablyPrimeLucas() bool {
+	if prime, done := n.checkSmallFactors(); done {
+		return prime
+	}
+	return n.lucasCheck(ModulusFromNat(n))
+}
+
+// ProbablyPrimeBPSW checks whether or not n is prime, using the Baillie-PSW test
+//
+// This combines a Miller-Rabin round with the witness 2, along with a strong Lucas
+// test, as in ProbablyPrimeLucas. No composite number is known to pass this test,
+// and none exist below 2^64. Unlike ProbablyPrime, this doesn't need a source
+// of randomness, but also doesn't come with a bound on the error probability for
+// adversarial inputs. For extra safety, this can be combined with ProbablyPrime.
+//
+// This leaks the same information as ProbablyPrimeLucas.
+func (n *Nat) ProbablyPrimeBPSW() bool {
+	if prime, done := n.checkSmallFactors(); done {
+		return prime
+	}
+	m := ModulusFromNat(n)
+	d, s := n.millerRabinSplit()
+	two := new(Nat).Mod(new(Nat).SetUint64(2), m)
+	if millerRabin(two, d, s, m) != 1 {
+		return false
+	}
+	return n.lucasCheck(m)
+}
+
+// deterministicWitnesses are the first 13 primes.
+//
+// Using each of these as a witness, Miller-Rabin has no false positives below
+// deterministicBound, as shown by Sorenson and Webster.
+var deterministicWitnesses = []uint64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37, 41}
+
+// deterministicBound is 3317044064679887385961981, which is just over 2^81.
+const deterministicBound = "2BE6951ADC5B22410A5FD"
+
+// DeterministicIsPrime checks whether or not n is prime, without any chance of error
+//
+// This works for n < 3317044064679887385961981, which is slightly above 2^81,
+// and returns an error for larger values. In that range, doing Miller-Rabin
+// with the first 13 primes as witnesses is enough to prove primality, so no
+// source of randomness is needed. This makes it useful for validating small
+// parameters, like cofactors.
+//
+// This leaks the same information as ProbablyPrime, along with whether or not
+// n is below the bound.
+func (n *Nat) DeterministicIsPrime() (bool, error) {
+	bound, _ := new(Nat).SetHex(deterministicBound)
+	// LEAK: whether n is below the bound
+	// OK: this is only used for small parameters, which should be public
+	if _, _, lt := n.Cmp(bound); lt != 1 {
+		return false, errors.New("DeterministicIsPrime: n is too large")
+	}
+	if prime, done := n.checkSmallFactors(); done {
+		return prime, nil
+	}
+	m := ModulusFromNat(n)
+	d, s := n.millerRabinSplit()
+	a := new(Nat)
+	for _, w := range deterministicWitnesses {
+		// n is above 1024^2, so every witness is already reduced
+		a.SetUint64(w)
+		if millerRabin(a, d, s, m) != 1 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
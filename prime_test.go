@@ -0,0 +1,203 @@
+package saferith
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestProbablyPrimeMatchesBig(t *testing.T) {
+	for i := uint64(0); i < 2000; i++ {
+		expected := new(big.Int).SetUint64(i).ProbablyPrime(20)
+		actual := new(Nat).SetUint64(i).ProbablyPrime(20, rand.Reader)
+		if expected != actual {
+			t.Errorf("%d: %+v != %+v", i, expected, actual)
+		}
+	}
+}
+
+func TestProbablyPrimeExamples(t *testing.T) {
+	primes := []string{
+		"FFFFFFFF00000001000000000000000000000000FFFFFFFFFFFFFFFFFFFFFFFF",
+		"7FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFED",
+		"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141",
+	}
+	for _, hex := range primes {
+		p, _ := new(Nat).SetHex(hex)
+		if !p.ProbablyPrime(20, rand.Reader) {
+			t.Errorf("%s should be prime", hex)
+		}
+		// The product of two primes is definitely not prime
+		q := new(Nat).Mul(p, p, -1)
+		if q.ProbablyPrime(20, rand.Reader) {
+			t.Errorf("%s^2 shouldn't be prime", hex)
+		}
+	}
+	// Carmichael numbers fool the Fermat test, but not Miller-Rabin
+	for _, c := range []uint64{561, 1105, 1729, 2465, 2821, 6601, 8911, 41041, 825265} {
+		if new(Nat).SetUint64(c).ProbablyPrime(20, rand.Reader) {
+			t.Errorf("%d shouldn't be prime", c)
+		}
+	}
+}
+
+func TestProbablyPrimeRequiresRounds(t *testing.T) {
+	// Without any rounds, this composite number would be accepted
+	p, _ := new(Nat).SetHex("FFFFFFFF00000001000000000000000000000000FFFFFFFFFFFFFFFFFFFFFFFF")
+	q, _ := new(Nat).SetHex("7FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFED")
+	n := new(Nat).Mul(p, q, -1)
+	for _, rounds := range []int{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("ProbablyPrime with %d rounds should have panicked", rounds)
+				}
+			}()
+			n.ProbablyPrime(rounds, rand.Reader)
+		}()
+	}
+}
+
+func TestTrialDivideMatchesBig(t *testing.T) {
+	for i := uint64(0); i < 1<<13; i++ {
+		expected := i != 0
+		for p := uint64(2); p < trialDivisionLimit && p < i; p++ {
+			if i%p == 0 && new(big.Int).SetUint64(p).ProbablyPrime(20) {
+				expected = false
+				break
+			}
+		}
+		actual := new(Nat).SetUint64(i).TrialDivide() == 1
+		if expected != actual {
+			t.Errorf("%d: %+v != %+v", i, expected, actual)
+		}
+	}
+}
+
+func TestTrialDivideExamples(t *testing.T) {
+	p, _ := new(Nat).SetHex("FFFFFFFF00000001000000000000000000000000FFFFFFFFFFFFFFFFFFFFFFFF")
+	if p.TrialDivide() != 1 {
+		t.Errorf("prime %+v was rejected", p)
+	}
+	// The largest prime below the limit, and the smallest prime above it
+	for _, small := range []uint64{1021, 1031} {
+		q := new(Nat).Mul(p, new(Nat).SetUint64(small), -1)
+		expected := Choice(0)
+		if small > trialDivisionLimit {
+			expected = 1
+		}
+		if actual := q.TrialDivide(); actual != expected {
+			t.Errorf("%d: %+v != %+v", small, expected, actual)
+		}
+	}
+}
+
+func TestStrongLucasPseudoprimes(t *testing.T) {
+	// The strong Lucas pseudoprimes, with Selfridge's parameters, below 2^16
+	pseudoprimes := map[uint64]bool{
+		5459: true, 5777: true, 10877: true, 16109: true, 18971: true,
+		22499: true, 24569: true, 25199: true, 40309: true, 58519: true,
+	}
+	// Below 1024^2, ProbablyPrimeLucas doesn't need to run the test at all
+	for i := uint64(101); i < 1<<16; i += 2 {
+		n := new(Nat).SetUint64(i)
+		expected := pseudoprimes[i] || new(big.Int).SetUint64(i).ProbablyPrime(20)
+		actual := n.lucasCheck(ModulusFromNat(n))
+		if expected != actual {
+			t.Errorf("%d: %+v != %+v", i, expected, actual)
+		}
+	}
+}
+
+func TestProbablyPrimeBPSWMatchesBig(t *testing.T) {
+	for i := uint64(0); i < 2000; i++ {
+		expected := new(big.Int).SetUint64(i).ProbablyPrime(0)
+		actual := new(Nat).SetUint64(i).ProbablyPrimeBPSW()
+		if expected != actual {
+			t.Errorf("%d: %+v != %+v", i, expected, actual)
+		}
+	}
+	// Odd numbers above 1024^2, which can't be handled by trial division alone
+	for i := uint64(1<<40 + 1); i < 1<<40+4000; i += 2 {
+		expected := new(big.Int).SetUint64(i).ProbablyPrime(0)
+		for _, actual := range []bool{new(Nat).SetUint64(i).ProbablyPrimeBPSW(), new(Nat).SetUint64(i).ProbablyPrimeLucas()} {
+			if expected != actual {
+				t.Errorf("%d: %+v != %+v", i, expected, actual)
+			}
+		}
+	}
+}
+
+func TestProbablyPrimeBPSWExamples(t *testing.T) {
+	primes := []string{
+		"FFFFFFFF00000001000000000000000000000000FFFFFFFFFFFFFFFFFFFFFFFF",
+		"7FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFED",
+		"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141",
+	}
+	for _, hex := range primes {
+		p, _ := new(Nat).SetHex(hex)
+		if !p.ProbablyPrimeBPSW() || !p.ProbablyPrimeLucas() {
+			t.Errorf("%s should be prime", hex)
+		}
+		q := new(Nat).Mul(p, p, -1)
+		if q.ProbablyPrimeBPSW() || q.ProbablyPrimeLucas() {
+			t.Errorf("%s^2 shouldn't be prime", hex)
+		}
+	}
+	// 3215031751 is a strong pseudoprime to the bases 2, 3, 5, and 7
+	n := new(Nat).SetUint64(3215031751)
+	if n.ProbablyPrimeBPSW() {
+		t.Errorf("%s shouldn't be prime", n)
+	}
+	m := ModulusFromNat(n)
+	d, s := n.millerRabinSplit()
+	if millerRabin(new(Nat).SetUint64(2), d, s, m) != 1 {
+		t.Errorf("%s should pass Miller-Rabin with the witness 2", n)
+	}
+}
+
+func TestDeterministicIsPrimeMatchesBig(t *testing.T) {
+	for i := uint64(0); i < 2000; i++ {
+		expected := new(big.Int).SetUint64(i).ProbablyPrime(20)
+		actual, err := new(Nat).SetUint64(i).DeterministicIsPrime()
+		if err != nil || expected != actual {
+			t.Errorf("%d: %+v != %+v (%v)", i, expected, actual, err)
+		}
+	}
+	for i := uint64(1<<62 + 1); i < 1<<62+2000; i += 2 {
+		expected := new(big.Int).SetUint64(i).ProbablyPrime(20)
+		actual, err := new(Nat).SetUint64(i).DeterministicIsPrime()
+		if err != nil || expected != actual {
+			t.Errorf("%d: %+v != %+v (%v)", i, expected, actual, err)
+		}
+	}
+}
+
+func TestDeterministicIsPrimeExamples(t *testing.T) {
+	// These are strong pseudoprimes to the bases 2, 3, 5, 7, and then to every base up to 37
+	for _, hex := range []string{"BFA17DC7", "437AE92817F9FC85B7E5"} {
+		n, _ := new(Nat).SetHex(hex)
+		prime, err := n.DeterministicIsPrime()
+		if err != nil || prime {
+			t.Errorf("%s shouldn't be prime (%v)", hex, err)
+		}
+	}
+	// 2^61 - 1, and 2^89 - 1 are Mersenne primes, but only the first is small enough
+	p, _ := new(Nat).SetHex("1FFFFFFFFFFFFFFF")
+	if prime, err := p.DeterministicIsPrime(); err != nil || !prime {
+		t.Errorf("%s should be prime (%v)", p, err)
+	}
+	p, _ = new(Nat).SetHex("1FFFFFFFFFFFFFFFFFFFFFF")
+	if _, err := p.DeterministicIsPrime(); err == nil {
+		t.Errorf("%s should be too large", p)
+	}
+	// The bound itself is rejected, but the largest value below it isn't
+	bound, _ := new(Nat).SetHex(deterministicBound)
+	if _, err := bound.DeterministicIsPrime(); err == nil {
+		t.Errorf("%s should be too large", bound)
+	}
+	bound.Sub(bound, new(Nat).SetUint64(1), -1)
+	if _, err := bound.DeterministicIsPrime(); err != nil {
+		t.Error(err)
+	}
+}
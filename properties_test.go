@@ -0,0 +1,141 @@
+package saferith
+
+import (
+	"testing"
+	"testing/quick"
+)
+
+// This file contains property tests, checking that operations satisfy the
+// algebraic laws we expect of them, and that their results don't depend on
+// the announced size of their inputs, beyond what their documentation says.
+
+// resized returns a copy of x, announcing extra more bits
+func resized(x *Nat, extra uint8) *Nat {
+	return x.Clone().Resize(x.AnnouncedLen() + int(extra))
+}
+
+// unchanged checks that x still has the same value, and capacity, as before
+func unchanged(before *Nat, x *Nat) bool {
+	return x.checkInvariants() && x.AnnouncedLen() == before.AnnouncedLen() && x.Eq(before) == 1
+}
+
+func testModMulDistributesOverModSub(x Nat, y Nat, z Nat, m Modulus) bool {
+	left := new(Nat).ModMul(&x, new(Nat).ModSub(&y, &z, &m), &m)
+	right := new(Nat).ModSub(new(Nat).ModMul(&x, &y, &m), new(Nat).ModMul(&x, &z, &m), &m)
+	return left.Eq(right) == 1
+}
+
+func TestModMulDistributesOverModSub(t *testing.T) {
+	err := quick.Check(testModMulDistributesOverModSub, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testAddSubInverse(x Nat, y Nat) bool {
+	sum := new(Nat).Add(&x, &y, -1)
+	return new(Nat).Sub(sum, &y, x.AnnouncedLen()).Eq(&x) == 1
+}
+
+func TestAddSubInverse(t *testing.T) {
+	err := quick.Check(testAddSubInverse, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// x^a * x^b = x^(a + b), and (x^a)^b = x^(ab)
+func testExpLaws(x Nat, a uint16, b uint16, m Modulus) bool {
+	aNat := new(Nat).SetUint64(uint64(a))
+	bNat := new(Nat).SetUint64(uint64(b))
+	xa := new(Nat).Exp(&x, aNat, &m)
+	xb := new(Nat).Exp(&x, bNat, &m)
+	sum := new(Nat).Add(aNat, bNat, -1)
+	if new(Nat).ModMul(xa, xb, &m).Eq(new(Nat).Exp(&x, sum, &m)) != 1 {
+		return false
+	}
+	product := new(Nat).Mul(aNat, bNat, -1)
+	return new(Nat).Exp(xa, bNat, &m).Eq(new(Nat).Exp(&x, product, &m)) == 1
+}
+
+func TestExpLaws(t *testing.T) {
+	err := quick.Check(testExpLaws, &quick.Config{MaxCount: 20})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// Reducing the base first doesn't change the result
+func testModExpCommute(x Nat, e Nat, m Modulus) bool {
+	expected := new(Nat).Exp(&x, &e, &m)
+	return new(Nat).Exp(new(Nat).Mod(&x, &m), &e, &m).Eq(expected) == 1
+}
+
+func TestModExpCommute(t *testing.T) {
+	err := quick.Check(testModExpCommute, &quick.Config{MaxCount: 20})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// Results of modular operations are independent of the capacity of their inputs
+func testModularResizeInvariant(x Nat, y Nat, m Modulus, xExtra uint8, yExtra uint8) bool {
+	xBig, yBig := resized(&x, xExtra), resized(&y, yExtra)
+	xBefore, yBefore := xBig.Clone(), yBig.Clone()
+	checks := []struct{ small, big *Nat }{
+		{new(Nat).Mod(&x, &m), new(Nat).Mod(xBig, &m)},
+		{new(Nat).ModAdd(&x, &y, &m), new(Nat).ModAdd(xBig, yBig, &m)},
+		{new(Nat).ModSub(&x, &y, &m), new(Nat).ModSub(xBig, yBig, &m)},
+		{new(Nat).ModMul(&x, &y, &m), new(Nat).ModMul(xBig, yBig, &m)},
+		{new(Nat).Exp(&x, &y, &m), new(Nat).Exp(xBig, yBig, &m)},
+		{new(Nat).ModInverse(&x, &m), new(Nat).ModInverse(xBig, &m)},
+	}
+	for _, c := range checks {
+		if c.small.AnnouncedLen() != c.big.AnnouncedLen() || c.small.Eq(c.big) != 1 {
+			return false
+		}
+	}
+	return unchanged(xBefore, xBig) && unchanged(yBefore, yBig)
+}
+
+func TestModularResizeInvariant(t *testing.T) {
+	err := quick.Check(testModularResizeInvariant, &quick.Config{MaxCount: 50})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// With an explicit capacity, results only depend on the values of the inputs
+func testCappedResizeInvariant(x Nat, y Nat, xExtra uint8, yExtra uint8, cap uint8, shift uint8) bool {
+	c := int(cap)
+	xBig, yBig := resized(&x, xExtra), resized(&y, yExtra)
+	xBefore, yBefore := x.Clone(), y.Clone()
+	xBigBefore, yBigBefore := xBig.Clone(), yBig.Clone()
+	checks := []struct{ small, big *Nat }{
+		{new(Nat).Add(&x, &y, c), new(Nat).Add(xBig, yBig, c)},
+		{new(Nat).Sub(&x, &y, c), new(Nat).Sub(xBig, yBig, c)},
+		{new(Nat).Mul(&x, &y, c), new(Nat).Mul(xBig, yBig, c)},
+		{new(Nat).Lsh(&x, uint(shift), c), new(Nat).Lsh(xBig, uint(shift), c)},
+		{new(Nat).Rsh(&x, uint(shift), c), new(Nat).Rsh(xBig, uint(shift), c)},
+	}
+	for _, c := range checks {
+		if !c.small.checkInvariants() || c.small.AnnouncedLen() != c.big.AnnouncedLen() || c.small.Eq(c.big) != 1 {
+			return false
+		}
+	}
+	gt0, eq0, lt0 := x.Cmp(&y)
+	gt1, eq1, lt1 := xBig.Cmp(yBig)
+	if gt0 != gt1 || eq0 != eq1 || lt0 != lt1 {
+		return false
+	}
+	// None of these operations should have touched their inputs
+	return unchanged(xBefore, &x) && unchanged(yBefore, &y) && unchanged(xBigBefore, xBig) && unchanged(yBigBefore, yBig)
+}
+
+func TestCappedResizeInvariant(t *testing.T) {
+	skipIfCheckingCaps(t)
+	err := quick.Check(testCappedResizeInvariant, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
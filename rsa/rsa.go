@@ -0,0 +1,105 @@
+// Package rsa provides the RSA private key primitives, built on saferith.
+//
+// This only implements the raw RSA operations, i.e. x^d mod n, and doesn't handle
+// any padding schemes. The private operations use the Chinese Remainder Theorem
+// for speed, and blind their input to avoid leaking the value being operated on.
+package rsa
+
+import (
+	"errors"
+	"io"
+
+	"github.com/cronokirby/saferith"
+)
+
+// PublicKey holds an RSA public key, with modulus N, and public exponent E.
+type PublicKey struct {
+	N *saferith.Modulus
+	E *saferith.Nat
+}
+
+// PrivateKey holds an RSA private key, along with its precomputed CRT values.
+type PrivateKey struct {
+	PublicKey
+	// D is the private exponent, satisfying E * D = 1 mod (P - 1)(Q - 1).
+	D *saferith.Nat
+	// Dp = D mod (P - 1).
+	Dp *saferith.Nat
+	// Dq = D mod (Q - 1).
+	Dq *saferith.Nat
+	// crt holds P, Q, and Q^-1 mod P.
+	crt *saferith.CRT
+}
+
+// NewPrivateKey creates a private key from its prime factors, and the public exponent.
+//
+// An error is returned if e isn't invertible modulo (p - 1)(q - 1), or if p and q aren't coprime.
+func NewPrivateKey(p *saferith.Nat, q *saferith.Nat, e *saferith.Nat) (*PrivateKey, error) {
+	pm, err := saferith.NewPrivateModulus(p, q)
+	if err != nil {
+		return nil, err
+	}
+	d, ok := new(saferith.Nat).ModInverseChecked(e, saferith.ModulusFromNat(pm.Phi()))
+	if ok != 1 {
+		return nil, errors.New("rsa: public exponent is not invertible")
+	}
+	one := new(saferith.Nat).SetUint64(1)
+	pMinus1 := new(saferith.Nat).Sub(p, one, pm.P().BitLen())
+	qMinus1 := new(saferith.Nat).Sub(q, one, pm.Q().BitLen())
+	return &PrivateKey{
+		PublicKey: PublicKey{N: pm.N(), E: new(saferith.Nat).SetNat(e)},
+		D:         d,
+		Dp:        new(saferith.Nat).Mod(d, saferith.ModulusFromNat(pMinus1)),
+		Dq:        new(saferith.Nat).Mod(d, saferith.ModulusFromNat(qMinus1)),
+		crt:       pm.CRT(),
+	}, nil
+}
+
+// Encrypt calculates m^e mod n, which is also the operation used to verify signatures.
+//
+// Since the public exponent is public, this will leak its value, but not that of m.
+func (k *PublicKey) Encrypt(m *saferith.Nat) *saferith.Nat {
+	return new(saferith.Nat).ExpPublic(m, k.E, k.N)
+}
+
+// Decrypt calculates c^d mod n, using randomness from rand to blind c.
+//
+// c is multiplied by r^e, for a random unit r, which then gets removed from the result.
+// This prevents side-channels from leaking information about the value of c.
+// The result is also checked by re-encrypting it, to protect against faults.
+//
+// An error is returned if c isn't reduced modulo n, or reading from rand fails.
+func (k *PrivateKey) Decrypt(rand io.Reader, c *saferith.Nat) (*saferith.Nat, error) {
+	if _, _, lt := c.CmpMod(k.N); lt != 1 {
+		return nil, errors.New("rsa: ciphertext is too large")
+	}
+	r := new(saferith.Nat)
+	var rInv *saferith.Nat
+	for {
+		if _, err := r.Rand(k.N, rand); err != nil {
+			return nil, err
+		}
+		var ok saferith.Choice
+		rInv, ok = new(saferith.Nat).ModInverseChecked(r, k.N)
+		// Failing this check would mean that we've found a factor of n, which
+		// isn't going to happen, but we handle it anyways.
+		if ok == 1 {
+			break
+		}
+	}
+	blinded := k.Encrypt(r)
+	blinded.ModMul(blinded, c, k.N)
+	m := k.crt.ExpCRT(blinded, k.Dp, k.Dq)
+	m.ModMul(m, rInv, k.N)
+	if k.Encrypt(m).Eq(new(saferith.Nat).Mod(c, k.N)) != 1 {
+		return nil, errors.New("rsa: internal error")
+	}
+	return m, nil
+}
+
+// Sign calculates m^d mod n, for a message representative m.
+//
+// This is the same operation as Decrypt, including the use of blinding.
+func (k *PrivateKey) Sign(rand io.Reader, m *saferith.Nat) (*saferith.Nat, error) {
+	return k.Decrypt(rand, m)
+}
@@ -0,0 +1,81 @@
+package rsa
+
+import (
+	"crypto/rand"
+	stdrsa "crypto/rsa"
+	"math/big"
+	"testing"
+
+	"github.com/cronokirby/saferith"
+)
+
+func testKey(t *testing.T) (*stdrsa.PrivateKey, *PrivateKey) {
+	std, err := stdrsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := new(saferith.Nat).SetBig(std.Primes[0], std.Primes[0].BitLen())
+	q := new(saferith.Nat).SetBig(std.Primes[1], std.Primes[1].BitLen())
+	e := new(saferith.Nat).SetUint64(uint64(std.E))
+	k, err := NewPrivateKey(p, q, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return std, k
+}
+
+func TestDecryptMatchesStandardLibrary(t *testing.T) {
+	std, k := testKey(t)
+	if k.N.Big().Cmp(std.N) != 0 {
+		t.Errorf("%+v != %+v", std.N, k.N)
+	}
+	for i := 0; i < 10; i++ {
+		cBig, err := rand.Int(rand.Reader, std.N)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := new(saferith.Nat).SetBig(cBig, std.N.BitLen())
+		m, err := k.Decrypt(rand.Reader, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := new(big.Int).Exp(cBig, std.D, std.N)
+		if m.Big().Cmp(expected) != 0 {
+			t.Errorf("%+v != %+v", expected, m)
+		}
+		if k.Encrypt(m).Eq(c) != 1 {
+			t.Errorf("encryption didn't round trip")
+		}
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	_, k := testKey(t)
+	m := new(saferith.Nat).SetUint64(0xDEADBEEF)
+	s, err := k.Sign(rand.Reader, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k.PublicKey.Encrypt(s).Eq(new(saferith.Nat).Mod(m, k.N)) != 1 {
+		t.Errorf("signature didn't verify")
+	}
+}
+
+func TestDecryptRejectsLargeCiphertext(t *testing.T) {
+	_, k := testKey(t)
+	if _, err := k.Decrypt(rand.Reader, k.N.Nat()); err == nil {
+		t.Errorf("expected error for unreduced ciphertext")
+	}
+}
+
+func TestNewPrivateKeyRejectsBadExponent(t *testing.T) {
+	p := new(saferith.Nat).SetUint64(11)
+	q := new(saferith.Nat).SetUint64(13)
+	// (p - 1)(q - 1) = 120, which is divisible by 3
+	if _, err := NewPrivateKey(p, q, new(saferith.Nat).SetUint64(3)); err == nil {
+		t.Errorf("expected error for non invertible exponent")
+	}
+	if _, err := NewPrivateKey(p, q, new(saferith.Nat).SetUint64(7)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,42 @@
+package saferith
+
+// Scratch holds temporary space, which can be reused across operations.
+//
+// Methods taking a Scratch, such as ExpWithScratch, store their temporary values
+// inside of it, instead of allocating them. Once a Scratch has grown large enough
+// for a given modulus, these methods no longer allocate at all, which avoids
+// putting pressure on the garbage collector in hot loops.
+//
+// The zero value is ready to use. A Scratch must not be used by multiple
+// goroutines at the same time.
+type Scratch struct {
+	// Reduced copies of the arguments
+	x     Nat
+	y     Nat
+	words []Word
+}
+
+// get returns a slice of n limbs, growing the underlying buffer if necessary.
+//
+// The contents of this slice are arbitrary, and the slice will be reused by
+// the next call to get. If zeroization is enabled, the old buffer is wiped
+// before being replaced by a larger one, since nothing else can clear it.
+func (s *Scratch) get(n int) []Word {
+	if cap(s.words) < n {
+		if zeroizing() {
+			clearLimbs(s.words)
+		}
+		s.words = make([]Word, n)
+	}
+	return s.words[:n]
+}
+
+// Clear overwrites all of the space held by s with zeros.
+//
+// The temporary values stored in a Scratch may be derived from secret
+// arguments, so this should be called once those values are no longer needed.
+func (s *Scratch) Clear() {
+	s.x.Clear()
+	s.y.Clear()
+	clearLimbs(s.words)
+}
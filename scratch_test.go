@@ -0,0 +1,124 @@
+package saferith
+
+import (
+	"math/big"
+	"testing"
+	"testing/quick"
+)
+
+// sharedScratch is reused across test cases, to check that leftover state doesn't matter
+var sharedScratch Scratch
+
+func testModMulWithScratchMatchesBig(x Nat, y Nat, m Modulus) bool {
+	actual := new(Nat).ModMulWithScratch(&x, &y, &m, &sharedScratch)
+	if !actual.checkInvariants() {
+		return false
+	}
+	expected := new(big.Int).Mul(x.Big(), y.Big())
+	expected.Mod(expected, m.Big())
+	return actual.Big().Cmp(expected) == 0
+}
+
+func TestModMulWithScratchMatchesBig(t *testing.T) {
+	err := quick.Check(testModMulWithScratchMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testModSqrWithScratchMatchesBig(x Nat, m Modulus) bool {
+	actual := new(Nat).ModSqrWithScratch(&x, &m, &sharedScratch)
+	if !actual.checkInvariants() {
+		return false
+	}
+	expected := new(big.Int).Mul(x.Big(), x.Big())
+	expected.Mod(expected, m.Big())
+	return actual.Big().Cmp(expected) == 0
+}
+
+func TestModSqrWithScratchMatchesBig(t *testing.T) {
+	err := quick.Check(testModSqrWithScratchMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testExpWithScratchMatchesBig(x Nat, y Nat, m Modulus) bool {
+	actual := new(Nat).ExpWithScratch(&x, &y, &m, &sharedScratch)
+	if !actual.checkInvariants() {
+		return false
+	}
+	expected := new(big.Int).Exp(x.Big(), y.Big(), m.Big())
+	return actual.Big().Cmp(expected) == 0
+}
+
+func TestExpWithScratchMatchesBig(t *testing.T) {
+	err := quick.Check(testExpWithScratchMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExpWithScratchAliasing(t *testing.T) {
+	var s Scratch
+	m := ModulusFromUint64(13)
+	x := new(Nat).SetUint64(5)
+	x.ExpWithScratch(x, x, m, &s)
+	expected := new(Nat).SetUint64(5 * 5 * 5 * 5 * 5 % 13)
+	if x.Eq(expected) != 1 {
+		t.Errorf("%+v != %+v", expected, x)
+	}
+}
+
+func TestWithScratchDoesNotAllocate(t *testing.T) {
+	for _, bytes := range [][]byte{modulus2048(), modulus2048Even()} {
+		m := ModulusFromBytes(bytes)
+		x := new(Nat).SetBytes(bytes)
+		x.limbs[0] ^= 1
+		y := new(Nat).SetUint64(0xAAAA_5555)
+		z := new(Nat)
+		var s Scratch
+		// The first calls make sure that z and s are large enough
+		z.ModMulWithScratch(x, x, m, &s)
+		z.ExpWithScratch(x, y, m, &s)
+		allocs := testing.AllocsPerRun(10, func() {
+			z.ModMulWithScratch(x, z, m, &s)
+			z.ModSqrWithScratch(z, m, &s)
+			z.ExpWithScratch(z, y, m, &s)
+		})
+		if allocs != 0 {
+			t.Errorf("%+v != %+v", 0, allocs)
+		}
+	}
+}
+
+func TestScratchClear(t *testing.T) {
+	var s Scratch
+	m := ModulusFromBytes(modulus2048())
+	x := new(Nat).SetBytes(ones())
+	new(Nat).ExpWithScratch(x, x, m, &s)
+	s.Clear()
+	for _, backing := range [][]Word{s.x.limbs, s.y.limbs, s.words} {
+		for _, limb := range backing[:cap(backing)] {
+			if limb != 0 {
+				t.Errorf("limb %+v was not cleared", limb)
+			}
+		}
+	}
+}
+
+func TestScratchGrowthIsWiped(t *testing.T) {
+	SetZeroization(true)
+	defer SetZeroization(false)
+	var s Scratch
+	small := s.get(4)
+	for i := range small {
+		small[i] = ^Word(0)
+	}
+	s.get(64)
+	for _, limb := range small {
+		if limb != 0 {
+			t.Errorf("limb %+v was not cleared", limb)
+		}
+	}
+}
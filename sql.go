@@ -0,0 +1,79 @@
+package saferith
+
+import (
+	"database/sql/driver"
+	"errors"
+)
+
+// This file implements database/sql support, through driver.Valuer and sql.Scanner.
+//
+// Numbers are stored as byte slices, using the format of MarshalBinary, which
+// keeps the announced length of a number, and fits binary columns, like Postgres'
+// bytea. When scanning, hex strings are also accepted, as produced by Hex, which
+// makes it possible to use text columns, or literals written by hand.
+
+// scanSource extracts the bytes, or string, a database gave us.
+func scanSource(src interface{}) ([]byte, string, error) {
+	switch v := src.(type) {
+	case []byte:
+		return v, "", nil
+	case string:
+		return nil, v, nil
+	case nil:
+		return nil, "", errors.New("cannot scan NULL into a number")
+	default:
+		return nil, "", errors.New("cannot scan this type into a number")
+	}
+}
+
+// Value implements driver.Valuer, producing the output of MarshalBinary.
+//
+// A nil Nat is stored as NULL.
+func (i *Nat) Value() (driver.Value, error) {
+	if i == nil {
+		return nil, nil
+	}
+	return i.MarshalBinary()
+}
+
+// Scan implements sql.Scanner.
+//
+// This accepts byte slices in the format of MarshalBinary, as well as hex
+// strings, in the format accepted by SetHex. NULL values return an error.
+func (i *Nat) Scan(src interface{}) error {
+	data, hex, err := scanSource(src)
+	if err != nil {
+		return err
+	}
+	if data != nil {
+		return i.UnmarshalBinary(data)
+	}
+	_, err = i.SetHex(hex)
+	return err
+}
+
+// Value implements driver.Valuer, producing the output of MarshalBinary.
+//
+// A nil Int is stored as NULL.
+func (i *Int) Value() (driver.Value, error) {
+	if i == nil {
+		return nil, nil
+	}
+	return i.MarshalBinary()
+}
+
+// Scan implements sql.Scanner.
+//
+// This accepts byte slices in the format of MarshalBinary, as well as signed hex
+// strings, in the format accepted by SetHex. NULL values return an error.
+func (i *Int) Scan(src interface{}) error {
+	data, hex, err := scanSource(src)
+	if err != nil {
+		return err
+	}
+	if data != nil {
+		return i.UnmarshalBinary(data)
+	}
+	_, err = i.SetHex(hex)
+	return err
+}
@@ -0,0 +1,75 @@
+package saferith
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"testing/quick"
+)
+
+var _ driver.Valuer = (*Nat)(nil)
+var _ sql.Scanner = (*Nat)(nil)
+var _ driver.Valuer = (*Int)(nil)
+var _ sql.Scanner = (*Int)(nil)
+
+func testNatSQLRoundTrip(x Nat) bool {
+	value, err := x.Value()
+	if err != nil || !driver.IsValue(value) {
+		return false
+	}
+	y := new(Nat)
+	if err := y.Scan(value); err != nil {
+		return false
+	}
+	if y.Eq(&x) != 1 || y.AnnouncedLen() != x.AnnouncedLen() || !y.checkInvariants() {
+		return false
+	}
+	// Hex strings, as stored in text columns, should also work
+	z := new(Nat)
+	return z.Scan(x.Hex()) == nil && z.Eq(&x) == 1
+}
+
+func TestNatSQLRoundTrip(t *testing.T) {
+	err := quick.Check(testNatSQLRoundTrip, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testIntSQLRoundTrip(x *Int) bool {
+	value, err := x.Value()
+	if err != nil || !driver.IsValue(value) {
+		return false
+	}
+	y := new(Int)
+	if err := y.Scan(value); err != nil {
+		return false
+	}
+	return y.Eq(x) == 1 && y.AnnouncedLen() == x.AnnouncedLen()
+}
+
+func TestIntSQLRoundTrip(t *testing.T) {
+	err := quick.Check(testIntSQLRoundTrip, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSQLExamples(t *testing.T) {
+	x := new(Int)
+	if err := x.Scan("-2A"); err != nil || x.EqInt64(-42) != 1 {
+		t.Errorf("%+v != %+v (%v)", -42, x, err)
+	}
+	var nat *Nat
+	if value, err := nat.Value(); value != nil || err != nil {
+		t.Errorf("nil Nat should be stored as NULL, got %+v (%v)", value, err)
+	}
+	for _, src := range []interface{}{nil, 42, []byte{0xFF}, "XYZ"} {
+		if err := new(Nat).Scan(src); err == nil {
+			t.Errorf("expected an error scanning %+v", src)
+		}
+		if err := new(Int).Scan(src); err == nil {
+			t.Errorf("expected an error scanning %+v", src)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package saferith
+
+// Table holds a fixed list of numbers, allowing entries to be selected in constant time.
+//
+// This is useful for window based exponentiation, or scalar multiplication, where
+// the entry to use depends on secret bits. The number of entries, and their announced
+// length, are public, but the index used for a lookup isn't leaked.
+type Table struct {
+	announced int
+	// If every entry is reduced modulo the same modulus, then this is that modulus
+	reduced *Modulus
+	entries [][]Word
+}
+
+// NewTable creates a new Table, holding copies of the given entries.
+//
+// Every entry is resized to the largest announced length among them.
+func NewTable(entries ...*Nat) *Table {
+	t := new(Table)
+	for _, e := range entries {
+		if e.announced > t.announced {
+			t.announced = e.announced
+		}
+	}
+	if len(entries) > 0 {
+		t.reduced = entries[0].reduced
+	}
+	t.entries = make([][]Word, len(entries))
+	for i, e := range entries {
+		if e.reduced != t.reduced {
+			t.reduced = nil
+		}
+		t.entries[i] = make([]Word, limbCount(t.announced))
+		copy(t.entries[i], e.limbs)
+	}
+	return t
+}
+
+// Len returns the number of entries in this table.
+func (t *Table) Len() int {
+	return len(t.entries)
+}
+
+// Lookup sets z <- t[i], returning z.
+//
+// Every entry of the table is scanned, so that the value of i isn't leaked, only
+// the size of the table. If i >= t.Len(), then z is set to 0.
+//
+// The capacity of the result matches the announced length of the entries.
+func (z *Nat) Lookup(t *Table, i Word) *Nat {
+	z.limbs = z.resizedLimbs(t.announced)
+	for j := 0; j < len(z.limbs); j++ {
+		z.limbs[j] = 0
+	}
+	for k, entry := range t.entries {
+		ctCondCopy(ctEq(i, Word(k)), z.limbs, entry)
+	}
+	z.announced = t.announced
+	// Since 0 is reduced modulo anything, this holds even if i is out of range
+	z.reduced = t.reduced
+	return z
+}
@@ -0,0 +1,67 @@
+package saferith
+
+import (
+	"testing"
+	"testing/quick"
+)
+
+func testTableLookup(a Nat, b Nat, c Nat, i uint8) bool {
+	entries := []*Nat{&a, &b, &c}
+	table := NewTable(entries...)
+	// Include one index past the end of the table
+	index := Word(i % 4)
+	actual := new(Nat).Lookup(table, index)
+	announced := a.maxAnnounced(&b)
+	if c.AnnouncedLen() > announced {
+		announced = c.AnnouncedLen()
+	}
+	if !actual.checkInvariants() || actual.AnnouncedLen() != announced {
+		return false
+	}
+	if int(index) >= table.Len() {
+		return actual.EqZero() == 1
+	}
+	return actual.Eq(entries[index]) == 1
+}
+
+func TestTableLookup(t *testing.T) {
+	err := quick.Check(testTableLookup, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTableLookupKeepsReduction(t *testing.T) {
+	m := ModulusFromUint64(13)
+	x := new(Nat).SetUint64(5)
+	entries := make([]*Nat, 8)
+	for i := range entries {
+		entries[i] = new(Nat).Exp(x, new(Nat).SetUint64(uint64(i)), m)
+	}
+	table := NewTable(entries...)
+	for i := range entries {
+		actual := new(Nat).Lookup(table, Word(i))
+		if actual.reduced != m || actual.Eq(entries[i]) != 1 {
+			t.Errorf("%+v != %+v", entries[i], actual)
+		}
+	}
+	// Mixing in an unreduced entry means that lookups can't be considered reduced
+	table = NewTable(append(entries, new(Nat).SetUint64(2))...)
+	if new(Nat).Lookup(table, 0).reduced != nil {
+		t.Errorf("lookup should not be reduced")
+	}
+}
+
+func TestTableLookupIsolatesEntries(t *testing.T) {
+	x := new(Nat).SetUint64(42)
+	table := NewTable(x)
+	x.SetUint64(7)
+	actual := new(Nat).Lookup(table, 0)
+	if actual.Eq(new(Nat).SetUint64(42)) != 1 {
+		t.Errorf("%+v != 42", actual)
+	}
+	actual.SetUint64(9)
+	if new(Nat).Lookup(table, 0).Eq(new(Nat).SetUint64(42)) != 1 {
+		t.Errorf("table entry was modified")
+	}
+}
@@ -0,0 +1,83 @@
+package saferith
+
+// primeMinusOnes returns p - 1, and q - 1, with the same capacities as p and q.
+func primeMinusOnes(p *Nat, q *Nat) (*Nat, *Nat) {
+	one := new(Nat).SetUint64(1)
+	return new(Nat).Sub(p, one, p.announced), new(Nat).Sub(q, one, q.announced)
+}
+
+// Totient calculates Euler's totient function of n = p * q, for distinct primes p and q
+//
+// This is phi(n) = (p - 1)(q - 1), the order of the group of units modulo n.
+// The primality of p and q isn't checked, and only their announced lengths are leaked.
+//
+// The capacity of the result is the sum of the announced lengths of p and q.
+func Totient(p *Nat, q *Nat) *Nat {
+	pMinus1, qMinus1 := primeMinusOnes(p, q)
+	return new(Nat).Mul(pMinus1, qMinus1, p.announced+q.announced)
+}
+
+// Carmichael calculates Carmichael's function of n = p * q, for distinct primes p and q
+//
+// This is lambda(n) = lcm(p - 1, q - 1), the smallest exponent such that x^lambda(n) = 1
+// for every unit x modulo n. This divides the totient, and can be used in its place
+// for RSA private exponents, or Paillier decryption. The primality of p and q isn't
+// checked, and only their announced lengths are leaked.
+//
+// The capacity of the result is the sum of the announced lengths of p and q.
+func Carmichael(p *Nat, q *Nat) *Nat {
+	pMinus1, qMinus1 := primeMinusOnes(p, q)
+	return new(Nat).LCM(pMinus1, qMinus1, p.announced+q.announced)
+}
+
+// PrivateModulus holds a modulus n = p * q, along with the secret values derived from its factors
+//
+// This bundles together the CRT structure for n, with its totient and Carmichael
+// function, which are what private key operations in RSA and Paillier need.
+type PrivateModulus struct {
+	crt    *CRT
+	phi    *Nat
+	lambda *Nat
+}
+
+// NewPrivateModulus creates a PrivateModulus from two distinct primes p and q
+//
+// An error is returned if p and q aren't coprime, which also rejects p = q. Like
+// NewCRT, the result of this check is leaked, and the primality of p and q isn't checked.
+func NewPrivateModulus(p *Nat, q *Nat) (*PrivateModulus, error) {
+	crt, err := NewCRT(ModulusFromNat(p), ModulusFromNat(q))
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateModulus{crt: crt, phi: Totient(p, q), lambda: Carmichael(p, q)}, nil
+}
+
+// N returns the modulus n = p * q.
+func (m *PrivateModulus) N() *Modulus {
+	return m.crt.N()
+}
+
+// P returns the first prime factor.
+func (m *PrivateModulus) P() *Modulus {
+	return m.crt.P()
+}
+
+// Q returns the second prime factor.
+func (m *PrivateModulus) Q() *Modulus {
+	return m.crt.Q()
+}
+
+// CRT returns the structure used to split operations modulo n into operations modulo p and q.
+func (m *PrivateModulus) CRT() *CRT {
+	return m.crt
+}
+
+// Phi returns a copy of Euler's totient function of n, (p - 1)(q - 1).
+func (m *PrivateModulus) Phi() *Nat {
+	return new(Nat).SetNat(m.phi)
+}
+
+// Lambda returns a copy of Carmichael's function of n, lcm(p - 1, q - 1).
+func (m *PrivateModulus) Lambda() *Nat {
+	return new(Nat).SetNat(m.lambda)
+}
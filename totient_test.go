@@ -0,0 +1,76 @@
+package saferith
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestTotientAndCarmichaelMatchBig(t *testing.T) {
+	p, _ := new(Nat).SetHex(crtPHex)
+	q, _ := new(Nat).SetHex(crtQHex)
+	pm, err := NewPrivateModulus(p, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	one := big.NewInt(1)
+	pMinus1 := new(big.Int).Sub(p.Big(), one)
+	qMinus1 := new(big.Int).Sub(q.Big(), one)
+	expectedPhi := new(big.Int).Mul(pMinus1, qMinus1)
+	gcd := new(big.Int).GCD(nil, nil, pMinus1, qMinus1)
+	expectedLambda := new(big.Int).Div(expectedPhi, gcd)
+
+	for _, phi := range []*Nat{Totient(p, q), pm.Phi()} {
+		if phi.AnnouncedLen() != 256 || phi.Big().Cmp(expectedPhi) != 0 {
+			t.Errorf("%+v != %+v", expectedPhi, phi)
+		}
+	}
+	for _, lambda := range []*Nat{Carmichael(p, q), pm.Lambda()} {
+		if lambda.AnnouncedLen() != 256 || lambda.Big().Cmp(expectedLambda) != 0 {
+			t.Errorf("%+v != %+v", expectedLambda, lambda)
+		}
+	}
+	expectedN := new(big.Int).Mul(p.Big(), q.Big())
+	if pm.N().Big().Cmp(expectedN) != 0 {
+		t.Errorf("%+v != %+v", expectedN, pm.N())
+	}
+}
+
+func TestCarmichaelExamples(t *testing.T) {
+	// lcm(2, 6) = 6, lcm(10, 12) = 60
+	for _, c := range [][3]uint64{{3, 7, 6}, {11, 13, 60}} {
+		expected := new(Nat).SetUint64(c[2])
+		actual := Carmichael(new(Nat).SetUint64(c[0]), new(Nat).SetUint64(c[1]))
+		if expected.Eq(actual) != 1 {
+			t.Errorf("%+v != %+v", expected, actual)
+		}
+	}
+}
+
+func TestPrivateModulusLambdaIsExponent(t *testing.T) {
+	pm, err := NewPrivateModulus(new(Nat).SetUint64(1019), new(Nat).SetUint64(1031))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lambda := pm.Lambda()
+	for x := uint64(2); x < 200; x++ {
+		xNat := new(Nat).SetUint64(x)
+		if xNat.IsUnit(pm.N()) != 1 {
+			continue
+		}
+		if actual := new(Nat).Exp(xNat, lambda, pm.N()); actual.EqUint64(1) != 1 {
+			t.Errorf("%d^lambda = %+v", x, actual)
+		}
+	}
+	// Modifying the returned values shouldn't change the modulus
+	lambda.SetUint64(0)
+	if pm.Lambda().EqZero() == 1 {
+		t.Errorf("lambda was modified")
+	}
+}
+
+func TestNewPrivateModulusRejectsEqualFactors(t *testing.T) {
+	p := new(Nat).SetUint64(1019)
+	if _, err := NewPrivateModulus(p, p); err == nil {
+		t.Errorf("expected an error for equal factors")
+	}
+}
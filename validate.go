@@ -0,0 +1,95 @@
+package saferith
+
+import "io"
+
+// HasBitLenBetween returns true if this modulus has between min and max bits, inclusive.
+//
+// Unlike BitLen, this checks the true length of the modulus, rather than its
+// announced length, which is what matters when vetting moduli from other parties.
+func (m *Modulus) HasBitLenBetween(min, max int) bool {
+	// LEAK: the true length of m
+	// OK: moduli are allowed to leak this value
+	trueLen := m.nat.TrueLen()
+	return min <= trueLen && trueLen <= max
+}
+
+// HasSmallFactors returns 1 if some prime below bound divides this modulus, other than itself
+//
+// This is useful to reject peer-supplied RSA or Paillier moduli, which should only
+// have large factors. Finding the primes below bound takes time and memory linear
+// in bound, so it shouldn't be too large, and ideally public. Which primes divide the
+// modulus isn't leaked.
+func (m *Modulus) HasSmallFactors(bound int) Choice {
+	if bound <= 2 {
+		return 0
+	}
+	groups := smallPrimeGroups
+	// The common case of checking against the default bound doesn't need to sieve
+	if bound != trialDivisionLimit {
+		groups = makeSmallPrimeGroups(bound)
+	}
+	return 1 ^ m.nat.trialDivide(groups)
+}
+
+// IsProbablePrime checks whether or not this modulus is prime
+//
+// This uses ProbablyPrime, with the given number of rounds, and leaks the same
+// information, which includes the result. Like ProbablyPrime, this panics
+// if rounds < 1.
+func (m *Modulus) IsProbablePrime(rounds int, rand io.Reader) bool {
+	checkRounds(rounds)
+	return m.nat.ProbablyPrime(rounds, rand)
+}
+
+// IsSafePrime checks whether or not this modulus is a safe prime p = 2q + 1, with q prime
+//
+// Both p and q are checked with ProbablyPrime, using the given number of rounds.
+// Like IsProbablePrime, the result is leaked, and this panics if rounds < 1.
+func (m *Modulus) IsSafePrime(rounds int, rand io.Reader) bool {
+	checkRounds(rounds)
+	p := &m.nat
+	// LEAK: whether p is odd
+	// OK: this is determined by the result, since 2 isn't a safe prime
+	if m.IsOdd() != 1 {
+		return false
+	}
+	q := new(Nat).Rsh(p, 1, -1)
+	// Checking p with a single round first rejects most candidates more cheaply
+	return p.ProbablyPrime(1, rand) && q.ProbablyPrime(rounds, rand) && p.ProbablyPrime(rounds, rand)
+}
+
+// IsBlumInteger checks whether p * q is a Blum integer
+//
+// This means that p and q are distinct primes, both equal to 3 mod 4. Primality
+// is checked with ProbablyPrime, using the given number of rounds, and the result
+// is leaked. Like ProbablyPrime, this panics if rounds < 1. This is meant to vet
+// the factors of moduli used in protocols relying on this structure, like some
+// proofs of knowledge of a factorization.
+func IsBlumInteger(p *Nat, q *Nat, rounds int, rand io.Reader) bool {
+	checkRounds(rounds)
+	// LEAK: whether p and q are distinct, and 3 mod 4
+	// OK: this is determined by the result
+	if p.Eq(q) == 1 || p.limbOrZero(0)&3 != 3 || q.limbOrZero(0)&3 != 3 {
+		return false
+	}
+	return p.ProbablyPrime(rounds, rand) && q.ProbablyPrime(rounds, rand)
+}
+
+// IsQuadraticResidue returns 1 if x is a square modulo the product of some primes, and 0 otherwise
+//
+// The factors must be distinct odd primes, but this isn't checked. For a modulus
+// n = p * q, as in Goldwasser-Micali encryption, x is a square modulo n exactly when
+// it's a square modulo both p and q, so passing the factors of n checks residuosity
+// modulo n, which can't be done without them. A single prime p can also be passed on
+// its own. 0 is considered to be a square.
+//
+// Like Jacobi, this doesn't leak anything beyond the announced size of x, and the
+// sizes of the factors. This will panic if some factor is even.
+func (x *Nat) IsQuadraticResidue(factors ...*Modulus) Choice {
+	square := Choice(1)
+	for _, p := range factors {
+		// The Jacobi symbol is -1 exactly for non squares
+		square &= 1 ^ ctEq(Word(x.Jacobi(p)+1), 0)
+	}
+	return square
+}
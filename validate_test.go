@@ -0,0 +1,154 @@
+package saferith
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestModulusHasBitLenBetween(t *testing.T) {
+	m := ModulusFromBytes([]byte{0x00, 0x00, 0x80, 0x01})
+	if !m.HasBitLenBetween(16, 16) || m.HasBitLenBetween(17, 32) || m.HasBitLenBetween(1, 15) {
+		t.Errorf("%+v should have exactly 16 bits", m)
+	}
+}
+
+func TestModulusHasSmallFactorsMatchesBig(t *testing.T) {
+	for _, bound := range []int{0, 2, 3, 100, trialDivisionLimit, 5000} {
+		for i := uint64(1); i < 1000; i++ {
+			expected := Choice(0)
+			for p := int64(2); p < int64(bound); p++ {
+				if uint64(p) != i && i%uint64(p) == 0 && big.NewInt(p).ProbablyPrime(20) {
+					expected = 1
+					break
+				}
+			}
+			actual := ModulusFromUint64(i).HasSmallFactors(bound)
+			if expected != actual {
+				t.Errorf("%d, %d: %+v != %+v", bound, i, expected, actual)
+			}
+		}
+	}
+}
+
+func TestModulusHasSmallFactorsExamples(t *testing.T) {
+	p, _ := new(Nat).SetHex("FFFFFFFF00000001000000000000000000000000FFFFFFFFFFFFFFFFFFFFFFFF")
+	if ModulusFromNat(p).HasSmallFactors(1<<16) != 0 {
+		t.Errorf("%+v has no small factors", p)
+	}
+	// 65521 is the largest prime below 2^16
+	n := new(Nat).Mul(p, new(Nat).SetUint64(65521), -1)
+	if ModulusFromNat(n).HasSmallFactors(1<<16) != 1 {
+		t.Errorf("%+v has a small factor", n)
+	}
+	if ModulusFromNat(n).HasSmallFactors(65521) != 0 {
+		t.Errorf("%+v has no factors below 65521", n)
+	}
+}
+
+func TestModulusIsSafePrime(t *testing.T) {
+	for i := uint64(1); i < 2000; i++ {
+		m := ModulusFromUint64(i)
+		expected := big.NewInt(int64(i)).ProbablyPrime(20) && big.NewInt(int64(i/2)).ProbablyPrime(20) && i%2 == 1
+		if actual := m.IsSafePrime(20, rand.Reader); expected != actual {
+			t.Errorf("%d: %+v != %+v", i, expected, actual)
+		}
+		if m.IsProbablePrime(20, rand.Reader) != big.NewInt(int64(i)).ProbablyPrime(20) {
+			t.Errorf("%d: primality mismatch", i)
+		}
+	}
+	// The 768 bit Oakley group from RFC 2409 uses a safe prime
+	p, _ := ModulusFromHex("FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74020BBEA63B139B22514A08798E3404DDEF9519B3CD3A431B302B0A6DF25F14374FE1356D6D51C245E485B576625E7EC6F44C42E9A63A3620FFFFFFFFFFFFFFFF")
+	if !p.IsSafePrime(20, rand.Reader) {
+		t.Errorf("%+v should be a safe prime", p)
+	}
+	// P-256's prime isn't safe
+	p, _ = ModulusFromHex("FFFFFFFF00000001000000000000000000000000FFFFFFFFFFFFFFFFFFFFFFFF")
+	if p.IsSafePrime(20, rand.Reader) || !p.IsProbablePrime(20, rand.Reader) {
+		t.Errorf("%+v should be prime, but not a safe prime", p)
+	}
+}
+
+func TestModulusIsProbablePrimeRequiresRounds(t *testing.T) {
+	p, _ := ModulusFromHex("FFFFFFFF00000001000000000000000000000000FFFFFFFFFFFFFFFFFFFFFFFF")
+	for _, rounds := range []int{0, -1} {
+		expectPanic(t, "IsProbablePrime", func() { p.IsProbablePrime(rounds, rand.Reader) })
+		expectPanic(t, "IsSafePrime", func() { p.IsSafePrime(rounds, rand.Reader) })
+		// Even moduli are rejected without testing primality, but rounds are still checked
+		expectPanic(t, "IsSafePrime", func() { ModulusFromUint64(8).IsSafePrime(rounds, rand.Reader) })
+	}
+}
+
+func TestIsBlumIntegerExamples(t *testing.T) {
+	cases := []struct {
+		p, q     uint64
+		expected bool
+	}{
+		{3, 7, true},
+		{7, 3, true},
+		{7, 7, false},
+		{5, 7, false},
+		{7, 15, false},
+		{1019, 1031, true},
+	}
+	for _, c := range cases {
+		actual := IsBlumInteger(new(Nat).SetUint64(c.p), new(Nat).SetUint64(c.q), 20, rand.Reader)
+		if c.expected != actual {
+			t.Errorf("%d, %d: %+v != %+v", c.p, c.q, c.expected, actual)
+		}
+	}
+	// 2^127 - 1 = 3 mod 4, unlike the order of secp256k1, which is 1 mod 4
+	p, _ := new(Nat).SetHex("7FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF")
+	q, _ := new(Nat).SetHex("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141")
+	if !IsBlumInteger(p, new(Nat).SetUint64(1031), 20, rand.Reader) || IsBlumInteger(p, q, 20, rand.Reader) {
+		t.Errorf("only %+v * 1031 should be a Blum integer", p)
+	}
+}
+
+func TestIsBlumIntegerRequiresRounds(t *testing.T) {
+	p := new(Nat).SetUint64(1019)
+	q := new(Nat).SetUint64(1031)
+	for _, rounds := range []int{0, -1} {
+		expectPanic(t, "IsBlumInteger", func() { IsBlumInteger(p, q, rounds, rand.Reader) })
+		expectPanic(t, "IsBlumInteger", func() { IsBlumInteger(p, p, rounds, rand.Reader) })
+	}
+}
+
+func TestIsQuadraticResidueMatchesBig(t *testing.T) {
+	for _, p := range []uint64{3, 5, 7, 13, 1031} {
+		m := ModulusFromUint64(p)
+		for i := uint64(0); i < 2*p; i++ {
+			expected := Choice(0)
+			if big.Jacobi(new(big.Int).SetUint64(i), new(big.Int).SetUint64(p)) >= 0 {
+				expected = 1
+			}
+			actual := new(Nat).SetUint64(i).IsQuadraticResidue(m)
+			if expected != actual {
+				t.Errorf("%d mod %d: %+v != %+v", i, p, expected, actual)
+			}
+		}
+	}
+}
+
+func TestIsQuadraticResidueWithFactors(t *testing.T) {
+	p := ModulusFromUint64(7)
+	q := ModulusFromUint64(11)
+	squares := make(map[uint64]bool)
+	for i := uint64(0); i < 77; i++ {
+		squares[i*i%77] = true
+	}
+	for i := uint64(0); i < 2*77; i++ {
+		expected := Choice(0)
+		if squares[i%77] {
+			expected = 1
+		}
+		actual := new(Nat).SetUint64(i).IsQuadraticResidue(p, q)
+		if expected != actual {
+			t.Errorf("%d mod 77: %+v != %+v", i, expected, actual)
+		}
+	}
+	// The Jacobi symbol modulo 77 is 1 for 6, but it's not a square modulo 7, nor 11
+	if new(Nat).SetUint64(6).IsQuadraticResidue(p, q) != 0 {
+		t.Errorf("6 shouldn't be a square modulo 77")
+	}
+}
@@ -0,0 +1,77 @@
+package saferith
+
+// Words returns a copy of the limbs of this number, least significant first
+//
+// The result always has the number of Words needed to hold the announced length
+// of z, regardless of its value. Modifying the result doesn't affect z.
+//
+// See the safenum_unsafe build tag for a variant which doesn't copy.
+func (z *Nat) Words() []Word {
+	out := make([]Word, len(z.limbs))
+	copy(out, z.limbs)
+	return out
+}
+
+// SetWords sets z to the number with the given limbs, least significant first
+//
+// The limbs are copied, so modifying w afterwards doesn't affect z.
+//
+// cap determines the number of bits to keep in the result, with any bits of w
+// past that being discarded. If cap < 0, then every bit of w is kept.
+// In particular, SetWords(z.Words(), z.AnnouncedLen()) creates a copy of z.
+func (z *Nat) SetWords(w []Word, cap int) *Nat {
+	if cap < 0 {
+		cap = len(w) * _W
+	}
+	z.reduced = nil
+	z.announced = cap
+	z.limbs = z.resizedLimbs(cap)
+	n := copy(z.limbs, w)
+	for i := n; i < len(z.limbs); i++ {
+		z.limbs[i] = 0
+	}
+	maskEnd(z.limbs, cap)
+	return z
+}
+
+// ExportLimbsLE returns the value of z as 64 bit limbs, least significant first
+//
+// This matches the layout of mp_limb_t arrays in GMP, and BN_ULONG arrays in
+// OpenSSL, on 64 bit platforms, so the result can be handed to C code as is,
+// with each limb in native byte order. On platforms where these libraries use
+// 32 bit limbs, Words provides the matching layout instead.
+//
+// The result has enough limbs to hold the announced length of z.
+func (z *Nat) ExportLimbsLE() []uint64 {
+	out := make([]uint64, (z.announced+63)/64)
+	for i, w := range z.limbs {
+		bit := i * _W
+		out[bit/64] |= uint64(w) << uint(bit%64)
+	}
+	return out
+}
+
+// ImportLimbsLE sets z to the number with the given 64 bit limbs, least significant first
+//
+// This is the inverse of ExportLimbsLE, and accepts numbers in the format used by
+// GMP and OpenSSL on 64 bit platforms. The limbs are copied.
+//
+// cap determines the number of bits to keep in the result. If cap < 0, then
+// every bit of the limbs is kept.
+func (z *Nat) ImportLimbsLE(limbs []uint64, cap int) *Nat {
+	if cap < 0 {
+		cap = 64 * len(limbs)
+	}
+	z.reduced = nil
+	z.announced = cap
+	z.limbs = z.resizedLimbs(cap)
+	for i := range z.limbs {
+		bit := i * _W
+		z.limbs[i] = 0
+		if bit/64 < len(limbs) {
+			z.limbs[i] = Word(limbs[bit/64] >> uint(bit%64))
+		}
+	}
+	maskEnd(z.limbs, cap)
+	return z
+}
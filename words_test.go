@@ -0,0 +1,95 @@
+package saferith
+
+import (
+	"testing"
+	"testing/quick"
+)
+
+func testWordsRoundTrip(x Nat) bool {
+	words := x.Words()
+	if len(words) != limbCount(x.AnnouncedLen()) {
+		return false
+	}
+	y := new(Nat).SetWords(words, x.AnnouncedLen())
+	if !y.checkInvariants() || y.AnnouncedLen() != x.AnnouncedLen() || y.Eq(&x) != 1 {
+		return false
+	}
+	// Modifying the words shouldn't modify either number
+	for i := range words {
+		words[i] ^= 1
+	}
+	return y.Eq(&x) == 1 && (len(words) == 0 || new(Nat).SetWords(words, -1).Eq(&x) != 1)
+}
+
+func TestWordsRoundTrip(t *testing.T) {
+	err := quick.Check(testWordsRoundTrip, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSetWordsExamples(t *testing.T) {
+	words := []Word{0xFF, 0x1}
+	// Only the low 4 bits are kept
+	actual := new(Nat).SetWords(words, 4)
+	expected := new(Nat).SetUint64(0xF)
+	if actual.AnnouncedLen() != 4 || expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	// Every bit is kept, with the second Word being the most significant
+	actual = new(Nat).SetWords(words, -1)
+	expected = new(Nat).Lsh(new(Nat).SetUint64(1), _W, -1)
+	expected.Add(expected, new(Nat).SetUint64(0xFF), -1)
+	if actual.AnnouncedLen() != 2*_W || expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	// Extra capacity is filled with zeros
+	actual = new(Nat).SetUint64(123).Resize(256)
+	actual.SetWords(words[:1], 256)
+	expected.SetUint64(0xFF)
+	if actual.AnnouncedLen() != 256 || expected.Eq(actual) != 1 || !actual.checkInvariants() {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+}
+
+func testLimbsLERoundTrip(x Nat) bool {
+	limbs := x.ExportLimbsLE()
+	if len(limbs) != (x.AnnouncedLen()+63)/64 {
+		return false
+	}
+	// The limbs should match the little endian bytes of x
+	bytes := x.FillBytesLE(make([]byte, 8*len(limbs)))
+	for i, limb := range limbs {
+		if new(Nat).SetBytesLE(bytes[8*i:8*i+8]).EqUint64(limb) != 1 {
+			return false
+		}
+	}
+	y := new(Nat).ImportLimbsLE(limbs, x.AnnouncedLen())
+	return y.checkInvariants() && y.AnnouncedLen() == x.AnnouncedLen() && y.Eq(&x) == 1
+}
+
+func TestLimbsLERoundTrip(t *testing.T) {
+	err := quick.Check(testLimbsLERoundTrip, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestImportLimbsLEExamples(t *testing.T) {
+	limbs := []uint64{0x0123456789ABCDEF, 0xFF}
+	actual := new(Nat).ImportLimbsLE(limbs, -1)
+	expected, _ := new(Nat).SetHex("00000000000000FF0123456789ABCDEF")
+	if actual.AnnouncedLen() != 128 || expected.Eq(actual) != 1 {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	// Truncating in the middle of the first limb
+	actual.ImportLimbsLE(limbs, 36)
+	expected.SetUint64(0x789ABCDEF)
+	expected.Resize(36)
+	if actual.AnnouncedLen() != 36 || expected.Eq(actual) != 1 || !actual.checkInvariants() {
+		t.Errorf("%+v != %+v", expected, actual)
+	}
+	if exported := actual.ExportLimbsLE(); len(exported) != 1 || exported[0] != 0x789ABCDEF {
+		t.Errorf("%x != %x", []uint64{0x789ABCDEF}, exported)
+	}
+}
@@ -0,0 +1,34 @@
+//go:build safenum_unsafe
+// +build safenum_unsafe
+
+package saferith
+
+// The safenum_unsafe build tag exposes the limbs of a Nat without copying them.
+//
+// This is meant for FFI layers, or custom kernels, which want to operate on
+// the limbs of a number directly. The caller becomes responsible for maintaining
+// the invariants saferith relies on, which is why this requires a build tag.
+
+// UnsafeWords returns the limbs of this number, least significant first, without copying them
+//
+// Modifying the result modifies z. Bits past the announced length of z must stay
+// zero, and the value must stay below the modulus if z was reduced by one.
+func (z *Nat) UnsafeWords() []Word {
+	return z.limbs
+}
+
+// SetWordsNoCopy sets z to the number with the given limbs, taking ownership of them
+//
+// w must hold exactly the number of Words needed for cap bits, otherwise this
+// panics. Any bits of w past cap are cleared, which modifies w. Afterwards, w
+// and z share the same memory, so modifying one modifies the other.
+func (z *Nat) SetWordsNoCopy(w []Word, cap int) *Nat {
+	if len(w) != limbCount(cap) {
+		panic("SetWordsNoCopy: wrong number of limbs for capacity")
+	}
+	maskEnd(w, cap)
+	z.reduced = nil
+	z.announced = cap
+	z.limbs = w
+	return z
+}
@@ -0,0 +1,35 @@
+//go:build safenum_unsafe
+// +build safenum_unsafe
+
+package saferith
+
+import "testing"
+
+func TestUnsafeWordsShareMemory(t *testing.T) {
+	words := []Word{0xFF, 0x1}
+	x := new(Nat).SetWordsNoCopy(words, _W+1)
+	words[0] = 7
+	expected := new(Nat).Lsh(new(Nat).SetUint64(1), _W, -1)
+	expected.Add(expected, new(Nat).SetUint64(7), -1)
+	if expected.Eq(x) != 1 {
+		t.Errorf("%+v != %+v", expected, x)
+	}
+	x.UnsafeWords()[1] = 0
+	if words[1] != 0 || x.EqUint64(7) != 1 {
+		t.Errorf("%+v != %+v", 7, x)
+	}
+}
+
+func TestSetWordsNoCopyMasks(t *testing.T) {
+	words := []Word{0xFF}
+	x := new(Nat).SetWordsNoCopy(words, 4)
+	if words[0] != 0xF || x.EqUint64(0xF) != 1 || !x.checkInvariants() {
+		t.Errorf("%+v != %+v", 0xF, x)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic for the wrong number of limbs")
+		}
+	}()
+	new(Nat).SetWordsNoCopy(words, 2*_W)
+}
@@ -0,0 +1,45 @@
+package saferith
+
+import "sync/atomic"
+
+// zeroizationEnabled is 1 if temporary values should be wiped after use
+var zeroizationEnabled uint32
+
+// SetZeroization controls whether or not temporary values are wiped after use.
+//
+// When enabled, operations like ModMul, Exp, and ModInverse overwrite the
+// temporary values they create with zeros before returning, including any space
+// left over in the capacity of the result. This guarantees that no intermediate
+// values derived from secret inputs remain on the heap, at a small cost in speed.
+// Zeroization is disabled by default.
+//
+// Values stored in a Scratch passed explicitly to an operation are not wiped,
+// since the Scratch is meant to be reused. Use Scratch.Clear for this purpose.
+//
+// This can be called concurrently with other operations.
+func SetZeroization(enabled bool) {
+	var v uint32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreUint32(&zeroizationEnabled, v)
+}
+
+// zeroizing returns true if temporary values should be wiped after use.
+func zeroizing() bool {
+	return atomic.LoadUint32(&zeroizationEnabled) == 1
+}
+
+// wipeTemporaries clears temporary values, if zeroization is enabled.
+//
+// This also clears the unused capacity of the result z, which often contains
+// intermediate values, since operations use it as scratch space.
+func wipeTemporaries(z *Nat, temporaries ...*Nat) {
+	if !zeroizing() {
+		return
+	}
+	clearLimbs(z.limbs[len(z.limbs):])
+	for _, t := range temporaries {
+		t.Clear()
+	}
+}
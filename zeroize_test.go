@@ -0,0 +1,46 @@
+package saferith
+
+import (
+	"testing"
+	"testing/quick"
+)
+
+func testZeroizingMatchesBig(x Nat, y Nat, m Modulus) bool {
+	return testModMulMatchesBig(x, y, m) && testExpMatchesBig(x, y, m) && testModInverseCheckedMatchesBig(x, m) &&
+		testModDoubleTripleMatchModAdd(x, m) && testExpFixedBaseMatchesExp(x, y, m, 0) &&
+		testPedersenCommitMatchesMultiExp(x, y, y, x, m)
+}
+
+func TestZeroizingMatchesBig(t *testing.T) {
+	SetZeroization(true)
+	defer SetZeroization(false)
+	err := quick.Check(testZeroizingMatchesBig, &quick.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestZeroizationClearsUnusedCapacity(t *testing.T) {
+	SetZeroization(true)
+	defer SetZeroization(false)
+	for _, bytes := range [][]byte{modulus2048(), modulus2048Even()} {
+		m := ModulusFromBytes(bytes)
+		x := new(Nat).SetBytes(ones())
+		x.limbs[0] |= 1
+		for _, z := range []*Nat{
+			new(Nat).ModMul(x, x, m),
+			new(Nat).ModInverse(x, m),
+			new(Nat).Mod(new(Nat).Mul(x, x, -1), m),
+			new(Nat).ModDouble(x, m),
+			new(Nat).ModTriple(x, m),
+			new(Nat).ExpFixedBase(NewFixedBase(x, m, 64), x.Clone().Resize(64)),
+			NewPedersenParams(m, x, x, 64).Commit(x.Clone().Resize(64), x.Clone().Resize(64)),
+		} {
+			for _, limb := range z.limbs[len(z.limbs):cap(z.limbs)] {
+				if limb != 0 {
+					t.Errorf("unused limb %+v was not cleared", limb)
+				}
+			}
+		}
+	}
+}